@@ -0,0 +1,371 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// Control characters of the 3964(R) procedure.
+const (
+	stx = 0x02
+	etx = 0x03
+	dle = 0x10
+	nak = 0x15
+)
+
+// Procedure3964 configures the Siemens 3964 and 3964R link-layer procedures.
+type Procedure3964 struct {
+	// Bcc appends the block check character to the blocks. True for 3964R.
+	Bcc bool
+	// HighPriority wins the initialization conflict when both partners send STX at the same time.
+	// The partners must have different priorities.
+	HighPriority bool
+	// AckTimeout is the acknowledgement delay time (QVZ).
+	// The standard values are 2 s for 3964R and 550 ms for 3964.
+	AckTimeout time.Duration
+	// CharTimeout is the character delay time (ZVZ) between the received bytes of the block.
+	CharTimeout time.Duration
+	// ConnectAttempts is the amount of STX that are sent before the connection fails.
+	ConnectAttempts int
+	// SendAttempts is the amount of times that the block is sent before the transmission fails.
+	SendAttempts int
+}
+
+// Default3964R returns the standard values of the 3964R procedure with low priority.
+func Default3964R() Procedure3964 {
+	return Procedure3964{Bcc: true, AckTimeout: 2 * time.Second, CharTimeout: 220 * time.Millisecond,
+		ConnectAttempts: 6, SendAttempts: 6}
+}
+
+// Link3964 sends and receives blocks with the 3964(R) procedure over the serial port.
+// The link reads the raw bytes in the asynchronous mode, so the EOP of the media must not be set.
+// The received event of the media reports the raw bytes and the received blocks are
+// notified with the handler of the link.
+type Link3964 struct {
+	media     *GXSerial
+	procedure Procedure3964
+	sub       *frameSubscription
+	// Received bytes that are not handled yet.
+	pending []byte
+	send    chan *send3964
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	onBlock func(l *Link3964, data []byte)
+	// Received blocks that are not delivered to the handler yet.
+	blocks [][]byte
+	// notify wakes up the delivery of the received blocks.
+	notify chan struct{}
+}
+
+// send3964 is a block that is waiting to be sent.
+type send3964 struct {
+	data   []byte
+	result chan error
+}
+
+// notConnectedError is returned when the partner doesn't answer to STX with DLE.
+type notConnectedError struct {
+	msg string
+}
+
+// Error implements error.
+func (e *notConnectedError) Error() string {
+	return e.msg
+}
+
+// NewLink3964 starts the 3964(R) procedure on the media.
+func NewLink3964(media *GXSerial, procedure Procedure3964) (*Link3964, error) {
+	if media == nil || procedure.AckTimeout <= 0 || procedure.CharTimeout <= 0 ||
+		procedure.ConnectAttempts < 1 || procedure.SendAttempts < 1 {
+		return nil, gxcommon.ErrInvalidArgument
+	}
+	l := &Link3964{media: media, procedure: procedure, send: make(chan *send3964), done: make(chan struct{}),
+		notify: make(chan struct{}, 1)}
+	l.sub = media.subscribeFrames(0, false)
+	l.sub.persistent = true
+	l.wg.Add(1)
+	go l.run()
+	go l.deliver()
+	return l, nil
+}
+
+// SetOnReceived sets the handler that is called with the data of the received blocks.
+// The handler is called in its own goroutine in the order of the received blocks,
+// so it can answer with Send.
+func (l *Link3964) SetOnReceived(value func(l *Link3964, data []byte)) {
+	l.mu.Lock()
+	l.onBlock = value
+	l.mu.Unlock()
+}
+
+// Send sends the block and waits until the partner acknowledges it.
+func (l *Link3964) Send(data []byte) error {
+	s := &send3964{data: data, result: make(chan error, 1)}
+	select {
+	case l.send <- s:
+	case <-l.done:
+		return errors.New(localize(l.media.p, MsgPortNotOpen, l.media.Port))
+	}
+	return <-s.result
+}
+
+// Close stops the procedure. The serial port is not closed.
+// The received blocks that are not delivered to the handler are dropped.
+func (l *Link3964) Close() {
+	l.once.Do(func() {
+		close(l.done)
+		l.sub.cancel()
+	})
+	l.wg.Wait()
+}
+
+// run handles the received bytes and the sent blocks.
+func (l *Link3964) run() {
+	defer l.wg.Done()
+	for {
+		if len(l.pending) != 0 {
+			b := l.pending[0]
+			l.pending = l.pending[1:]
+			if b == stx {
+				l.receive()
+			}
+			continue
+		}
+		select {
+		case r := <-l.sub.ch:
+			l.pending = append(l.pending, r.data...)
+		case s := <-l.send:
+			s.result <- l.transmit(s.data)
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// deliver calls the handler with the received blocks.
+// The handler isn't called from run, because Send waits for run to transmit the block.
+func (l *Link3964) deliver() {
+	for {
+		select {
+		case <-l.notify:
+		case <-l.done:
+			return
+		}
+		for {
+			l.mu.Lock()
+			if len(l.blocks) == 0 {
+				l.mu.Unlock()
+				break
+			}
+			data := l.blocks[0]
+			l.blocks = l.blocks[1:]
+			cb := l.onBlock
+			l.mu.Unlock()
+			if cb != nil {
+				cb(l, data)
+			}
+		}
+	}
+}
+
+// readByte returns the next received byte or an error if it's not received before the timeout.
+func (l *Link3964) readByte(timeout time.Duration) (byte, error) {
+	if len(l.pending) == 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for len(l.pending) == 0 {
+			select {
+			case r := <-l.sub.ch:
+				l.pending = append(l.pending, r.data...)
+			case <-timer.C:
+				return 0, os.ErrDeadlineExceeded
+			case <-l.done:
+				return 0, errors.New(localize(l.media.p, MsgPortNotOpen, l.media.Port))
+			}
+		}
+	}
+	b := l.pending[0]
+	l.pending = l.pending[1:]
+	return b, nil
+}
+
+// write sends the bytes.
+func (l *Link3964) write(data ...byte) error {
+	return l.media.Send(data, "")
+}
+
+// block returns the block with the doubled DLE bytes, DLE ETX and the BCC.
+func (l *Link3964) block(data []byte) []byte {
+	ret := make([]byte, 0, len(data)+4)
+	for _, b := range data {
+		if b == dle {
+			ret = append(ret, dle)
+		}
+		ret = append(ret, b)
+	}
+	ret = append(ret, dle, etx)
+	if l.procedure.Bcc {
+		ret = append(ret, Bcc(ret))
+	}
+	return ret
+}
+
+// connect sends STX until the partner answers with DLE.
+func (l *Link3964) connect() error {
+	var err error
+	for attempt := 0; attempt < l.procedure.ConnectAttempts; attempt++ {
+		if err = l.write(stx); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(l.procedure.AckTimeout)
+		for {
+			var b byte
+			if b, err = l.readByte(time.Until(deadline)); err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					break
+				}
+				return err
+			}
+			if b == dle {
+				return nil
+			}
+			if b == stx {
+				if l.procedure.HighPriority {
+					// The partner waits until this block is sent.
+					continue
+				}
+				// Low priority receives the block of the partner first.
+				l.receive()
+			}
+			err = &notConnectedError{msg: localize(l.media.p, MsgNoDle)}
+			break
+		}
+	}
+	return err
+}
+
+// transmit sends the block with the 3964(R) procedure.
+func (l *Link3964) transmit(data []byte) error {
+	block := l.block(data)
+	attempts := l.procedure.SendAttempts
+	var err error
+	for attempt := 0; attempt < l.procedure.SendAttempts; attempt++ {
+		if attempt != 0 {
+			l.media.tracef(true, gxcommon.TraceTypesInfo, "Retransmit %d/%d", attempt, l.procedure.SendAttempts-1)
+		}
+		if err = l.connect(); err != nil {
+			var nc *notConnectedError
+			if errors.As(err, &nc) || errors.Is(err, os.ErrDeadlineExceeded) {
+				attempts = l.procedure.ConnectAttempts
+				break
+			}
+			return err
+		}
+		if err = l.media.Send(block, ""); err != nil {
+			return err
+		}
+		var b byte
+		if b, err = l.readByte(l.procedure.AckTimeout); err == nil {
+			if b == dle {
+				return nil
+			}
+			err = errors.New(localize(l.media.p, MsgNak))
+		} else if !errors.Is(err, os.ErrDeadlineExceeded) {
+			return err
+		}
+	}
+	err = fmt.Errorf("%s: %w", localize(l.media.p, MsgNotAcknowledged, l.media.Port, attempts), err)
+	l.media.errorf(true, "send", err)
+	return err
+}
+
+// receive receives the block after STX and acknowledges it.
+func (l *Link3964) receive() {
+	if err := l.write(dle); err != nil {
+		return
+	}
+	var raw, data []byte
+	for {
+		b, err := l.readByte(l.procedure.CharTimeout)
+		if err != nil {
+			_ = l.write(nak)
+			return
+		}
+		raw = append(raw, b)
+		if b != dle {
+			data = append(data, b)
+			continue
+		}
+		if b, err = l.readByte(l.procedure.CharTimeout); err != nil {
+			_ = l.write(nak)
+			return
+		}
+		raw = append(raw, b)
+		if b == dle {
+			data = append(data, dle)
+			continue
+		}
+		if b != etx {
+			_ = l.write(nak)
+			return
+		}
+		break
+	}
+	if l.procedure.Bcc {
+		b, err := l.readByte(l.procedure.CharTimeout)
+		if err != nil || b != Bcc(raw) {
+			_ = l.write(nak)
+			return
+		}
+	}
+	if err := l.write(dle); err != nil {
+		return
+	}
+	l.mu.Lock()
+	l.blocks = append(l.blocks, data)
+	l.mu.Unlock()
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,118 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// AckPolicy defines how the receiver acknowledges the data of SendAcknowledged.
+type AckPolicy struct {
+	// Ack is the positive acknowledgement, e.g. ACK (0x06).
+	Ack []byte
+	// Nak is the negative acknowledgement, e.g. NAK (0x15).
+	// The data is retransmitted when it's received. Nil if the receiver doesn't send NAK.
+	Nak []byte
+	// Timeout is the maximum time to wait for the acknowledgement.
+	// The data is retransmitted if the acknowledgement is not received in time.
+	Timeout time.Duration
+	// Retries is the amount of retransmissions before an error is returned.
+	Retries int
+}
+
+// match finds the first acknowledgement from the received data.
+func (p *AckPolicy) match(data []byte) (int, bool) {
+	end := -1
+	if pos := bytes.Index(data, p.Ack); pos != -1 {
+		end = pos + len(p.Ack)
+	}
+	if len(p.Nak) != 0 {
+		if pos := bytes.Index(data, p.Nak); pos != -1 && (end == -1 || pos+len(p.Nak) < end) {
+			end = pos + len(p.Nak)
+		}
+	}
+	return end, end != -1
+}
+
+// AckPolicy returns the acknowledgement policy. Nil if it's not set.
+func (g *GXSerial) AckPolicy() *AckPolicy {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.ackPolicy
+}
+
+// SetAckPolicy sets the acknowledgement policy of SendAcknowledged.
+func (g *GXSerial) SetAckPolicy(value *AckPolicy) error {
+	if value != nil && (len(value.Ack) == 0 || value.Timeout <= 0 || value.Retries < 0) {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	g.ackPolicy = value
+	g.mu.Unlock()
+	return nil
+}
+
+// SendAcknowledged sends data and waits until the receiver acknowledges it.
+// On NAK or timeout the data is retransmitted up to AckPolicy.Retries times before an error is returned.
+// If the acknowledgement policy is not set, data is sent without waiting.
+func (g *GXSerial) SendAcknowledged(data any) error {
+	policy := g.AckPolicy()
+	if policy == nil {
+		return g.Send(data, "")
+	}
+	var err error
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt != 0 {
+			g.tracef(true, gxcommon.TraceTypesInfo, "Retransmit %d/%d", attempt, policy.Retries)
+		}
+		var reply []byte
+		reply, err = g.SendRequest(data, policy.match, policy.Timeout).Wait()
+		if err == nil {
+			if bytes.HasSuffix(reply, policy.Ack) {
+				return nil
+			}
+			err = errors.New(localize(g.p, MsgNak))
+		} else if !g.s.isOpen() {
+			return err
+		}
+	}
+	err = fmt.Errorf("%s: %w", localize(g.p, MsgNotAcknowledged, g.Port, policy.Retries+1), err)
+	g.errorf(true, "send", err)
+	return err
+}
@@ -0,0 +1,72 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// activityTime is the time of the last transfer. It holds both the wall clock and the monotonic clock reading.
+type activityTime struct {
+	t atomic.Pointer[time.Time]
+}
+
+// store saves the time of the transfer.
+func (a *activityTime) store(t time.Time) {
+	a.t.Store(&t)
+}
+
+// load returns the time of the last transfer. Zero time is returned if nothing is transferred.
+func (a *activityTime) load() time.Time {
+	if t := a.t.Load(); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
+// GetLastReceivedTime returns the time when data was received last time.
+// The returned time holds the monotonic clock reading, so time.Since(t) is not affected by the changes
+// of the wall clock, and t.Round(0) is the wall clock time, e.g. for "last seen" in UIs.
+// Zero time is returned if nothing is received. The time is kept when the port is closed and reopened.
+func (g *GXSerial) GetLastReceivedTime() time.Time {
+	return g.lastRx.load()
+}
+
+// GetLastSentTime returns the time when data was written last time to the serial port.
+// See GetLastReceivedTime for the clock readings.
+// Zero time is returned if nothing is sent. The time is kept when the port is closed and reopened.
+func (g *GXSerial) GetLastSentTime() time.Time {
+	return g.lastTx.load()
+}
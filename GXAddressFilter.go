@@ -0,0 +1,99 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "bytes"
+
+// AddressFilter passes only the frames that are addressed to this station.
+// It's used on shared buses, e.g. RS-485, where all stations receive all frames.
+type AddressFilter struct {
+	// Address is the address of this station.
+	Address []byte
+	// Offset is the position of the address in the frame. It's used when Extract is nil.
+	Offset int
+	// Extract returns the address of the frame. False is returned if the frame has no address.
+	Extract func(frame []byte) ([]byte, bool)
+	// Broadcast are the addresses that all stations accept.
+	Broadcast [][]byte
+}
+
+// address returns the address of the frame.
+func (f *AddressFilter) address(frame []byte) ([]byte, bool) {
+	if f.Extract != nil {
+		return f.Extract(frame)
+	}
+	end := f.Offset + len(f.Address)
+	if f.Offset < 0 || end > len(frame) {
+		return nil, false
+	}
+	return frame[f.Offset:end], true
+}
+
+// accept returns true if the frame is addressed to this station.
+func (f *AddressFilter) accept(frame []byte) bool {
+	addr, ok := f.address(frame)
+	if !ok {
+		return false
+	}
+	if bytes.Equal(addr, f.Address) {
+		return true
+	}
+	for _, it := range f.Broadcast {
+		if bytes.Equal(addr, it) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddressFilter returns the address filter. Nil if all frames are received.
+func (g *GXSerial) AddressFilter() *AddressFilter {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.addressFilter
+}
+
+// SetAddressFilter sets the filter that drops the received frames that are not addressed to this station.
+// Frames are completed when the EOP is set. Dropped frames are counted and they are not notified.
+// Nil disables the filter.
+func (g *GXSerial) SetAddressFilter(value *AddressFilter) {
+	g.mu.Lock()
+	g.addressFilter = value
+	g.mu.Unlock()
+}
+
+// DiscardedFrames returns the amount of the frames that the address filter has dropped.
+func (g *GXSerial) DiscardedFrames() uint64 {
+	return g.discardedFrames.Load()
+}
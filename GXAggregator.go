@@ -0,0 +1,187 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// AggregatedFrame is a frame that is received from one of the aggregated ports.
+type AggregatedFrame struct {
+	// Media is the serial port where the frame is received.
+	Media *GXSerial
+	// Port is the name of the serial port where the frame is received.
+	Port string
+	// Data is the content of the frame.
+	Data []byte
+	// Err is the framing error if the frame is invalid.
+	Err error
+	// Time is the time when the frame was completed.
+	Time time.Time
+	// Seq is the sequence number of the frame in the aggregated stream.
+	Seq uint64
+}
+
+// Aggregator merges the received frames of several serial ports into one stream.
+// Each frame is tagged with the port where it's received.
+// Frames are received in the asynchronous mode only, and the received events of the ports are notified as well.
+// The ports can be opened, closed and reopened while they are aggregated.
+type Aggregator struct {
+	mu      sync.Mutex
+	sources map[*GXSerial]*frameSubscription
+	closed  bool
+	// Keeps the order of the sequence numbers and the frames in the channel.
+	sendMu sync.Mutex
+	seq    uint64
+	ch     chan AggregatedFrame
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewAggregator returns a new aggregator.
+// The buffer is the capacity of the channel. When it's full, the frames of the ports are buffered up to
+// 16 frames per port and then dropped, see GXSerial.DroppedFrames. The readers of the ports never wait for the consumer.
+func NewAggregator(buffer int) *Aggregator {
+	return &Aggregator{
+		sources: make(map[*GXSerial]*frameSubscription),
+		ch:      make(chan AggregatedFrame, max(buffer, 0)),
+		done:    make(chan struct{}),
+	}
+}
+
+// Frames returns the channel that receives the frames of all aggregated ports in the order they are received.
+// The channel is closed when the aggregator is closed.
+func (a *Aggregator) Frames() <-chan AggregatedFrame {
+	return a.ch
+}
+
+// Add adds the serial ports to the aggregator. The ports that are already added are ignored.
+func (a *Aggregator) Add(media ...*GXSerial) error {
+	for _, m := range media {
+		if m == nil {
+			return gxcommon.ErrInvalidArgument
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, m := range media {
+		if a.closed {
+			return errors.New(localize(m.p, MsgAggregatorClosed))
+		}
+		if _, ok := a.sources[m]; ok {
+			continue
+		}
+		s := m.subscribeFrames(framesBuffer, true)
+		m.frameMu.Lock()
+		s.persistent = true
+		m.frameMu.Unlock()
+		a.sources[m] = s
+		a.wg.Add(1)
+		go a.forward(m, s)
+	}
+	return nil
+}
+
+// Remove removes the serial port from the aggregator.
+func (a *Aggregator) Remove(media *GXSerial) {
+	a.mu.Lock()
+	s, ok := a.sources[media]
+	delete(a.sources, media)
+	a.mu.Unlock()
+	if ok {
+		s.cancel()
+	}
+}
+
+// Sources returns the aggregated serial ports.
+func (a *Aggregator) Sources() []*GXSerial {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ret := make([]*GXSerial, 0, len(a.sources))
+	for m := range a.sources {
+		ret = append(ret, m)
+	}
+	return ret
+}
+
+// Close removes all serial ports from the aggregator and closes the channel.
+// The serial ports are not closed.
+func (a *Aggregator) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.done)
+	sources := a.sources
+	a.sources = nil
+	a.mu.Unlock()
+	for _, s := range sources {
+		s.cancel()
+	}
+	a.wg.Wait()
+	close(a.ch)
+}
+
+// forward passes the frames of the port to the aggregated stream.
+func (a *Aggregator) forward(m *GXSerial, s *frameSubscription) {
+	defer a.wg.Done()
+	for {
+		select {
+		case r := <-s.ch:
+			a.send(m, s, r)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// send adds the frame to the aggregated stream.
+// The frame is dropped if the port is removed while the consumer is waited.
+func (a *Aggregator) send(m *GXSerial, s *frameSubscription, r frameResult) {
+	a.sendMu.Lock()
+	defer a.sendMu.Unlock()
+	f := AggregatedFrame{Media: m, Port: m.GetName(), Data: r.data, Err: r.err, Time: r.time, Seq: a.seq + 1}
+	select {
+	case a.ch <- f:
+		a.seq++
+	case <-a.done:
+	case <-s.done:
+	}
+}
@@ -0,0 +1,89 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// High baud rates that are not defined in gxcommon.
+const (
+	// BaudRate500000 defines that the baudrate is 500000.
+	BaudRate500000 gxcommon.BaudRate = 500000
+	// BaudRate576000 defines that the baudrate is 576000.
+	BaudRate576000 gxcommon.BaudRate = 576000
+	// BaudRate1000000 defines that the baudrate is 1000000.
+	BaudRate1000000 gxcommon.BaudRate = 1000000
+	// BaudRate1152000 defines that the baudrate is 1152000.
+	BaudRate1152000 gxcommon.BaudRate = 1152000
+	// BaudRate1500000 defines that the baudrate is 1500000.
+	BaudRate1500000 gxcommon.BaudRate = 1500000
+	// BaudRate2000000 defines that the baudrate is 2000000.
+	BaudRate2000000 gxcommon.BaudRate = 2000000
+	// BaudRate2500000 defines that the baudrate is 2500000.
+	BaudRate2500000 gxcommon.BaudRate = 2500000
+	// BaudRate3000000 defines that the baudrate is 3000000.
+	BaudRate3000000 gxcommon.BaudRate = 3000000
+	// BaudRate3500000 defines that the baudrate is 3500000.
+	BaudRate3500000 gxcommon.BaudRate = 3500000
+	// BaudRate4000000 defines that the baudrate is 4000000.
+	BaudRate4000000 gxcommon.BaudRate = 4000000
+)
+
+// highBaudRates are the high standard baud rates of the USB serial adapters.
+var highBaudRates = []gxcommon.BaudRate{gxcommon.BaudRate230400, gxcommon.BaudRate460800, gxcommon.BaudRate921600,
+	BaudRate1000000, BaudRate2000000, BaudRate3000000, BaudRate4000000}
+
+// parseBaudRate parses the baud rate. gxcommon knows the baud rates up to 921600,
+// so the other values are parsed as numbers.
+func parseBaudRate(value string) (gxcommon.BaudRate, error) {
+	ret, err := gxcommon.BaudRateParse(value)
+	if err != nil {
+		n, e := strconv.Atoi(value)
+		if e != nil || n <= 0 {
+			return 0, err
+		}
+		ret, err = gxcommon.BaudRate(n), nil
+	}
+	return ret, err
+}
+
+// baudRateString returns the baud rate as a number. gxcommon.BaudRate.String
+// returns an empty string for the baud rates it doesn't know.
+func baudRateString(value gxcommon.BaudRate) string {
+	return fmt.Sprintf("%d", int(value))
+}
@@ -0,0 +1,163 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// SendBreak holds the transmit line in the break condition for the given duration.
+// Several meter and bootloader protocols require a break before the communication.
+// The data that is already sent is transmitted before the break starts and
+// Send waits until the break ends. See Capabilities.Break.
+func (g *GXSerial) SendBreak(duration time.Duration) error {
+	if duration <= 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if err := g.checkWritable(); err != nil {
+		return err
+	}
+	if !g.s.capabilities().Break {
+		return g.unsupported("Break", duration)
+	}
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	defer g.sendLock.release()
+	if err := g.s.waitTxEmpty(g.charTime()); err != nil {
+		return err
+	}
+	if err := g.s.setBreak(true); err != nil {
+		return err
+	}
+	time.Sleep(duration)
+	if err := g.s.setBreak(false); err != nil {
+		return err
+	}
+	g.trace(true, gxcommon.TraceTypesInfo, localize(g.p, MsgBreakSent, duration, g.Port))
+	return nil
+}
+
+// BreakEventArgs describes the received break condition.
+type BreakEventArgs struct {
+	// Time is the time when the break was detected.
+	Time time.Time
+}
+
+// BreakEventHandler is called when a break condition is received.
+type BreakEventHandler func(m gxcommon.IGXMedia, e BreakEventArgs)
+
+// SetOnBreak sets the handler that is called when a break condition is received.
+// On Linux and macOS the driver marks the breaks (PARMRK) and the marks are removed from the data,
+// so a break doesn't corrupt the byte stream. On Windows the break is read from the communication
+// errors (CE_BREAK) after the data is received and the null byte that the driver inserts for the break
+// is removed from the data.
+// The break is also traced as info. Breaks are not detected when 9-bit receive is enabled.
+// If the port is open, the change is applied immediately. Nil disables the detection.
+func (g *GXSerial) SetOnBreak(value BreakEventHandler) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() && (value != nil) != (g.onBreak != nil) {
+		if err := g.applyBreakDetection(value != nil); err != nil {
+			return err
+		}
+	}
+	g.onBreak = value
+	return nil
+}
+
+// applyBreakDetection sets the break detection to the driver. The caller holds the lock.
+func (g *GXSerial) applyBreakDetection(on bool) error {
+	if !on && parityMarking && (g.nineBitReceive || g.parityErrorCharOn) {
+		// The marks are still used.
+		return nil
+	}
+	return g.s.setBreakDetection(on)
+}
+
+// breakReceived notifies the received break.
+func (g *GXSerial) breakReceived() {
+	g.mu.RLock()
+	cb := g.onBreak
+	g.mu.RUnlock()
+	g.trace(true, gxcommon.TraceTypesInfo, localize(g.p, MsgBreakReceived, g.Port))
+	if cb != nil {
+		e := BreakEventArgs{Time: time.Now()}
+		g.callHandler(true, "Break", func() {
+			cb(g, e)
+		})
+	}
+}
+
+// checkBreak notifies the break that the driver reports outside of the data and
+// removes the null byte that the driver inserts for the break.
+// The break is reported after the null byte is received, so the last null byte of the data is removed.
+// If the data has no null byte, the null byte is not read yet and it's removed from the start of the next read.
+func (g *GXSerial) checkBreak(data []byte) []byte {
+	if parityMarking {
+		return data
+	}
+	g.mu.RLock()
+	on := g.onBreak != nil
+	g.mu.RUnlock()
+	if !on {
+		return data
+	}
+	if g.breakNull && len(data) != 0 {
+		g.breakNull = false
+		if data[0] == 0 {
+			data = data[1:]
+		}
+	}
+	brk, err := g.s.takeBreak()
+	if err != nil {
+		g.log(slog.LevelWarn, "read break state failed", "err", err)
+	} else if brk {
+		if pos := bytes.LastIndexByte(data, 0); pos != -1 {
+			data = append(data[:pos], data[pos+1:]...)
+		} else {
+			g.breakNull = true
+		}
+		g.breakReceived()
+	}
+	return data
+}
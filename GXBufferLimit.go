@@ -0,0 +1,79 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// BufferOverflowError is returned by Receive when the synchronous receive buffer exceeds its limit
+// before the EOP or the requested amount of bytes is received. The buffered data is discarded.
+type BufferOverflowError struct {
+	// Limit is the maximum size of the buffer in bytes.
+	Limit int
+	msg   string
+}
+
+// Error implements error.
+func (e *BufferOverflowError) Error() string {
+	return e.msg
+}
+
+// ReceiveBufferLimit returns the maximum size of the synchronous receive buffer in bytes.
+// Zero if the size is not limited.
+func (g *GXSerial) ReceiveBufferLimit() int {
+	return g.received.Limit()
+}
+
+// SetReceiveBufferLimit sets the maximum size of the synchronous receive buffer in bytes.
+// When received data doesn't fit in the buffer, the buffered data is discarded and the pending
+// or the next Receive returns *BufferOverflowError. The limit also applies to the data of a frame
+// that is not ended yet when the receive filter or the address filter frames the data. Zero doesn't limit the size.
+func (g *GXSerial) SetReceiveBufferLimit(value int) error {
+	if value < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.received.SetLimit(value)
+	return nil
+}
+
+// bufferOverflow returns the error of the overflowed synchronous receive buffer.
+func (g *GXSerial) bufferOverflow() error {
+	limit := g.received.Limit()
+	err := &BufferOverflowError{Limit: limit, msg: localize(g.p, MsgBufferOverflow, g.Port, limit)}
+	g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: "receive", Err: err})
+	return err
+}
@@ -0,0 +1,97 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// DiscardNull returns true if the driver discards the received null bytes.
+func (g *GXSerial) DiscardNull() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.discardNull
+}
+
+// SetDiscardNull sets if the driver discards the received null bytes (fNull of DCB).
+// Some legacy devices fill the line with null bytes. See Capabilities.DiscardNull.
+// If the port is open, the change is applied immediately.
+// *UnsupportedSettingError is returned if the driver can't discard the null bytes.
+func (g *GXSerial) SetDiscardNull(value bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() && value != g.discardNull {
+		if err := g.applyDiscardNull(value); err != nil {
+			return err
+		}
+	}
+	g.discardNull = value
+	return nil
+}
+
+// applyDiscardNull sets the null byte discarding to the driver. The caller holds the lock.
+func (g *GXSerial) applyDiscardNull(value bool) error {
+	if !g.s.capabilities().DiscardNull {
+		return g.unsupported("DiscardNull", value)
+	}
+	return g.s.setDiscardNull(value)
+}
+
+// AbortOnError returns true if the driver aborts the pending reads and writes when a line error occurs.
+func (g *GXSerial) AbortOnError() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.abortOnError
+}
+
+// SetAbortOnError sets if the driver aborts the pending reads and writes when a line error,
+// e.g. a parity or framing error, occurs (fAbortOnError of DCB). The reader clears the error
+// and continues reading also when KeepOpen is not set. Use SetParityErrorChar to replace the bytes with a parity error.
+// See Capabilities.AbortOnError. If the port is open, the change is applied immediately.
+// *UnsupportedSettingError is returned if the driver doesn't support the mode.
+func (g *GXSerial) SetAbortOnError(value bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() && value != g.abortOnError {
+		if err := g.applyAbortOnError(value); err != nil {
+			return err
+		}
+	}
+	g.abortOnError = value
+	return nil
+}
+
+// applyAbortOnError sets the abort on error mode to the driver. The caller holds the lock.
+func (g *GXSerial) applyAbortOnError(value bool) error {
+	if !g.s.capabilities().AbortOnError {
+		return g.unsupported("AbortOnError", value)
+	}
+	return g.s.setAbortOnError(value)
+}
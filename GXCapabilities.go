@@ -0,0 +1,94 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "github.com/Gurux/gxcommon-go"
+
+// Capabilities describes what the current platform and serial port support.
+// Cross-platform applications can use it to adapt before the settings are applied.
+type Capabilities struct {
+	// MarkSpaceParity is true if mark and space parity are supported.
+	MarkSpaceParity bool
+	// CustomBaudRate is true if baud rates outside of BaudRates can be used.
+	CustomBaudRate bool
+	// NineBitReceive is true if the 9th bit of the received bytes can be reported.
+	NineBitReceive bool
+	// RS485 is true if the native RS-485 mode of the driver can be used.
+	RS485 bool
+	// LowLatency is true if the low latency mode of the driver can be used.
+	LowLatency bool
+	// Loopback is true if the internal loopback mode of the driver can be used.
+	Loopback bool
+	// DiscardNull is true if the driver can discard the received null bytes.
+	DiscardNull bool
+	// AbortOnError is true if the driver can abort the pending reads and writes on a line error.
+	AbortOnError bool
+	// Break is true if a break condition can be sent.
+	Break bool
+	// ControlLines is true if RTS and DTR lines can be set.
+	ControlLines bool
+	// ModemStatus is true if CTS, DSR, DCD and RI lines can be read.
+	ModemStatus bool
+	// OnePointFiveStopBits is true if 1.5 stop bits are supported.
+	OnePointFiveStopBits bool
+	// BaudRates are the supported standard baud rates.
+	BaudRates []gxcommon.BaudRate
+}
+
+// SupportsBaudRate returns true if the given baud rate can be used.
+func (c *Capabilities) SupportsBaudRate(value gxcommon.BaudRate) bool {
+	if c.CustomBaudRate {
+		return value > 0
+	}
+	for _, it := range c.BaudRates {
+		if it == value {
+			return true
+		}
+	}
+	return false
+}
+
+// UnsupportedSettingError is returned when a serial port setting can't be used on this platform.
+type UnsupportedSettingError struct {
+	// Setting is the name of the unsupported setting, e.g. "BaudRate".
+	Setting string
+	// Value is the unsupported value.
+	Value any
+	msg   string
+}
+
+// Error implements error.
+func (e *UnsupportedSettingError) Error() string {
+	return e.msg
+}
@@ -0,0 +1,208 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// Checksum calculates the checksum that is appended to the sent frames and verified from the received frames.
+type Checksum interface {
+	// Size returns the size of the checksum in bytes.
+	Size() int
+	// Sum returns the checksum of data.
+	Sum(data []byte) []byte
+}
+
+// checksum is a built-in checksum.
+type checksum struct {
+	name string
+	size int
+	sum  func(data []byte) []byte
+}
+
+// Size implements Checksum.
+func (c checksum) Size() int {
+	return c.size
+}
+
+// Sum implements Checksum.
+func (c checksum) Sum(data []byte) []byte {
+	return c.sum(data)
+}
+
+// String returns the name of the checksum.
+func (c checksum) String() string {
+	return c.name
+}
+
+// Built-in checksums.
+var (
+	// ChecksumCrc16Ccitt is CRC-16/CCITT-FALSE in big endian byte order.
+	ChecksumCrc16Ccitt Checksum = checksum{"CRC16-CCITT", 2, func(data []byte) []byte {
+		return binary.BigEndian.AppendUint16(nil, Crc16Ccitt(data))
+	}}
+	// ChecksumCrc16Modbus is CRC-16/MODBUS in little endian byte order.
+	ChecksumCrc16Modbus Checksum = checksum{"CRC16-Modbus", 2, func(data []byte) []byte {
+		return binary.LittleEndian.AppendUint16(nil, Crc16Modbus(data))
+	}}
+	// ChecksumCrc32 is IEEE CRC-32 in little endian byte order.
+	ChecksumCrc32 Checksum = checksum{"CRC-32", 4, func(data []byte) []byte {
+		return binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+	}}
+	// ChecksumLrc is the longitudinal redundancy check of Modbus ASCII.
+	ChecksumLrc Checksum = checksum{"LRC", 1, func(data []byte) []byte {
+		return []byte{Lrc(data)}
+	}}
+	// ChecksumBcc is the XOR block check character.
+	ChecksumBcc Checksum = checksum{"BCC", 1, func(data []byte) []byte {
+		return []byte{Bcc(data)}
+	}}
+)
+
+// Crc16Ccitt returns CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF) of data.
+func Crc16Ccitt(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Crc16X25 returns CRC-16/X-25 (reflected polynomial 0x8408, initial value 0xFFFF, inverted result) of data.
+func Crc16X25(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
+
+// Crc16Dnp returns CRC-16/DNP (reflected polynomial 0xA6BC, initial value 0, inverted result) of data.
+func Crc16Dnp(data []byte) uint16 {
+	crc := uint16(0)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA6BC
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
+
+// Crc16Modbus returns CRC-16/MODBUS (reflected polynomial 0xA001, initial value 0xFFFF) of data.
+func Crc16Modbus(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Lrc returns the two's complement of the sum of the bytes.
+func Lrc(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return -sum
+}
+
+// Bcc returns XOR of the bytes.
+func Bcc(data []byte) byte {
+	var ret byte
+	for _, b := range data {
+		ret ^= b
+	}
+	return ret
+}
+
+// ChecksumError is returned when the checksum of the received frame doesn't match.
+type ChecksumError struct {
+	// Frame is the received frame including the checksum.
+	Frame []byte
+	// Expected is the calculated checksum.
+	Expected []byte
+	// Actual is the received checksum.
+	Actual []byte
+	msg    string
+}
+
+// Error implements error.
+func (e *ChecksumError) Error() string {
+	return e.msg
+}
+
+// verifyChecksum verifies the checksum at the end of the frame and returns the frame without the checksum.
+func (g *GXSerial) verifyChecksum(c Checksum, frame []byte) ([]byte, error) {
+	size := c.Size()
+	if len(frame) < size {
+		return nil, &ChecksumError{Frame: frame,
+			msg: localize(g.p, MsgChecksumMismatch, []byte(nil), frame)}
+	}
+	payload, actual := frame[:len(frame)-size], frame[len(frame)-size:]
+	expected := c.Sum(payload)
+	if !bytes.Equal(expected, actual) {
+		return nil, &ChecksumError{Frame: frame, Expected: expected, Actual: actual,
+			msg: localize(g.p, MsgChecksumMismatch, expected, actual)}
+	}
+	return payload, nil
+}
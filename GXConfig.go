@@ -0,0 +1,322 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// Config is a complete serial port configuration.
+// The config package reads it from YAML or TOML documents, e.g.
+//
+//	port: /dev/ttyUSB0
+//	baudRate: 9600
+//	dataBits: 7
+//	parity: Even
+//	stopBits: One
+//	eop: [0x0D, 0x0A]
+//	trace: Verbose
+//	connectTimeout: 5s
+//	writeTimeout: 1s
+//
+// The EOP is a string, a byte or a list of bytes.
+type Config struct {
+	Port           string
+	BaudRate       gxcommon.BaudRate
+	DataBits       int
+	Parity         gxcommon.Parity
+	StopBits       gxcommon.StopBits
+	Eop            any
+	Trace          gxcommon.TraceLevel
+	ConnectTimeout time.Duration
+	WriteTimeout   time.Duration
+}
+
+// DefaultConfig returns the configuration that is used for the settings that are not given: 9600 8N1.
+func DefaultConfig() *Config {
+	return &Config{BaudRate: gxcommon.BaudRate9600, DataBits: 8, Parity: gxcommon.ParityNone, StopBits: gxcommon.StopBitsOne}
+}
+
+// Apply configures the media.
+func (c *Config) Apply(media *GXSerial) error {
+	media.Port = c.Port
+	if err := media.SetBaudRate(c.BaudRate); err != nil {
+		return err
+	}
+	if err := media.SetDataBits(c.DataBits); err != nil {
+		return err
+	}
+	if err := media.SetParity(c.Parity); err != nil {
+		return err
+	}
+	if err := media.SetStopBits(c.StopBits); err != nil {
+		return err
+	}
+	media.SetEop(c.Eop)
+	if err := media.SetTrace(c.Trace); err != nil {
+		return err
+	}
+	if err := media.SetConnectTimeout(c.ConnectTimeout); err != nil {
+		return err
+	}
+	return media.SetWriteTimeout(c.WriteTimeout)
+}
+
+// SetValue parses the named setting and stores it, e.g. SetValue("baudRate", "9600").
+// The names are port, baudRate, dataBits, parity, stopBits, eop, trace, connectTimeout and writeTimeout.
+// The EOP is a comma separated list of bytes, e.g. 0x0D,0x0A, or a string.
+func (c *Config) SetValue(name, value string) error {
+	doc := newConfigDocument(c)
+	s, ok := doc.values()[name]
+	if !ok {
+		return fmt.Errorf("%s: %w", localizeDefault(MsgUnknownSetting, name), gxcommon.ErrInvalidArgument)
+	}
+	if err := s.parse(value); err != nil {
+		return fmt.Errorf("%s: %w", localizeDefault(MsgInvalidSetting, name, value), err)
+	}
+	*c = *doc.config()
+	return nil
+}
+
+// NewGXSerialFromConfig creates a GXSerial with the given configuration.
+// The configuration is validated.
+func NewGXSerialFromConfig(c *Config) (*GXSerial, error) {
+	g := NewGXSerial(c.Port, c.BaudRate, c.DataBits, c.Parity, c.StopBits)
+	if err := c.Apply(g); err != nil {
+		return nil, err
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ParseModeString parses the device:baud,format shorthand of the serial tools,
+// e.g. COM3:9600,8N1 or ttyUSB0:19200,7E1.
+// The format is the data bits, the parity (N, E, O, M or S) and the stop bits (1, 1.5 or 2).
+// The format can be omitted and then 8N1 is used. On Linux and macOS the device is searched from /dev
+// if it's not a path.
+func ParseModeString(text string) (*Config, error) {
+	c := DefaultConfig()
+	pos := strings.LastIndex(text, ":")
+	if pos < 1 {
+		return nil, errors.New(localizeDefault(MsgInvalidModeString, text))
+	}
+	c.Port = devicePath(text[:pos])
+	baud, format, _ := strings.Cut(text[pos+1:], ",")
+	n, err := strconv.Atoi(strings.TrimSpace(baud))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("%s: %w", localizeDefault(MsgInvalidSetting, "baud rate", baud), gxcommon.ErrInvalidArgument)
+	}
+	c.BaudRate = gxcommon.BaudRate(n)
+	if format = strings.ToUpper(strings.TrimSpace(format)); format != "" {
+		if err := parseFrameFormat(c, format); err != nil {
+			return nil, fmt.Errorf("%s: %w", localizeDefault(MsgInvalidSetting, "format", format), err)
+		}
+	}
+	return c, nil
+}
+
+// parseFrameFormat parses the frame format, e.g. 8N1.
+func parseFrameFormat(c *Config, format string) error {
+	if len(format) < 3 || format[0] < '5' || format[0] > '8' {
+		return gxcommon.ErrInvalidArgument
+	}
+	c.DataBits = int(format[0] - '0')
+	switch format[1] {
+	case 'N':
+		c.Parity = gxcommon.ParityNone
+	case 'E':
+		c.Parity = gxcommon.ParityEven
+	case 'O':
+		c.Parity = gxcommon.ParityOdd
+	case 'M':
+		c.Parity = gxcommon.ParityMark
+	case 'S':
+		c.Parity = gxcommon.ParitySpace
+	default:
+		return gxcommon.ErrInvalidArgument
+	}
+	switch format[2:] {
+	case "1":
+		c.StopBits = gxcommon.StopBitsOne
+	case "1.5":
+		c.StopBits = gxcommon.StopBitsOnePointFive
+	case "2":
+		c.StopBits = gxcommon.StopBitsTwo
+	default:
+		return gxcommon.ErrInvalidArgument
+	}
+	return nil
+}
+
+// NewGXSerialFromString creates a GXSerial from the device:baud,format shorthand,
+// e.g. COM3:9600,8N1. The configuration is validated.
+func NewGXSerialFromString(text string) (*GXSerial, error) {
+	c, err := ParseModeString(text)
+	if err != nil {
+		return nil, err
+	}
+	return NewGXSerialFromConfig(c)
+}
+
+// configDocument holds the settings while they are parsed from text.
+type configDocument struct {
+	Port           setting[string]
+	BaudRate       setting[gxcommon.BaudRate]
+	DataBits       setting[int]
+	Parity         setting[gxcommon.Parity]
+	StopBits       setting[gxcommon.StopBits]
+	Eop            eopValue
+	Trace          setting[gxcommon.TraceLevel]
+	ConnectTimeout setting[time.Duration]
+	WriteTimeout   setting[time.Duration]
+}
+
+func newConfigDocument(c *Config) *configDocument {
+	return &configDocument{
+		Port:           setting[string]{c.Port},
+		BaudRate:       setting[gxcommon.BaudRate]{c.BaudRate},
+		DataBits:       setting[int]{c.DataBits},
+		Parity:         setting[gxcommon.Parity]{c.Parity},
+		StopBits:       setting[gxcommon.StopBits]{c.StopBits},
+		Eop:            eopValue{c.Eop},
+		Trace:          setting[gxcommon.TraceLevel]{c.Trace},
+		ConnectTimeout: setting[time.Duration]{c.ConnectTimeout},
+		WriteTimeout:   setting[time.Duration]{c.WriteTimeout},
+	}
+}
+
+func (d *configDocument) config() *Config {
+	return &Config{
+		Port:           d.Port.value,
+		BaudRate:       d.BaudRate.value,
+		DataBits:       d.DataBits.value,
+		Parity:         d.Parity.value,
+		StopBits:       d.StopBits.value,
+		Eop:            d.Eop.value,
+		Trace:          d.Trace.value,
+		ConnectTimeout: d.ConnectTimeout.value,
+		WriteTimeout:   d.WriteTimeout.value,
+	}
+}
+
+// values returns the settings of the document by their names.
+func (d *configDocument) values() map[string]interface{ parse(string) error } {
+	return map[string]interface{ parse(string) error }{
+		"port":           &d.Port,
+		"baudRate":       &d.BaudRate,
+		"dataBits":       &d.DataBits,
+		"parity":         &d.Parity,
+		"stopBits":       &d.StopBits,
+		"eop":            &d.Eop,
+		"trace":          &d.Trace,
+		"connectTimeout": &d.ConnectTimeout,
+		"writeTimeout":   &d.WriteTimeout,
+	}
+}
+
+// setting is a scalar value of the configuration document.
+type setting[T any] struct {
+	value T
+}
+
+// parse parses and validates the value.
+func (s *setting[T]) parse(text string) error {
+	var err error
+	switch v := any(&s.value).(type) {
+	case *string:
+		*v = text
+	case *gxcommon.BaudRate:
+		var n int
+		if n, err = strconv.Atoi(text); err == nil && n <= 0 {
+			err = gxcommon.ErrInvalidArgument
+		}
+		*v = gxcommon.BaudRate(n)
+	case *int:
+		if *v, err = strconv.Atoi(text); err == nil && (*v < 5 || *v > 8) {
+			err = gxcommon.ErrInvalidArgument
+		}
+	case *gxcommon.Parity:
+		*v, err = gxcommon.ParityParse(text)
+	case *gxcommon.StopBits:
+		*v, err = gxcommon.StopBitsParse(text)
+	case *gxcommon.TraceLevel:
+		*v, err = gxcommon.TraceLevelParse(text)
+	case *time.Duration:
+		if *v, err = time.ParseDuration(text); err == nil && *v < 0 {
+			err = gxcommon.ErrInvalidArgument
+		}
+	}
+	return err
+}
+
+// eopValue is the EOP of the configuration document.
+type eopValue struct {
+	value any
+}
+
+// parse parses the EOP from a comma separated list of bytes, e.g. 0x0D,0x0A.
+// Other text is used as a string.
+func (e *eopValue) parse(text string) error {
+	parts := strings.Split(text, ",")
+	data := make([]byte, 0, len(parts))
+	for _, it := range parts {
+		v, ok := parseEopByte(strings.TrimSpace(it))
+		if !ok {
+			e.value = text
+			return nil
+		}
+		data = append(data, v)
+	}
+	if len(data) == 1 {
+		e.value = data[0]
+	} else {
+		e.value = data
+	}
+	return nil
+}
+
+// parseEopByte parses a byte of the EOP. Hexadecimal values start with 0x.
+// False is returned if the text is not a byte.
+func parseEopByte(text string) (byte, bool) {
+	v, err := strconv.ParseUint(text, 0, 8)
+	return byte(v), err == nil
+}
@@ -0,0 +1,111 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// ControlLine identifies a modem control output line.
+type ControlLine int
+
+const (
+	// ControlLineDtr is the Data Terminal Ready line.
+	ControlLineDtr ControlLine = iota
+	// ControlLineRts is the Request To Send line.
+	ControlLineRts
+)
+
+// String returns the name of the control line.
+func (c ControlLine) String() string {
+	switch c {
+	case ControlLineDtr:
+		return "DTR"
+	case ControlLineRts:
+		return "RTS"
+	}
+	return "Unknown"
+}
+
+// RtsEnable returns the state of the RTS line.
+// When the port is open, the state is read from the driver. Otherwise the state that is set when the port is opened is returned.
+func (g *GXSerial) RtsEnable() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.s.isOpen() {
+		if on, err := g.s.getRtsEnable(); err == nil {
+			return on
+		}
+	}
+	return g.rtsEnable != nil && *g.rtsEnable
+}
+
+// SetRtsEnable sets the state of the RTS line, e.g. to power an optical probe or to key a transmitter.
+// The state is set when the port is opened. If the port is open, the line is changed immediately.
+// Until the state is set, the driver default is used.
+func (g *GXSerial) SetRtsEnable(value bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() {
+		if err := g.setControlLine(ControlLineRts, value); err != nil {
+			return err
+		}
+	}
+	g.rtsEnable = &value
+	return nil
+}
+
+// DtrEnable returns the state of the DTR line.
+// When the port is open, the state is read from the driver. Otherwise the state that is set when the port is opened is returned.
+func (g *GXSerial) DtrEnable() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.s.isOpen() {
+		if on, err := g.s.getDtrEnable(); err == nil {
+			return on
+		}
+	}
+	return g.dtrEnable != nil && *g.dtrEnable
+}
+
+// SetDtrEnable sets the state of the DTR line, e.g. to reset the device or to power a probe.
+// The state is set when the port is opened. If the port is open, the line is changed immediately.
+// Until the state is set, the driver default is used.
+func (g *GXSerial) SetDtrEnable(value bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() {
+		if err := g.setControlLine(ControlLineDtr, value); err != nil {
+			return err
+		}
+	}
+	g.dtrEnable = &value
+	return nil
+}
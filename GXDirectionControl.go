@@ -0,0 +1,157 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// DirectionControl switches the direction of an RS-485 transceiver that is driven by a GPIO
+// instead of RTS. Set is called with true before the data is written and with false
+// after the transmitter is empty.
+type DirectionControl struct {
+	// Set switches the transceiver to transmit (true) or receive (false).
+	Set func(transmit bool) error
+	// DelayBeforeSend is the delay after the transmitter is enabled before the data is sent.
+	DelayBeforeSend time.Duration
+	// DelayAfterSend is the turnaround delay after the last byte is sent before the transceiver
+	// is switched back to receive.
+	DelayAfterSend time.Duration
+}
+
+// DirectionControl returns the direction control of the RS-485 transceiver. Nil if not used.
+func (g *GXSerial) DirectionControl() *DirectionControl {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.direction
+}
+
+// SetDirectionControl sets the hook that switches the RS-485 transceiver direction around each write.
+// The transceiver is switched to receive when the port is opened.
+// If the port is open, the transceiver is switched to receive immediately. Nil disables the direction control.
+func (g *GXSerial) SetDirectionControl(value *DirectionControl) error {
+	if value != nil && (value.Set == nil || value.DelayBeforeSend < 0 || value.DelayAfterSend < 0) {
+		return gxcommon.ErrInvalidArgument
+	}
+	if value != nil {
+		v := *value
+		value = &v
+		if g.s.isOpen() {
+			if err := value.Set(false); err != nil {
+				return err
+			}
+		}
+	}
+	g.mu.Lock()
+	g.direction = value
+	g.mu.Unlock()
+	return nil
+}
+
+// beginDirection switches the transceiver to transmit before the data is written.
+// The returned function switches it back to receive after the data is sent.
+func (g *GXSerial) beginDirection() (func(), error) {
+	g.mu.RLock()
+	d := g.direction
+	g.mu.RUnlock()
+	if d == nil {
+		return func() {}, nil
+	}
+	if err := d.Set(true); err != nil {
+		return nil, err
+	}
+	time.Sleep(d.DelayBeforeSend)
+	return func() {
+		if err := g.s.waitTxEmpty(g.charTime()); err != nil {
+			g.log(slog.LevelWarn, "wait for empty transmitter failed", "err", err)
+		}
+		time.Sleep(d.DelayAfterSend)
+		g.s.logError("switch transceiver to receive failed", d.Set(false))
+	}, nil
+}
+
+// GPIOLine is a GPIO line that is controlled with the value file of the sysfs GPIO interface,
+// e.g. /sys/class/gpio/gpio17/value. The line must be exported and its direction set to out.
+// Set can be used as the Set function of DirectionControl.
+type GPIOLine struct {
+	mu sync.Mutex
+	f  *os.File
+	// Is the line active low.
+	activeLow bool
+}
+
+// OpenGPIOLine opens the value file of the GPIO line. If activeLow is true,
+// the line is low while the data is sent.
+func OpenGPIOLine(path string, activeLow bool) (*GPIOLine, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open GPIO line failed: %w", err)
+	}
+	return &GPIOLine{f: f, activeLow: activeLow}, nil
+}
+
+// Set sets the line active (true) or inactive (false).
+func (l *GPIOLine) Set(active bool) error {
+	value := "0"
+	if active != l.activeLow {
+		value = "1"
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+	if _, err := l.f.WriteAt([]byte(value), 0); err != nil {
+		return fmt.Errorf("set GPIO line failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the value file of the GPIO line.
+func (l *GPIOLine) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
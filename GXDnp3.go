@@ -0,0 +1,139 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// dnp3Start starts the DNP3 link frame.
+var dnp3Start = []byte{0x05, 0x64}
+
+const (
+	// dnp3HeaderSize is the size of the header without the CRC.
+	dnp3HeaderSize = 8
+	// dnp3BlockSize is the maximum size of the user data block that is followed by a CRC.
+	dnp3BlockSize = 16
+)
+
+// Dnp3Frame is the EOP of DNP3 link-layer frames (LPDU).
+// The frame starts with 0x05 0x64 and its length is read from the header.
+// The CRC of the header and the CRCs of the user data blocks are verified and a mismatch is
+// notified with *ChecksumError. Bytes before the start of the frame are removed.
+// Use it as the EOP of SetEop or Receive.
+//
+//	media.SetEop(gxserial.Dnp3Frame{StripCrc: true})
+type Dnp3Frame struct {
+	// StripCrc removes the CRCs and delivers the header and the user data without them.
+	StripCrc bool
+}
+
+// dnp3Crc returns the CRC of the block in the byte order of the frame.
+func dnp3Crc(block []byte) []byte {
+	return binary.LittleEndian.AppendUint16(nil, Crc16Dnp(block))
+}
+
+// find returns the start and the end of the first frame in data.
+// The start bytes are skipped if the CRC of the header doesn't match.
+func (Dnp3Frame) find(data []byte) (int, int, bool) {
+	for offset := 0; ; {
+		pos := bytes.Index(data[offset:], dnp3Start)
+		if pos == -1 {
+			return 0, 0, false
+		}
+		start := offset + pos
+		if start+dnp3HeaderSize+2 > len(data) {
+			return 0, 0, false
+		}
+		header := data[start : start+dnp3HeaderSize]
+		length := int(header[2])
+		if length < 5 || !bytes.Equal(dnp3Crc(header), data[start+dnp3HeaderSize:start+dnp3HeaderSize+2]) {
+			offset = start + 1
+			continue
+		}
+		// The length counts the control, destination and source fields and the user data.
+		userData := length - 5
+		end := start + dnp3HeaderSize + 2 + userData + 2*((userData+dnp3BlockSize-1)/dnp3BlockSize)
+		if end > len(data) {
+			return 0, 0, false
+		}
+		return start, end, true
+	}
+}
+
+// End returns the length of the first frame in data.
+func (d Dnp3Frame) End(data []byte) (int, bool) {
+	_, end, ok := d.find(data)
+	return end, ok
+}
+
+// check verifies the CRCs of the user data blocks.
+func (d Dnp3Frame) check(frame []byte) ([]byte, []byte, bool) {
+	start, end, ok := d.find(frame)
+	if !ok {
+		return nil, nil, true
+	}
+	body := frame[start+dnp3HeaderSize+2 : end]
+	for len(body) != 0 {
+		n := min(len(body)-2, dnp3BlockSize)
+		expected := dnp3Crc(body[:n])
+		actual := body[n : n+2]
+		if !bytes.Equal(expected, actual) {
+			return expected, actual, false
+		}
+		body = body[n+2:]
+	}
+	return nil, nil, true
+}
+
+// Decode removes the bytes before the start of the frame and, if StripCrc is set, the CRCs.
+func (d Dnp3Frame) Decode(frame []byte) []byte {
+	start, end, ok := d.find(frame)
+	if !ok {
+		return frame
+	}
+	frame = frame[start:end]
+	if !d.StripCrc {
+		return frame
+	}
+	ret := append([]byte(nil), frame[:dnp3HeaderSize]...)
+	body := frame[dnp3HeaderSize+2:]
+	for len(body) != 0 {
+		n := min(len(body)-2, dnp3BlockSize)
+		ret = append(ret, body[:n]...)
+		body = body[n+2:]
+	}
+	return ret
+}
@@ -0,0 +1,155 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// echoMargin is the time the echo is waited after the sent data should have been received back.
+const echoMargin = 100 * time.Millisecond
+
+// echoBuffer holds the sent data whose echo has not been received yet.
+type echoBuffer struct {
+	mu   sync.Mutex
+	data []byte
+	// Time when the echo is not waited anymore. Zero while the data is written.
+	deadline time.Time
+}
+
+// expect adds the data that is written to the expected echo.
+func (b *echoBuffer) expect(data []byte) {
+	b.mu.Lock()
+	b.data = append(b.data, data...)
+	b.deadline = time.Time{}
+	b.mu.Unlock()
+}
+
+// sent starts the wait of the echo after the data is written.
+func (b *echoBuffer) sent(charTime time.Duration) {
+	b.mu.Lock()
+	if len(b.data) != 0 {
+		b.deadline = time.Now().Add(time.Duration(len(b.data))*charTime + echoMargin)
+	}
+	b.mu.Unlock()
+}
+
+// reset discards the expected echo.
+func (b *echoBuffer) reset() {
+	b.mu.Lock()
+	b.data = nil
+	b.deadline = time.Time{}
+	b.mu.Unlock()
+}
+
+// strip removes the echo from the beginning of the received data.
+// It returns false if the received data doesn't match the expected echo.
+// The expected echo is discarded then and the rest of the data is returned as received.
+func (b *echoBuffer) strip(data []byte) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.data) == 0 {
+		return data, true
+	}
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		// The echo was lost.
+		b.data = nil
+		return data, true
+	}
+	n := 0
+	for n < len(b.data) && n < len(data) && b.data[n] == data[n] {
+		n++
+	}
+	if n < len(b.data) && n < len(data) {
+		b.data = nil
+		return data[n:], false
+	}
+	b.data = b.data[n:]
+	return data[n:], true
+}
+
+// EchoSuppression returns true if the echo of the sent data is removed from the received data.
+func (g *GXSerial) EchoSuppression() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.echoSuppression
+}
+
+// SetEchoSuppression sets the half-duplex mode where the sent data is echoed back, e.g. on two-wire
+// RS-485 buses. The echo is removed from the received data before it's framed.
+// The received data is traced as it's received, including the echo.
+// If the echo doesn't match the sent data, the expected echo is discarded and the mismatch is traced.
+func (g *GXSerial) SetEchoSuppression(value bool) {
+	g.mu.Lock()
+	g.echoSuppression = value
+	g.mu.Unlock()
+	if !value {
+		g.echo.reset()
+	}
+}
+
+// expectEcho adds the written data to the expected echo in the half-duplex mode.
+// The returned function starts the wait of the echo after the data is written.
+func (g *GXSerial) expectEcho(bufs ...[]byte) func() {
+	g.mu.RLock()
+	on := g.echoSuppression
+	g.mu.RUnlock()
+	if !on {
+		return func() {}
+	}
+	for _, it := range bufs {
+		g.echo.expect(it)
+	}
+	return func() {
+		g.echo.sent(g.charTime())
+	}
+}
+
+// suppressEcho removes the echo of the sent data from the received data.
+func (g *GXSerial) suppressEcho(data []byte) []byte {
+	g.mu.RLock()
+	on := g.echoSuppression
+	g.mu.RUnlock()
+	if !on {
+		return data
+	}
+	data, ok := g.echo.strip(data)
+	if !ok {
+		g.trace(true, gxcommon.TraceTypesError, localize(g.p, MsgEchoMismatch, g.Port))
+	}
+	return data
+}
@@ -0,0 +1,85 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ConfigFromEnv reads the configuration from the environment variables with the given prefix, e.g.
+// GXSERIAL_PORT, GXSERIAL_BAUD, GXSERIAL_DATABITS, GXSERIAL_PARITY, GXSERIAL_STOPBITS, GXSERIAL_EOP,
+// GXSERIAL_TRACE, GXSERIAL_CONNECT_TIMEOUT and GXSERIAL_WRITE_TIMEOUT when the prefix is GXSERIAL.
+// The settings that are not set use DefaultConfig. Timeouts are durations, e.g. 5s.
+// The EOP is a comma separated list of bytes, e.g. 0x0D,0x0A, or a string.
+func ConfigFromEnv(prefix string) (*Config, error) {
+	doc := newConfigDocument(DefaultConfig())
+	values := []struct {
+		name  string
+		value interface{ parse(string) error }
+	}{
+		{"PORT", &doc.Port},
+		{"BAUD", &doc.BaudRate},
+		{"DATABITS", &doc.DataBits},
+		{"PARITY", &doc.Parity},
+		{"STOPBITS", &doc.StopBits},
+		{"EOP", &doc.Eop},
+		{"TRACE", &doc.Trace},
+		{"CONNECT_TIMEOUT", &doc.ConnectTimeout},
+		{"WRITE_TIMEOUT", &doc.WriteTimeout},
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	for _, it := range values {
+		name := prefix + it.name
+		if text, ok := os.LookupEnv(name); ok {
+			if err := it.value.parse(text); err != nil {
+				return nil, fmt.Errorf("%s: %w", localizeDefault(MsgInvalidSetting, name, text), err)
+			}
+		}
+	}
+	return doc.config(), nil
+}
+
+// FromEnv returns a media that is configured from the environment variables with the given prefix.
+// See ConfigFromEnv for the used variables.
+func FromEnv(prefix string) (*GXSerial, error) {
+	c, err := ConfigFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return NewGXSerialFromConfig(c)
+}
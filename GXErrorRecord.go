@@ -0,0 +1,54 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrorRecord is an error with the time and the operation where it occurred.
+type ErrorRecord struct {
+	// Time is the time when the error occurred.
+	Time time.Time
+	// Op is the operation that failed, e.g. "open", "read" or "send".
+	Op string
+	// Err is the error.
+	Err error
+}
+
+// String returns the error record in human-readable form.
+func (e ErrorRecord) String() string {
+	return fmt.Sprintf("%s %s: %v", e.Time.Format(time.RFC3339Nano), e.Op, e.Err)
+}
@@ -0,0 +1,101 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// EscapedEop is an EOP byte that can appear escaped inside the payload.
+// The escape byte makes the following byte part of the payload, so an escaped EOP doesn't end the frame.
+// Use it as the EOP of SetEop or Receive.
+//
+// Example: frames end with ETX and DLE escapes ETX and DLE bytes of the payload.
+//
+//	media.SetEop(gxserial.EscapedEop{Eop: 0x03, Escape: 0x10, Unstuff: true})
+type EscapedEop struct {
+	// Eop ends the frame.
+	Eop byte
+	// Escape is the escape byte, for example DLE (0x10) or ESC (0x1B).
+	Escape byte
+	// Xor is applied to the escaped byte when it is stuffed or unstuffed. For example, HDLC uses 0x20.
+	Xor byte
+	// Unstuff removes the escape bytes from the received frame.
+	Unstuff bool
+}
+
+// End returns the length of the first frame in data.
+func (e EscapedEop) End(data []byte) (int, bool) {
+	for pos := 0; pos < len(data); pos++ {
+		switch data[pos] {
+		case e.Escape:
+			//Skip escaped byte.
+			pos++
+		case e.Eop:
+			return pos + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Decode removes the escape bytes from the frame if Unstuff is set.
+func (e EscapedEop) Decode(frame []byte) []byte {
+	if !e.Unstuff {
+		return frame
+	}
+	ret := make([]byte, 0, len(frame))
+	for pos := 0; pos < len(frame); pos++ {
+		if frame[pos] == e.Escape && pos+1 < len(frame) {
+			pos++
+			ret = append(ret, frame[pos]^e.Xor)
+		} else {
+			ret = append(ret, frame[pos])
+		}
+	}
+	return ret
+}
+
+// Encode escapes the payload and appends the EOP. It's used by SendFramed.
+func (e EscapedEop) Encode(payload []byte) []byte {
+	return e.Stuff(payload)
+}
+
+// Stuff escapes the EOP and escape bytes of the payload and appends the EOP.
+func (e EscapedEop) Stuff(payload []byte) []byte {
+	ret := make([]byte, 0, len(payload)+1)
+	for _, b := range payload {
+		if b == e.Eop || b == e.Escape {
+			ret = append(ret, e.Escape, b^e.Xor)
+		} else {
+			ret = append(ret, b)
+		}
+	}
+	return append(ret, e.Eop)
+}
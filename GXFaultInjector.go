@@ -0,0 +1,140 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"sync"
+	"time"
+)
+
+// FaultInjector injects faults to the data of a test media so application
+// retry and CRC logic can be exercised. Each fault is applied once.
+type FaultInjector struct {
+	mu        sync.Mutex
+	drop      int
+	flips     []bitFlip
+	duplicate int
+	delay     time.Duration
+	writeErr  error
+}
+
+type bitFlip struct {
+	offset int
+	mask   byte
+}
+
+// DropBytes drops the next count received bytes.
+func (f *FaultInjector) DropBytes(count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drop += count
+}
+
+// FlipBits inverts the bits set in mask at the given offset of the next received chunk.
+// If the chunk is shorter, the last byte is modified.
+func (f *FaultInjector) FlipBits(offset int, mask byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flips = append(f.flips, bitFlip{offset: offset, mask: mask})
+}
+
+// DuplicateChunks delivers the next count received chunks twice.
+func (f *FaultInjector) DuplicateChunks(count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.duplicate += count
+}
+
+// DelayNext delays the delivery of the next received chunk.
+func (f *FaultInjector) DelayNext(value time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = value
+}
+
+// FailNextWrite makes the next send fail with the given error.
+func (f *FaultInjector) FailNextWrite(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writeErr = err
+}
+
+// Reset removes all pending faults.
+func (f *FaultInjector) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drop = 0
+	f.flips = nil
+	f.duplicate = 0
+	f.delay = 0
+	f.writeErr = nil
+}
+
+// writeError returns the pending write error and clears it.
+func (f *FaultInjector) writeError() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.writeErr
+	f.writeErr = nil
+	return err
+}
+
+// apply applies the pending faults to the received chunk.
+// It returns the chunks to deliver and the delay before the delivery.
+func (f *FaultInjector) apply(data []byte) ([][]byte, time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delay := f.delay
+	f.delay = 0
+	if f.drop != 0 {
+		n := min(f.drop, len(data))
+		f.drop -= n
+		data = data[n:]
+	}
+	if len(data) == 0 {
+		return nil, delay
+	}
+	if len(f.flips) != 0 {
+		data = append([]byte(nil), data...)
+		for _, it := range f.flips {
+			data[min(it.offset, len(data)-1)] ^= it.mask
+		}
+		f.flips = nil
+	}
+	if f.duplicate != 0 {
+		f.duplicate--
+		return [][]byte{data, data}, delay
+	}
+	return [][]byte{data}, delay
+}
@@ -0,0 +1,106 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"flag"
+	"fmt"
+)
+
+// SettingsRef holds the serial port settings of the command line flags.
+// The settings are available after the flag set is parsed.
+type SettingsRef struct {
+	doc *configDocument
+}
+
+// RegisterFlags declares the serial port flags port, baud, databits, parity, stopbits, eop and trace
+// to the flag set. The prefix is added to the flag names, e.g. "meter-" declares meter-port.
+// The flags that are not given use DefaultConfig. The EOP is a comma separated list of bytes,
+// e.g. 0x0D,0x0A, or a string.
+func RegisterFlags(fs *flag.FlagSet, prefix string) *SettingsRef {
+	r := &SettingsRef{doc: newConfigDocument(DefaultConfig())}
+	fs.Var(&r.doc.Port, prefix+"port", "Serial port name")
+	fs.Var(&r.doc.BaudRate, prefix+"baud", "Baud rate")
+	fs.Var(&r.doc.DataBits, prefix+"databits", "Data bits (5, 6, 7, 8)")
+	fs.Var(&r.doc.Parity, prefix+"parity", "Parity (None, Odd, Even, Mark, Space)")
+	fs.Var(&r.doc.StopBits, prefix+"stopbits", "Stop bits (One, OnePointFive, Two)")
+	fs.Var(&r.doc.Eop, prefix+"eop", "End of packet, e.g. 0x7E or 0x0D,0x0A")
+	fs.Var(&r.doc.Trace, prefix+"trace", "Trace level (Off, Error, Warning, Info, Verbose)")
+	return r
+}
+
+// Config returns the configuration of the parsed flags.
+func (r *SettingsRef) Config() *Config {
+	return r.doc.config()
+}
+
+// Media returns a media that is configured with the parsed flags.
+func (r *SettingsRef) Media() (*GXSerial, error) {
+	return NewGXSerialFromConfig(r.Config())
+}
+
+// String implements flag.Value.
+func (s *setting[T]) String() string {
+	return fmt.Sprint(s.value)
+}
+
+// Set implements flag.Value.
+func (s *setting[T]) Set(value string) error {
+	return s.parse(value)
+}
+
+// String implements flag.Value.
+func (e *eopValue) String() string {
+	switch v := e.value.(type) {
+	case nil:
+		return ""
+	case byte:
+		return fmt.Sprintf("0x%02X", v)
+	case []byte:
+		var ret string
+		for pos, it := range v {
+			if pos != 0 {
+				ret += ","
+			}
+			ret += fmt.Sprintf("0x%02X", it)
+		}
+		return ret
+	}
+	return fmt.Sprint(e.value)
+}
+
+// Set implements flag.Value.
+func (e *eopValue) Set(value string) error {
+	return e.parse(value)
+}
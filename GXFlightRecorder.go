@@ -0,0 +1,128 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// FlightEventType is the type of the flight recorder event.
+type FlightEventType int
+
+const (
+	// FlightEventState is a media state change.
+	FlightEventState FlightEventType = iota
+	// FlightEventSent is sent data.
+	FlightEventSent
+	// FlightEventReceived is received data.
+	FlightEventReceived
+	// FlightEventError is an error.
+	FlightEventError
+)
+
+// String returns the name of the event type.
+func (t FlightEventType) String() string {
+	switch t {
+	case FlightEventState:
+		return "State"
+	case FlightEventSent:
+		return "TX"
+	case FlightEventReceived:
+		return "RX"
+	case FlightEventError:
+		return "Error"
+	}
+	return "Unknown"
+}
+
+// FlightEvent is an event kept by the flight recorder.
+type FlightEvent struct {
+	// Time is the time of the event.
+	Time time.Time
+	// Type is the type of the event.
+	Type FlightEventType
+	// State is the new media state of a state event.
+	State gxcommon.MediaState
+	// Data is the transferred data. It's truncated to the maximum payload size.
+	Data []byte
+	// Size is the size of the transferred data before truncation.
+	Size int
+	// Err is the error of an error event.
+	Err error
+}
+
+// String returns the event in human-readable form.
+func (e FlightEvent) String() string {
+	ts := e.Time.Format(time.RFC3339Nano)
+	switch e.Type {
+	case FlightEventState:
+		return fmt.Sprintf("%s %s %s", ts, e.Type, e.State)
+	case FlightEventError:
+		return fmt.Sprintf("%s %s %v", ts, e.Type, e.Err)
+	}
+	if len(e.Data) < e.Size {
+		return fmt.Sprintf("%s %s % X ... (%d bytes)", ts, e.Type, e.Data, e.Size)
+	}
+	return fmt.Sprintf("%s %s % X", ts, e.Type, e.Data)
+}
+
+// flightRecorder keeps the latest events.
+type flightRecorder struct {
+	events     *ring[FlightEvent]
+	maxPayload int
+}
+
+// add adds an event. Data is copied and truncated.
+func (r *flightRecorder) add(e FlightEvent) {
+	e.Time = time.Now()
+	if e.Data != nil {
+		e.Size = len(e.Data)
+		e.Data = append([]byte(nil), e.Data[:min(len(e.Data), r.maxPayload)]...)
+	}
+	r.events.Add(e)
+}
+
+// dump writes the events to w, one event per line.
+func (r *flightRecorder) dump(w io.Writer) error {
+	for _, it := range r.events.Items() {
+		if _, err := fmt.Fprintln(w, it.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
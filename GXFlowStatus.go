@@ -0,0 +1,87 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"time"
+)
+
+// FlowStatus tells whether the transmission is held off by flow control.
+type FlowStatus struct {
+	// CtsHold is true if the transmission waits for the CTS line.
+	CtsHold bool
+	// DsrHold is true if the transmission waits for the DSR line. Windows only.
+	DsrHold bool
+	// DcdHold is true if the transmission waits for the DCD line. Windows only.
+	DcdHold bool
+	// XoffHold is true if the transmission waits because XOFF was received.
+	// On POSIX systems this is a heuristic: the output queue doesn't drain while software flow control is used.
+	XoffHold bool
+	// XoffSent is true if XOFF was sent to the peer. Windows only.
+	XoffSent bool
+	// Pending is the amount of bytes in the driver output queue.
+	Pending int
+}
+
+// Paused returns true if the transmission is held off.
+func (s FlowStatus) Paused() bool {
+	return s.CtsHold || s.DsrHold || s.DcdHold || s.XoffHold
+}
+
+// flowSampleBits is the amount of bit times the output queue is sampled when XOFF is detected.
+const flowSampleBits = 40
+
+// FlowStatus returns whether the transmission is currently held off by flow control.
+// It tells a slow device from a stuck link. CtsHold and XoffHold are reported only when the handshake
+// of the port uses them, see SetHandshake.
+func (g *GXSerial) FlowStatus() (FlowStatus, error) {
+	if !g.s.isOpen() {
+		return FlowStatus{}, errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	sample := time.Millisecond
+	if g.baudRate > 0 {
+		sample = max(sample, flowSampleBits*time.Second/time.Duration(g.baudRate))
+	}
+	return g.s.getFlowStatus(sample)
+}
+
+// TxPaused returns true if the transmission is currently held off by CTS or a received XOFF.
+func (g *GXSerial) TxPaused() (bool, error) {
+	s, err := g.FlowStatus()
+	if err != nil {
+		return false, err
+	}
+	return s.Paused(), nil
+}
@@ -0,0 +1,243 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"time"
+)
+
+// Frame is a complete received frame.
+type Frame struct {
+	// Data is the content of the frame.
+	Data []byte
+	// Time is the time when the frame was completed.
+	Time time.Time
+}
+
+// framesBuffer is the amount of frames that are buffered for a slow consumer.
+// When the buffer is full, the frames are dropped so that the reader isn't blocked.
+const framesBuffer = 16
+
+// frameResult is a complete received frame or a framing error.
+type frameResult struct {
+	data []byte
+	err  error
+	time time.Time
+}
+
+// frameSubscription receives the complete frames of the asynchronous receive.
+type frameSubscription struct {
+	g  *GXSerial
+	ch chan frameResult
+	// Closed when the consumer stops or the port is closed.
+	done chan struct{}
+	once sync.Once
+	// If true, the subscription isn't ended when the port is closed.
+	persistent bool
+	// If true, the frames are dropped when the buffer is full. Otherwise the reader waits for the consumer.
+	lossy bool
+}
+
+// subscribeFrames starts receiving the complete frames.
+// If lossy is true, the frames are dropped when the buffer is full.
+func (g *GXSerial) subscribeFrames(buffer int, lossy bool) *frameSubscription {
+	s := &frameSubscription{g: g, ch: make(chan frameResult, buffer), done: make(chan struct{}), lossy: lossy}
+	g.frameMu.Lock()
+	if g.frameSubs == nil {
+		g.frameSubs = make(map[*frameSubscription]struct{})
+	}
+	g.frameSubs[s] = struct{}{}
+	g.frameMu.Unlock()
+	return s
+}
+
+// hasFrameSubscriptions returns true if the complete frames are subscribed.
+func (g *GXSerial) hasFrameSubscriptions() bool {
+	g.frameMu.Lock()
+	defer g.frameMu.Unlock()
+	return len(g.frameSubs) != 0
+}
+
+// end stops the subscription.
+func (s *frameSubscription) end() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// cancel stops the subscription and removes it from the media.
+func (s *frameSubscription) cancel() {
+	s.end()
+	s.g.frameMu.Lock()
+	delete(s.g.frameSubs, s)
+	s.g.frameMu.Unlock()
+}
+
+// publishFrame passes the frame to the subscribers.
+func (g *GXSerial) publishFrame(r frameResult) {
+	g.frameMu.Lock()
+	if len(g.frameSubs) == 0 {
+		g.frameMu.Unlock()
+		return
+	}
+	subs := make([]*frameSubscription, 0, len(g.frameSubs))
+	for s := range g.frameSubs {
+		subs = append(subs, s)
+	}
+	g.frameMu.Unlock()
+	if r.time.IsZero() {
+		r.time = time.Now()
+	}
+	for _, s := range subs {
+		if s.lossy {
+			select {
+			case s.ch <- r:
+			case <-s.done:
+			default:
+				g.droppedFrames.Add(1)
+				g.errorf(true, "receive", errors.New(localize(g.p, MsgFrameDropped, g.Port)))
+			}
+			continue
+		}
+		select {
+		case s.ch <- r:
+		case <-s.done:
+		}
+	}
+}
+
+// DroppedFrames returns the amount of the received frames that are dropped
+// because the consumer of Frames, FramesChan or Aggregator was too slow.
+func (g *GXSerial) DroppedFrames() uint64 {
+	return g.droppedFrames.Load()
+}
+
+// endFrameSubscriptions stops all subscriptions that are not persistent when the port is closed.
+func (g *GXSerial) endFrameSubscriptions() {
+	g.frameMu.Lock()
+	var subs []*frameSubscription
+	for s := range g.frameSubs {
+		if !s.persistent {
+			subs = append(subs, s)
+			delete(g.frameSubs, s)
+		}
+	}
+	g.frameMu.Unlock()
+	for _, s := range subs {
+		s.end()
+	}
+}
+
+// Frames returns an iterator over the complete received frames.
+// Frames are ended with the configured EOP. If the EOP is not set, the received chunks are returned as they are.
+// Invalid frames are returned as errors and the iteration continues.
+// The iteration ends when the context ends or the port is closed.
+// Frames are received in the asynchronous mode only, and the received event is notified as well.
+// If the consumer is too slow, the frames are dropped and counted with DroppedFrames, and
+// the error event is notified.
+//
+//	for frame, err := range media.Frames(ctx) {
+//	    ...
+//	}
+func (g *GXSerial) Frames(ctx context.Context) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		if !g.IsOpen() {
+			yield(nil, errors.New(localize(g.p, MsgPortNotOpen, g.Port)))
+			return
+		}
+		s := g.subscribeFrames(framesBuffer, true)
+		defer s.cancel()
+		for {
+			select {
+			case r := <-s.ch:
+				if !yield(r.data, r.err) {
+					return
+				}
+			case <-s.done:
+				// Return the frames that were received before the port was closed.
+				for {
+					select {
+					case r := <-s.ch:
+						if !yield(r.data, r.err) {
+							return
+						}
+					default:
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// FramesChan returns a channel that receives the complete received frames with their timestamps.
+// Frames are ended with the configured EOP. If the EOP is not set, the received chunks are delivered as they are.
+// Invalid frames are not delivered; they are notified through the error event.
+// The buffer is the amount of frames that are buffered for a slow consumer. When it's full, the frames are
+// dropped and counted with DroppedFrames, and the error event is notified. The reader never waits for the consumer.
+// The channel is closed when the port is closed. A closed channel is returned if the port is not open.
+func (g *GXSerial) FramesChan(buffer int) <-chan Frame {
+	ch := make(chan Frame)
+	if !g.IsOpen() {
+		close(ch)
+		return ch
+	}
+	s := g.subscribeFrames(max(buffer, 1), true)
+	go func() {
+		defer close(ch)
+		defer s.cancel()
+		for {
+			select {
+			case r := <-s.ch:
+				if r.err != nil {
+					continue
+				}
+				select {
+				case ch <- Frame{Data: r.data, Time: r.time}:
+				case <-s.done:
+					return
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return ch
+}
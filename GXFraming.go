@@ -0,0 +1,290 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// frameEnder is implemented by the EOPs that find the end of the frame themselves.
+type frameEnder interface {
+	End(data []byte) (int, bool)
+}
+
+// frameDecoder is implemented by the EOPs that transform the received frame.
+type frameDecoder interface {
+	Decode(frame []byte) []byte
+}
+
+// frameEncoder is implemented by the EOPs that build the sent frame from the payload.
+type frameEncoder interface {
+	Encode(payload []byte) []byte
+}
+
+// frameChecker is implemented by the EOPs that verify the checksum of the received frame.
+type frameChecker interface {
+	// check returns the calculated and the received checksum and false if they don't match.
+	check(frame []byte) (expected []byte, actual []byte, ok bool)
+}
+
+// predicate returns the matcher if the EOP is a predicate function.
+func predicate(eop any) (Matcher, bool) {
+	switch v := eop.(type) {
+	case Matcher:
+		return v, v != nil
+	case func([]byte) (int, bool):
+		return v, v != nil
+	case frameEnder:
+		return v.End, true
+	}
+	return nil, false
+}
+
+// decode transforms the received frame if the EOP requires it.
+func decode(eop any, frame []byte) []byte {
+	if d, ok := eop.(frameDecoder); ok {
+		return d.Decode(frame)
+	}
+	return frame
+}
+
+// eopSuffix returns the bytes that end the frames of the EOP.
+// Nil is returned if the frames don't end with fixed bytes.
+func eopSuffix(eop any) []byte {
+	if e, ok := eop.(EscapedEop); ok {
+		return []byte{e.Eop}
+	}
+	if _, ok := predicate(eop); ok || eop == nil {
+		return nil
+	}
+	terminator, err := gxcommon.ToBytes(eop, binary.BigEndian)
+	if err != nil {
+		return nil
+	}
+	return terminator
+}
+
+// encodeFrame builds the sent frame from the payload. The checksum is appended to the payload
+// and the frame is ended as the EOP requires.
+func (g *GXSerial) encodeFrame(eop any, c Checksum, payload []byte) ([]byte, error) {
+	frame := append([]byte(nil), payload...)
+	if c != nil {
+		frame = append(frame, c.Sum(payload)...)
+	}
+	if e, ok := eop.(frameEncoder); ok {
+		return e.Encode(frame), nil
+	}
+	if _, ok := predicate(eop); ok {
+		return nil, errors.New(localize(g.p, MsgFramingNotSupported, eop))
+	}
+	return append(frame, eopSuffix(eop)...), nil
+}
+
+// toMatcher returns the matcher that finds the end of the frame for the EOP.
+// Nil is returned if the EOP is not set.
+func toMatcher(eop any) (Matcher, error) {
+	if eop == nil {
+		return nil, nil
+	}
+	if m, ok := predicate(eop); ok {
+		return m, nil
+	}
+	terminator, err := gxcommon.ToBytes(eop, binary.BigEndian)
+	if err != nil {
+		return nil, err
+	}
+	if len(terminator) == 0 {
+		return nil, nil
+	}
+	return func(data []byte) (int, bool) {
+		if pos := bytes.Index(data, terminator); pos != -1 {
+			return pos + len(terminator), true
+		}
+		return 0, false
+	}, nil
+}
+
+// FrameValidator checks the received frame before it's delivered.
+// It returns an error if the frame is invalid.
+type FrameValidator func(frame []byte) error
+
+// FrameError is notified when the frame validator rejects the received frame.
+type FrameError struct {
+	// Frame is the rejected frame.
+	Frame []byte
+	// Err is the error returned by the frame validator.
+	Err error
+	msg string
+}
+
+// Error implements error.
+func (e *FrameError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the error of the frame validator.
+func (e *FrameError) Unwrap() error {
+	return e.Err
+}
+
+// FrameValidator returns the frame validator. Nil if frames are not validated.
+func (g *GXSerial) FrameValidator() FrameValidator {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.validator
+}
+
+// SetFrameValidator sets the validator that is called for each completed frame before it's delivered.
+// Frames are completed when the EOP is set. Rejected frames are dropped and *FrameError is notified
+// through the error event. In synchronous mode Receive returns the error. Nil disables the validation.
+func (g *GXSerial) SetFrameValidator(value FrameValidator) {
+	g.mu.Lock()
+	g.validator = value
+	g.mu.Unlock()
+}
+
+// completeFrame decodes the received frame, verifies the checksum and validates it.
+// It returns the frame that is delivered to the application.
+func (g *GXSerial) completeFrame(eop any, frame []byte) ([]byte, error) {
+	g.mu.RLock()
+	c := g.checksum
+	v := g.validator
+	g.mu.RUnlock()
+	if fc, ok := eop.(frameChecker); ok {
+		if expected, actual, ok := fc.check(frame); !ok {
+			return nil, &ChecksumError{Frame: frame, Expected: expected, Actual: actual,
+				msg: localize(g.p, MsgChecksumMismatch, expected, actual)}
+		}
+	}
+	frame = decode(eop, frame)
+	if c != nil {
+		var err error
+		// The checksum is before the bytes that end the frame.
+		suffix := eopSuffix(eop)
+		if len(suffix) != 0 && bytes.HasSuffix(frame, suffix) {
+			if frame, err = g.verifyChecksum(c, frame[:len(frame)-len(suffix)]); err != nil {
+				return nil, err
+			}
+			frame = append(append([]byte(nil), frame...), suffix...)
+		} else if frame, err = g.verifyChecksum(c, frame); err != nil {
+			return nil, err
+		}
+	}
+	if v != nil {
+		if err := v(frame); err != nil {
+			return nil, &FrameError{Frame: frame, Err: err, msg: localize(g.p, MsgInvalidFrame, frame, err)}
+		}
+	}
+	return frame, nil
+}
+
+// newFrameParser returns the parser of the media for the EOP. Nil is returned if the EOP is not set.
+// The data that doesn't end a frame in the previous parser is kept.
+func newFrameParser(eop any, previous *Parser) (*Parser, error) {
+	p, err := NewParser(eop)
+	if err != nil || p.match == nil {
+		return nil, err
+	}
+	if previous != nil {
+		p.buf = previous.buf
+	}
+	return p, nil
+}
+
+// deliver notifies the received data asynchronously.
+// If the EOP is set, data is buffered until a complete frame is received.
+// In synchronous mode the frames that the receive filter and the address filter pass are added to the
+// synchronous buffer, and the data that doesn't end a frame is discarded when it exceeds the receive buffer limit.
+func (g *GXSerial) deliver(data []byte, synchronous bool) {
+	g.mu.RLock()
+	p := g.parser
+	eop := g.eop
+	filter := g.addressFilter
+	g.mu.RUnlock()
+	if p == nil {
+		g.dispatch(data, data, synchronous)
+		return
+	}
+	frames := p.Feed(data)
+	if limit := g.received.Limit(); synchronous && limit > 0 && len(p.buf) > limit {
+		// The end of the frame is not found. The pending Receive fails as if the buffer overflowed.
+		p.Reset()
+		g.received.Overflow()
+	}
+	for _, raw := range frames {
+		frame, err := g.completeFrame(eop, raw)
+		if err != nil {
+			if synchronous {
+				// Receive reports the error.
+				g.appendData(raw)
+				continue
+			}
+			g.errorf(true, "frame", err)
+			g.publishFrame(frameResult{err: err, time: g.rxTime})
+			continue
+		}
+		if filter != nil && !filter.accept(frame) {
+			g.discardedFrames.Add(1)
+			continue
+		}
+		g.dispatch(raw, frame, synchronous)
+	}
+}
+
+// dispatch passes the complete frame to the receive filter and then to the received event
+// or, in synchronous mode, to the synchronous buffer. raw is the frame as it was received.
+func (g *GXSerial) dispatch(raw []byte, frame []byte, synchronous bool) {
+	switch g.filterFrame(frame) {
+	case ReceiveVerdictConsumed:
+		return
+	case ReceiveVerdictDrop:
+		g.discardedFrames.Add(1)
+		return
+	}
+	if synchronous {
+		g.appendData(raw)
+		return
+	}
+	g.meter.add(0, 0, 1, 0)
+	if g.retain(frame) {
+		return
+	}
+	g.receivef(true, frame)
+	g.timestampedf(frame)
+	g.publishFrame(frameResult{data: frame, time: g.rxTime})
+}
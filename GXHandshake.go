@@ -0,0 +1,98 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// Handshake is the flow control of the serial port.
+type Handshake int
+
+const (
+	// HandshakeNone doesn't use flow control.
+	HandshakeNone Handshake = iota
+	// HandshakeXOnXOff uses the XON and XOFF characters in both directions.
+	HandshakeXOnXOff
+	// HandshakeRequestToSend uses the RTS and CTS lines.
+	// The driver controls RTS, so it can't be set by the application.
+	HandshakeRequestToSend
+	// HandshakeRequestToSendXOnXOff uses both the RTS and CTS lines and the XON and XOFF characters.
+	HandshakeRequestToSendXOnXOff
+)
+
+// String returns the name of the handshake.
+func (h Handshake) String() string {
+	switch h {
+	case HandshakeNone:
+		return "None"
+	case HandshakeXOnXOff:
+		return "XOnXOff"
+	case HandshakeRequestToSend:
+		return "RequestToSend"
+	case HandshakeRequestToSendXOnXOff:
+		return "RequestToSendXOnXOff"
+	}
+	return fmt.Sprintf("Handshake(%d)", int(h))
+}
+
+// rtsCts returns true if the RTS and CTS lines are used.
+func (h Handshake) rtsCts() bool {
+	return h == HandshakeRequestToSend || h == HandshakeRequestToSendXOnXOff
+}
+
+// xonXoff returns true if the XON and XOFF characters are used.
+func (h Handshake) xonXoff() bool {
+	return h == HandshakeXOnXOff || h == HandshakeRequestToSendXOnXOff
+}
+
+// Handshake returns the flow control of the serial port.
+func (g *GXSerial) Handshake() Handshake {
+	return g.handshake
+}
+
+// SetHandshake sets the flow control of the serial port. The default is HandshakeNone.
+// If the port is open, the flow control is changed immediately.
+func (g *GXSerial) SetHandshake(value Handshake) error {
+	if value < HandshakeNone || value > HandshakeRequestToSendXOnXOff {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.handshake = value
+	if g.s.isOpen() {
+		return g.s.setHandshake(value)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// hdlcFlag opens and closes the HDLC frame.
+const hdlcFlag = 0x7E
+
+// HdlcFrame is the EOP of DLMS HDLC frames (IEC 62056-46).
+// The length of the frame is read from the frame format field after the opening flag
+// and the frame ends when all of its bytes and the closing flag are received.
+// Each received event contains exactly one HDLC frame, even if the frame is received in several reads
+// or the payload contains flag bytes. Bytes before the opening flag are removed from the frame.
+// The closing flag of a frame can also be the opening flag of the next frame. The flag is then
+// added to the start of the next frame.
+// Use it as the EOP of SetEop or Receive.
+//
+//	media.SetEop(gxserial.HdlcFrame{})
+type HdlcFrame struct {
+}
+
+// find returns the start and the end of the first HDLC frame in data.
+// If the data starts from the frame format field, the opening flag was the closing flag of the
+// previous frame. Then the frame that ends first is returned so that more data doesn't change the result.
+func (h HdlcFrame) find(data []byte) (int, int, bool) {
+	shared := -1
+	if len(data) >= 2 && data[0]&0xF0 == 0xA0 {
+		// The length excludes the opening and closing flags.
+		end := int((uint16(data[0])<<8|uint16(data[1]))&0x7FF) + 1
+		if end <= len(data) && data[end-1] == hdlcFlag {
+			shared = end
+		}
+	}
+	start, end, ok := h.findFlag(data)
+	if shared != -1 && (!ok || shared <= end) {
+		return 0, shared, true
+	}
+	return start, end, ok
+}
+
+// findFlag returns the start and the end of the first HDLC frame that starts with the opening flag.
+func (HdlcFrame) findFlag(data []byte) (int, int, bool) {
+	for start := 0; start < len(data); start++ {
+		if data[start] != hdlcFlag {
+			continue
+		}
+		if start+3 > len(data) {
+			return 0, 0, false
+		}
+		format := uint16(data[start+1])<<8 | uint16(data[start+2])
+		if format&0xF000 != 0xA000 {
+			// The flag is the closing flag of the previous frame or noise.
+			continue
+		}
+		// The length excludes the opening and closing flags.
+		end := start + int(format&0x7FF) + 2
+		if end > len(data) {
+			return 0, 0, false
+		}
+		if data[end-1] == hdlcFlag {
+			return start, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// End returns the length of the first frame in data.
+func (h HdlcFrame) End(data []byte) (int, bool) {
+	_, end, ok := h.find(data)
+	return end, ok
+}
+
+// Decode removes the bytes before the opening flag from the frame.
+// The opening flag is added if it was the closing flag of the previous frame.
+func (h HdlcFrame) Decode(frame []byte) []byte {
+	if start, end, ok := h.find(frame); ok {
+		if frame[start] != hdlcFlag {
+			return append([]byte{hdlcFlag}, frame[start:end]...)
+		}
+		return frame[start:end]
+	}
+	return frame
+}
@@ -0,0 +1,212 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// HotplugMode defines how the media handles removal and re-attach of the serial port device.
+type HotplugMode int
+
+const (
+	// HotplugNone doesn't follow the device. Removal is noticed when the read fails.
+	HotplugNone HotplugMode = iota
+	// HotplugDetect closes the media as soon as the device is removed.
+	HotplugDetect
+	// HotplugReconnect closes the media when the device is removed and opens it again
+	// when the same device is attached. The device is matched by the serial number when it's known.
+	// On macOS the serial number is read from IOKit. If the package is built without cgo, the serial number
+	// isn't known and the device is matched by the name of its device node.
+	HotplugReconnect
+)
+
+// String returns the name of the hot-plug mode.
+func (m HotplugMode) String() string {
+	switch m {
+	case HotplugNone:
+		return "None"
+	case HotplugDetect:
+		return "Detect"
+	case HotplugReconnect:
+		return "Reconnect"
+	}
+	return fmt.Sprintf("HotplugMode(%d)", int(m))
+}
+
+// DeviceEventType is the type of the device event.
+type DeviceEventType int
+
+const (
+	// DeviceRemoved is notified when the device of the opened serial port is removed.
+	DeviceRemoved DeviceEventType = iota
+	// DeviceAttached is notified when the removed device is attached again.
+	DeviceAttached
+)
+
+// String returns the name of the device event type.
+func (t DeviceEventType) String() string {
+	switch t {
+	case DeviceRemoved:
+		return "Removed"
+	case DeviceAttached:
+		return "Attached"
+	}
+	return fmt.Sprintf("DeviceEventType(%d)", int(t))
+}
+
+// DeviceEventArgs describes the removed or attached device.
+type DeviceEventArgs struct {
+	// Type is the type of the event.
+	Type DeviceEventType
+	// Port is the serial port of the device.
+	Port string
+	// Serial is the serial number of the device. Empty if it's unknown.
+	Serial string
+}
+
+// DeviceEventHandler is called when the device of the serial port is removed or attached.
+type DeviceEventHandler func(m gxcommon.IGXMedia, e DeviceEventArgs)
+
+// Hotplug returns how the removal and re-attach of the device are handled.
+func (g *GXSerial) Hotplug() HotplugMode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.hotplug
+}
+
+// SetHotplug sets how the removal and re-attach of the device are handled.
+// Device notifications are started when the media is opened and stopped when it's closed.
+// If the platform doesn't support device notifications, it's traced and the media works as with HotplugNone.
+func (g *GXSerial) SetHotplug(value HotplugMode) error {
+	if value < HotplugNone || value > HotplugReconnect {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	g.hotplug = value
+	g.mu.Unlock()
+	return nil
+}
+
+// SetOnDeviceChange sets the handler that is called when the device is removed or attached.
+func (g *GXSerial) SetOnDeviceChange(value DeviceEventHandler) {
+	g.mu.Lock()
+	g.onDevice = value
+	g.mu.Unlock()
+}
+
+// startWatch starts the device notifications. The caller holds the lock.
+func (g *GXSerial) startWatch() {
+	if g.hotplug == HotplugNone || g.watchStop != nil {
+		return
+	}
+	g.deviceSerial = deviceSerial(g.Port)
+	stop, err := watchDevice(g)
+	if err != nil {
+		g.trace(false, gxcommon.TraceTypesError, fmt.Sprintf("Device notifications: %v", err))
+		return
+	}
+	g.watchStop = stop
+}
+
+// stopWatch stops the device notifications.
+func (g *GXSerial) stopWatch() {
+	g.mu.Lock()
+	stop := g.watchStop
+	g.watchStop = nil
+	g.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// deviceEvent notifies the device event.
+func (g *GXSerial) deviceEvent(e DeviceEventArgs) {
+	g.tracef(true, gxcommon.TraceTypesInfo, "Device %s: %s %s", e.Type, e.Port, e.Serial)
+	g.mu.RLock()
+	cb := g.onDevice
+	g.mu.RUnlock()
+	if cb != nil {
+		// The device is attached when the port is closed.
+		g.invokeHandler(true, "DeviceChange", func() {
+			cb(g, e)
+		})
+	}
+}
+
+// deviceRemoved is called by the device watcher when the device of the opened port is removed.
+func (g *GXSerial) deviceRemoved() {
+	if !g.IsOpen() {
+		return
+	}
+	g.deviceEvent(DeviceEventArgs{Type: DeviceRemoved, Port: g.Port, Serial: g.deviceSerial})
+	_ = g.closePort()
+}
+
+// deviceAttached is called by the device watcher when the removed device is attached again.
+// port is the serial port of the attached device. The media is opened again in HotplugReconnect mode.
+// The port isn't changed if the serial number of the attached device is different.
+func (g *GXSerial) deviceAttached(port string) {
+	if g.IsOpen() || g.Hotplug() != HotplugReconnect || g.isClosedByUser() {
+		return
+	}
+	g.mu.RLock()
+	serial := g.deviceSerial
+	g.mu.RUnlock()
+	if serial != "" && deviceSerial(port) != serial {
+		return
+	}
+	g.mu.Lock()
+	g.Port = port
+	g.mu.Unlock()
+	g.deviceEvent(DeviceEventArgs{Type: DeviceAttached, Port: port, Serial: serial})
+	// Close can be called while the event is notified.
+	g.lifecycleMu.Lock()
+	defer g.lifecycleMu.Unlock()
+	if g.closedByUser || g.IsOpen() {
+		return
+	}
+	if err := g.open(); err != nil {
+		g.errorf(true, "reconnect", err)
+	}
+}
+
+// isClosedByUser returns true if the media is closed with Close.
+func (g *GXSerial) isClosedByUser() bool {
+	g.lifecycleMu.Lock()
+	defer g.lifecycleMu.Unlock()
+	return g.closedByUser
+}
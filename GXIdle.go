@@ -0,0 +1,123 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// IdleEventArgs describes the idle link.
+type IdleEventArgs struct {
+	// Idle is the time after the last received data or after the port was opened.
+	Idle time.Duration
+	// LastReceived is the time when the data was received last time or when the port was opened.
+	LastReceived time.Time
+}
+
+// IdleEventHandler is called when no data has been received for the idle period.
+type IdleEventHandler func(m gxcommon.IGXMedia, e IdleEventArgs)
+
+// IdlePeriod returns the time without received data after the idle event is notified.
+func (g *GXSerial) IdlePeriod() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.idlePeriod
+}
+
+// SetOnIdle sets the handler that is called when no data has been received for the given period while the port is open.
+// The handler is called again after each period until data is received. Nil handler disables the event.
+func (g *GXSerial) SetOnIdle(period time.Duration, value IdleEventHandler) error {
+	if value != nil && period <= 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopIdleWatch()
+	g.idlePeriod = period
+	g.onIdle = value
+	g.startIdleWatch()
+	return nil
+}
+
+// startIdleWatch starts the idle timer if it's needed. The caller holds the lock.
+func (g *GXSerial) startIdleWatch() {
+	if g.onIdle == nil || g.idleStop != nil || !g.s.isOpen() {
+		return
+	}
+	g.idleStop = make(chan struct{})
+	go g.watchIdle(g.idleStop, g.idlePeriod)
+}
+
+// stopIdleWatch stops the idle timer. The caller holds the lock.
+func (g *GXSerial) stopIdleWatch() {
+	if g.idleStop != nil {
+		close(g.idleStop)
+		g.idleStop = nil
+	}
+}
+
+// watchIdle notifies the idle event until stop is closed.
+func (g *GXSerial) watchIdle(stop chan struct{}, period time.Duration) {
+	timer := time.NewTimer(period)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		last := time.Unix(0, g.lastReceived.Load())
+		idle := time.Since(last)
+		if idle < period {
+			timer.Reset(period - idle)
+			continue
+		}
+		g.mu.RLock()
+		cb := g.onIdle
+		g.mu.RUnlock()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if cb != nil {
+			g.callHandler(true, "Idle", func() {
+				cb(g, IdleEventArgs{Idle: idle, LastReceived: last})
+			})
+		}
+		timer.Reset(period)
+	}
+}
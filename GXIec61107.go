@@ -0,0 +1,254 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// IecMode is the protocol mode of IEC 61107 (IEC 62056-21).
+type IecMode int
+
+const (
+	// IecModeA doesn't change the baud rate.
+	IecModeA IecMode = iota
+	// IecModeB changes the baud rate without acknowledgement.
+	IecModeB
+	// IecModeC changes the baud rate with the acknowledgement message.
+	IecModeC
+	// IecModeE changes to the binary HDLC protocol with the acknowledgement message.
+	IecModeE
+)
+
+// String returns the name of the protocol mode.
+func (m IecMode) String() string {
+	switch m {
+	case IecModeA:
+		return "A"
+	case IecModeB:
+		return "B"
+	case IecModeC:
+		return "C"
+	case IecModeE:
+		return "E"
+	}
+	return fmt.Sprintf("IecMode(%d)", int(m))
+}
+
+// IecIdentification is the identification message "/XXXZ\W...Ident" that the meter sends
+// as a reply to the request message.
+type IecIdentification struct {
+	// Manufacturer is the three letter flag of the manufacturer.
+	Manufacturer string
+	// FastReaction is true if the meter supports 20 ms reaction time.
+	// It's told with the lower case third letter of the manufacturer flag.
+	FastReaction bool
+	// BaudRateID is the baud rate identifier Z.
+	BaudRateID byte
+	// BaudRate is the highest baud rate of the meter. 300 in the mode A.
+	BaudRate gxcommon.BaudRate
+	// Mode is the protocol mode.
+	Mode IecMode
+	// Enhanced are the enhanced identification characters W that follow the back slashes.
+	Enhanced []byte
+	// Identification is the identification of the meter.
+	Identification string
+}
+
+// iecModeCBaudRates are the baud rate identifiers of the modes C and E.
+var iecModeCBaudRates = map[byte]gxcommon.BaudRate{
+	'0': gxcommon.BaudRate300,
+	'1': gxcommon.BaudRate600,
+	'2': gxcommon.BaudRate1200,
+	'3': gxcommon.BaudRate2400,
+	'4': gxcommon.BaudRate4800,
+	'5': gxcommon.BaudRate9600,
+	'6': gxcommon.BaudRate19200,
+}
+
+// iecModeBBaudRates are the baud rate identifiers of the mode B.
+var iecModeBBaudRates = map[byte]gxcommon.BaudRate{
+	'A': gxcommon.BaudRate600,
+	'B': gxcommon.BaudRate1200,
+	'C': gxcommon.BaudRate2400,
+	'D': gxcommon.BaudRate4800,
+	'E': gxcommon.BaudRate9600,
+	'F': gxcommon.BaudRate19200,
+}
+
+// isLetter returns true if the byte is an ASCII letter.
+func isLetter(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z')
+}
+
+// ParseIecIdentification parses the IEC 61107 identification message.
+// The trailing CR LF is optional.
+func ParseIecIdentification(data []byte) (*IecIdentification, error) {
+	msg := data
+	for len(msg) != 0 && (msg[len(msg)-1] == '\r' || msg[len(msg)-1] == '\n') {
+		msg = msg[:len(msg)-1]
+	}
+	if len(msg) < 5 || msg[0] != '/' || !isLetter(msg[1]) || !isLetter(msg[2]) || !isLetter(msg[3]) {
+		return nil, errors.New(localizeDefault(MsgInvalidIecMessage, data))
+	}
+	ret := &IecIdentification{
+		Manufacturer: string(msg[1:4]),
+		FastReaction: msg[3] >= 'a' && msg[3] <= 'z',
+		BaudRateID:   msg[4],
+		BaudRate:     gxcommon.BaudRate300,
+	}
+	rest := msg[5:]
+	for len(rest) > 1 && rest[0] == '\\' {
+		ret.Enhanced = append(ret.Enhanced, rest[1])
+		rest = rest[2:]
+	}
+	ret.Identification = string(rest)
+	switch z := ret.BaudRateID; {
+	case z >= '0' && z <= '9':
+		br, ok := iecModeCBaudRates[z]
+		if !ok {
+			return nil, errors.New(localizeDefault(MsgInvalidIecBaudRate, z))
+		}
+		ret.BaudRate = br
+		ret.Mode = IecModeC
+		for _, w := range ret.Enhanced {
+			if w == '2' {
+				ret.Mode = IecModeE
+			}
+		}
+	case z >= 'A' && z <= 'I':
+		br, ok := iecModeBBaudRates[z]
+		if !ok {
+			return nil, errors.New(localizeDefault(MsgInvalidIecBaudRate, z))
+		}
+		ret.BaudRate = br
+		ret.Mode = IecModeB
+	default:
+		ret.Mode = IecModeA
+	}
+	return ret, nil
+}
+
+// Ack returns the acknowledgement message "ACK V Z Y CR LF" that selects the protocol
+// and the baud rate in the modes C and E. Programming selects the programming mode
+// instead of the data readout. Nil is returned in the modes A and B that are not acknowledged.
+func (id *IecIdentification) Ack(programming bool) []byte {
+	if id.Mode != IecModeC && id.Mode != IecModeE {
+		return nil
+	}
+	// Protocol control character. 2 selects the binary mode.
+	v := byte('0')
+	if id.Mode == IecModeE {
+		v = '2'
+	}
+	y := byte('0')
+	if programming {
+		y = '1'
+	}
+	return []byte{0x06, v, id.BaudRateID, y, '\r', '\n'}
+}
+
+// Settings returns the serial port settings that are used after the identification.
+// The mode E uses the binary 8N1 format and the other modes the 7E1 format.
+func (id *IecIdentification) Settings() (gxcommon.BaudRate, int, gxcommon.Parity, gxcommon.StopBits) {
+	if id.Mode == IecModeE {
+		return id.BaudRate, 8, gxcommon.ParityNone, gxcommon.StopBitsOne
+	}
+	return id.BaudRate, 7, gxcommon.ParityEven, gxcommon.StopBitsOne
+}
+
+// Apply sets the serial port settings that are used after the identification to the media.
+// In the modes C and E send the acknowledgement message with the current settings before the settings are applied.
+func (id *IecIdentification) Apply(media *GXSerial) error {
+	baudRate, dataBits, parity, stopBits := id.Settings()
+	if err := media.SetBaudRate(baudRate); err != nil {
+		return err
+	}
+	if err := media.SetDataBits(dataBits); err != nil {
+		return err
+	}
+	if err := media.SetParity(parity); err != nil {
+		return err
+	}
+	return media.SetStopBits(stopBits)
+}
+
+// IecHandshake sends the request message "/?Address!" and reads the identification message of the meter.
+// In the modes C and E the acknowledgement message is sent and the settings of the selected mode
+// are applied after it, so in the mode E the media is ready for the HDLC frames when IecHandshake returns.
+// The port must be open with the settings of the request, 300 baud and 7E1.
+// Programming selects the programming mode instead of the data readout in the modes C and E.
+// WaitTime is the time that the identification message is waited.
+func (g *GXSerial) IecHandshake(address string, programming bool, waitTime time.Duration) (*IecIdentification, error) {
+	if !g.IsSynchronous() {
+		defer g.GetSynchronous()()
+	}
+	if err := g.Send("/?"+address+"!\r\n", ""); err != nil {
+		return nil, err
+	}
+	r := gxcommon.NewReceiveParameters[[]byte]()
+	r.EOP = byte('\n')
+	ok, err := g.ReceiveWait(r, waitTime)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", localize(g.p, MsgRequestTimeout, g.Port, waitTime), os.ErrDeadlineExceeded)
+	}
+	id, err := ParseIecIdentification(r.Reply.([]byte))
+	if err != nil {
+		return nil, err
+	}
+	if ack := id.Ack(programming); ack != nil {
+		if err = g.Send(ack, ""); err != nil {
+			return nil, err
+		}
+		// The acknowledgement is sent with the current settings.
+		if err = g.waitOutputEmpty(); err != nil {
+			return nil, err
+		}
+		if err = g.s.waitTxEmpty(g.charTime()); err != nil {
+			return nil, err
+		}
+	}
+	if err = id.Apply(g); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
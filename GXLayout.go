@@ -0,0 +1,206 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// ReceiveInto receives a reply like Receive and decodes it to the struct that v points to.
+// The reply is decoded with UnmarshalLayout. args.ReplyType is ignored and args.Reply is the received bytes.
+func (g *GXSerial) ReceiveInto(v any, args *gxcommon.ReceiveParameters) (bool, error) {
+	tmp := *args
+	tmp.ReplyType = gxcommon.DataTypeBytes
+	ok, err := g.Receive(&tmp)
+	args.Reply = tmp.Reply
+	if !ok || err != nil {
+		return ok, err
+	}
+	return true, UnmarshalLayout(tmp.Reply.([]byte), v)
+}
+
+// UnmarshalLayout decodes the binary data to the struct that v points to.
+// The layout of the fields is given with the layout tag: `layout:"offset[,size][,le|be]"`, e.g.
+//
+//	type Status struct {
+//		Address uint8  `layout:"0"`
+//		Value   uint32 `layout:"1,le"`
+//		Name    string `layout:"5,8"`
+//		Data    []byte `layout:"13"`
+//	}
+//
+// The offset is the position of the field from the start of the struct. Numbers are big endian
+// unless le is given. The integer, float, bool, string, []byte, byte array and struct fields are supported.
+// The size is needed for strings and byte slices that are not at the end of the data.
+// Trailing NUL bytes and spaces are trimmed from fixed size strings. Fields without the tag are not decoded.
+// io.ErrUnexpectedEOF is returned if the data is too short.
+func UnmarshalLayout(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%s: %w", localizeDefault(MsgLayoutTarget), gxcommon.ErrInvalidArgument)
+	}
+	return unmarshalStruct(data, rv.Elem())
+}
+
+// layoutField is the parsed layout tag.
+type layoutField struct {
+	offset int
+	// Size of the field. -1 if it's not given.
+	size  int
+	order binary.ByteOrder
+}
+
+// parseLayoutTag parses the layout tag of the field.
+func parseLayoutTag(tag string) (layoutField, error) {
+	ret := layoutField{size: -1, order: binary.BigEndian}
+	parts := strings.Split(tag, ",")
+	var err error
+	if ret.offset, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil || ret.offset < 0 {
+		return ret, gxcommon.ErrInvalidArgument
+	}
+	for _, it := range parts[1:] {
+		switch it = strings.TrimSpace(it); it {
+		case "le":
+			ret.order = binary.LittleEndian
+		case "be":
+			ret.order = binary.BigEndian
+		default:
+			if ret.size, err = strconv.Atoi(it); err != nil || ret.size < 0 {
+				return ret, gxcommon.ErrInvalidArgument
+			}
+		}
+	}
+	return ret, nil
+}
+
+// unmarshalStruct decodes the tagged fields of the struct.
+func unmarshalStruct(data []byte, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("layout")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		f, err := parseLayoutTag(tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", localizeDefault(MsgInvalidLayoutTag, tag, sf.Name), err)
+		}
+		if err := unmarshalField(data, f, rv.Field(i)); err != nil {
+			return fmt.Errorf("%s: %w", localizeDefault(MsgLayoutField, sf.Name), err)
+		}
+	}
+	return nil
+}
+
+// unmarshalField decodes the field from its offset.
+func unmarshalField(data []byte, f layoutField, fv reflect.Value) error {
+	if f.offset > len(data) {
+		return io.ErrUnexpectedEOF
+	}
+	data = data[f.offset:]
+	size := f.size
+	switch fv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Struct:
+		if size == -1 {
+			size = len(data)
+		}
+	case reflect.Array:
+		size = fv.Len()
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		size = 1
+	case reflect.Int16, reflect.Uint16:
+		size = 2
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		size = 4
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		size = 8
+	default:
+		return fmt.Errorf("%s: %w", localizeDefault(MsgUnsupportedLayout, fv.Type()), gxcommon.ErrInvalidArgument)
+	}
+	if size > len(data) {
+		return io.ErrUnexpectedEOF
+	}
+	data = data[:size]
+	switch fv.Kind() {
+	case reflect.String:
+		if f.size != -1 {
+			data = []byte(strings.TrimRight(string(data), "\x00 "))
+		}
+		fv.SetString(string(data))
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%s: %w", localizeDefault(MsgUnsupportedLayout, fv.Type()), gxcommon.ErrInvalidArgument)
+		}
+		fv.SetBytes(append([]byte(nil), data...))
+	case reflect.Array:
+		if fv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%s: %w", localizeDefault(MsgUnsupportedLayout, fv.Type()), gxcommon.ErrInvalidArgument)
+		}
+		reflect.Copy(fv, reflect.ValueOf(data))
+	case reflect.Struct:
+		return unmarshalStruct(data, fv)
+	case reflect.Bool:
+		fv.SetBool(data[0] != 0)
+	case reflect.Int8:
+		fv.SetInt(int64(int8(data[0])))
+	case reflect.Uint8:
+		fv.SetUint(uint64(data[0]))
+	case reflect.Int16:
+		fv.SetInt(int64(int16(f.order.Uint16(data))))
+	case reflect.Uint16:
+		fv.SetUint(uint64(f.order.Uint16(data)))
+	case reflect.Int32:
+		fv.SetInt(int64(int32(f.order.Uint32(data))))
+	case reflect.Uint32:
+		fv.SetUint(uint64(f.order.Uint32(data)))
+	case reflect.Int64:
+		fv.SetInt(int64(f.order.Uint64(data)))
+	case reflect.Uint64:
+		fv.SetUint(f.order.Uint64(data))
+	case reflect.Float32:
+		fv.SetFloat(float64(math.Float32frombits(f.order.Uint32(data))))
+	case reflect.Float64:
+		fv.SetFloat(math.Float64frombits(f.order.Uint64(data)))
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LineStep is a single step of a control line sequence.
+// If Wait is non-zero the step is a delay and Line and State are ignored.
+type LineStep struct {
+	// Line is the control line that is set.
+	Line ControlLine
+	// State is the new state of the line.
+	State bool
+	// Wait is the time to wait before the next step.
+	Wait time.Duration
+}
+
+// LineSequence is an ordered list of control line steps.
+type LineSequence []LineStep
+
+// String returns the sequence in esptool custom reset sequence format, e.g. "D0|R1|W0.1|D1|R0|W0.05|D0".
+func (s LineSequence) String() string {
+	parts := make([]string, 0, len(s))
+	for _, it := range s {
+		switch {
+		case it.Wait != 0:
+			parts = append(parts, "W"+strconv.FormatFloat(it.Wait.Seconds(), 'f', -1, 64))
+		case it.Line == ControlLineRts:
+			parts = append(parts, "R"+boolToDigit(it.State))
+		default:
+			parts = append(parts, "D"+boolToDigit(it.State))
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func boolToDigit(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+// ParseLineSequence parses a sequence in esptool custom reset sequence format.
+// D sets DTR, R sets RTS and W waits the given amount of seconds.
+func ParseLineSequence(value string) (LineSequence, error) {
+	b := NewLineSequenceBuilder()
+	for _, it := range strings.Split(value, "|") {
+		it = strings.TrimSpace(it)
+		if len(it) < 2 {
+			return nil, errors.New(localizeDefault(MsgInvalidLineStep, it))
+		}
+		switch it[0] {
+		case 'D', 'R':
+			on, err := strconv.ParseBool(it[1:])
+			if err != nil {
+				return nil, errors.New(localizeDefault(MsgInvalidLineStep, it))
+			}
+			if it[0] == 'D' {
+				b.Dtr(on)
+			} else {
+				b.Rts(on)
+			}
+		case 'W':
+			sec, err := strconv.ParseFloat(it[1:], 64)
+			if err != nil || sec < 0 {
+				return nil, errors.New(localizeDefault(MsgInvalidLineStep, it))
+			}
+			b.Wait(time.Duration(sec * float64(time.Second)))
+		default:
+			return nil, errors.New(localizeDefault(MsgInvalidLineStep, it))
+		}
+	}
+	return b.Build(), nil
+}
+
+// LineSequenceBuilder is used to build custom control line sequences.
+type LineSequenceBuilder struct {
+	steps LineSequence
+}
+
+// NewLineSequenceBuilder creates an empty line sequence builder.
+func NewLineSequenceBuilder() *LineSequenceBuilder {
+	return &LineSequenceBuilder{}
+}
+
+// Set adds a step that sets the given control line.
+func (b *LineSequenceBuilder) Set(line ControlLine, on bool) *LineSequenceBuilder {
+	b.steps = append(b.steps, LineStep{Line: line, State: on})
+	return b
+}
+
+// Dtr adds a step that sets the DTR line.
+func (b *LineSequenceBuilder) Dtr(on bool) *LineSequenceBuilder {
+	return b.Set(ControlLineDtr, on)
+}
+
+// Rts adds a step that sets the RTS line.
+func (b *LineSequenceBuilder) Rts(on bool) *LineSequenceBuilder {
+	return b.Set(ControlLineRts, on)
+}
+
+// Wait adds a delay step.
+func (b *LineSequenceBuilder) Wait(value time.Duration) *LineSequenceBuilder {
+	if value > 0 {
+		b.steps = append(b.steps, LineStep{Wait: value})
+	}
+	return b
+}
+
+// Build returns the built sequence.
+func (b *LineSequenceBuilder) Build() LineSequence {
+	return append(LineSequence(nil), b.steps...)
+}
+
+var (
+	// SequenceEsp32Classic puts ESP32/ESP8266 boards with the classic
+	// DTR->IO0, RTS->EN auto-reset circuit into the bootloader mode.
+	SequenceEsp32Classic = LineSequence{
+		{Line: ControlLineDtr, State: false},
+		{Line: ControlLineRts, State: true},
+		{Wait: 100 * time.Millisecond},
+		{Line: ControlLineDtr, State: true},
+		{Line: ControlLineRts, State: false},
+		{Wait: 50 * time.Millisecond},
+		{Line: ControlLineDtr, State: false},
+	}
+
+	// SequenceEsp32UsbJtag puts ESP32 chips with the built-in USB-Serial/JTAG
+	// peripheral into the bootloader mode.
+	SequenceEsp32UsbJtag = LineSequence{
+		{Line: ControlLineRts, State: false},
+		{Line: ControlLineDtr, State: false},
+		{Wait: 100 * time.Millisecond},
+		{Line: ControlLineDtr, State: true},
+		{Line: ControlLineRts, State: false},
+		{Wait: 100 * time.Millisecond},
+		{Line: ControlLineRts, State: true},
+		{Line: ControlLineDtr, State: false},
+		{Line: ControlLineRts, State: true},
+		{Wait: 100 * time.Millisecond},
+		{Line: ControlLineDtr, State: false},
+		{Line: ControlLineRts, State: false},
+	}
+
+	// SequenceStm32 puts STM32 boards with RTS->BOOT0 and DTR->NRST wiring
+	// into the system memory bootloader.
+	SequenceStm32 = LineSequence{
+		{Line: ControlLineRts, State: true},
+		{Line: ControlLineDtr, State: true},
+		{Wait: 100 * time.Millisecond},
+		{Line: ControlLineDtr, State: false},
+		{Wait: 50 * time.Millisecond},
+		{Line: ControlLineRts, State: false},
+	}
+)
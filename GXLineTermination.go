@@ -0,0 +1,65 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// LineTermination is the terminator that is appended to the sent strings.
+type LineTermination string
+
+const (
+	// LineTerminationNone doesn't append a terminator.
+	LineTerminationNone LineTermination = ""
+	// LineTerminationCR appends a carriage return.
+	LineTerminationCR LineTermination = "\r"
+	// LineTerminationLF appends a line feed.
+	LineTerminationLF LineTermination = "\n"
+	// LineTerminationCRLF appends a carriage return and a line feed.
+	LineTerminationCRLF LineTermination = "\r\n"
+)
+
+// LineTermination returns the terminator that is appended to the sent strings.
+func (g *GXSerial) LineTermination() LineTermination {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.lineTermination
+}
+
+// SetLineTermination sets the terminator that is appended to every string that is sent with Send,
+// so interactive protocols don't need a second Send for the EOP. Custom bytes can be used,
+// e.g. LineTermination("\x03"). The terminator is appended after the text codec, before the checksum.
+// Other data types and SendFramed are not affected. LineTerminationNone disables the terminator.
+func (g *GXSerial) SetLineTermination(value LineTermination) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lineTermination = value
+}
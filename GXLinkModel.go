@@ -0,0 +1,84 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// LinkModel describes simulated link characteristics of a test media.
+type LinkModel struct {
+	// BaudRate is the simulated baud rate. Zero transfers data without a delay.
+	BaudRate int
+	// BitsPerByte is the amount of bits on the wire for each byte,
+	// including start, parity and stop bits. Default is 10 (8N1).
+	BitsPerByte int
+	// Latency is a fixed delay added before each received chunk.
+	Latency time.Duration
+	// Jitter is the maximum random delay added before each received chunk.
+	Jitter time.Duration
+	// Seed initializes the jitter generator so that runs are repeatable.
+	// Zero uses a random seed.
+	Seed uint64
+}
+
+// transmissionTime returns the time that count bytes take on the wire.
+func (m *LinkModel) transmissionTime(count int) time.Duration {
+	if m.BaudRate <= 0 {
+		return 0
+	}
+	bits := m.BitsPerByte
+	if bits <= 0 {
+		bits = 10
+	}
+	return time.Duration(int64(count) * int64(bits) * int64(time.Second) / int64(m.BaudRate))
+}
+
+// newRand returns the jitter generator for the model.
+func (m *LinkModel) newRand() *rand.Rand {
+	if m.Seed == 0 {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return rand.New(rand.NewPCG(m.Seed, m.Seed))
+}
+
+// delay returns the delay before count received bytes are delivered.
+func (m *LinkModel) delay(count int, r *rand.Rand) time.Duration {
+	ret := m.Latency + m.transmissionTime(count)
+	if m.Jitter > 0 {
+		ret += time.Duration(r.Int64N(int64(m.Jitter) + 1))
+	}
+	return ret
+}
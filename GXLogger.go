@@ -0,0 +1,87 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// Logger returns the logger of the internal conditions. Nil if they are not logged.
+func (g *GXSerial) Logger() *slog.Logger {
+	return g.logger.Load()
+}
+
+// SetLogger sets the logger of the internal conditions that are not reported otherwise,
+// e.g. errors when the port is closed, failed cleanup system calls and the errors that
+// are notified when no error handler is set. The value is a *slog.Logger or an io.Writer
+// that the conditions are written to as text. Nil disables the logging.
+// Protocol traffic is reported with the trace event and it's not logged.
+func (g *GXSerial) SetLogger(value any) error {
+	switch v := value.(type) {
+	case nil:
+		g.logger.Store(nil)
+	case *slog.Logger:
+		g.logger.Store(v)
+	case io.Writer:
+		g.logger.Store(slog.New(slog.NewTextHandler(v, nil)))
+	default:
+		return gxcommon.ErrInvalidArgument
+	}
+	return nil
+}
+
+// log logs the internal condition with the port name.
+func (g *GXSerial) log(level slog.Level, msg string, args ...any) {
+	if l := g.logger.Load(); l != nil {
+		l.Log(context.Background(), level, msg, append([]any{"port", g.Port}, args...)...)
+	}
+}
+
+// logError logs the error of the port if it's not nil.
+func (p *port) logError(msg string, err error) {
+	if err == nil || p.log == nil {
+		return
+	}
+	if l := p.log.Load(); l != nil {
+		l.Error(msg, "err", err)
+	}
+}
+
+// portLogger is the logger that the port shares with the media.
+type portLogger = *atomic.Pointer[slog.Logger]
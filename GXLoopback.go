@@ -0,0 +1,142 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// selfTestMargin is the time that is waited for the looped back data in addition to its transfer time.
+const selfTestMargin = 500 * time.Millisecond
+
+// SelfTestError is returned by SelfTest when the looped back data differs from the sent pattern.
+type SelfTestError struct {
+	// Sent is the sent pattern.
+	Sent []byte
+	// Received is the received data. It's shorter than Sent if all data is not received.
+	Received []byte
+	// Offset is the position of the first byte that differs.
+	Offset int
+	msg    string
+}
+
+// Error implements error.
+func (e *SelfTestError) Error() string {
+	return e.msg
+}
+
+// Loopback returns true if the internal loopback mode of the driver is enabled.
+func (g *GXSerial) Loopback() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.loopback
+}
+
+// EnableLoopback enables or disables the internal loopback mode of the driver, where the sent data
+// is received back without leaving the UART. See Capabilities.Loopback.
+// If the port is open, the mode is changed immediately.
+// *UnsupportedSettingError is returned if the driver doesn't support the loopback mode.
+func (g *GXSerial) EnableLoopback(value bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() && value != g.loopback {
+		if err := g.applyLoopback(value); err != nil {
+			return err
+		}
+	}
+	g.loopback = value
+	return nil
+}
+
+// applyLoopback sets the loopback mode to the driver. The caller holds the lock.
+func (g *GXSerial) applyLoopback(value bool) error {
+	if !g.s.capabilities().Loopback {
+		return g.unsupported("Loopback", value)
+	}
+	return g.s.setLoopback(value)
+}
+
+// selfTestPattern returns the bytes that are sent in the self-test.
+// The pattern has all values that fit in the data bits.
+func selfTestPattern(dataBits int) []byte {
+	mask := byte(1<<dataBits - 1)
+	ret := []byte{0x55, 0xAA & mask, 0x00, mask}
+	for i := 0; i <= int(mask); i++ {
+		ret = append(ret, byte(i))
+	}
+	return ret
+}
+
+// SelfTest sends a test pattern and verifies that it's received back. Use it with the loopback mode
+// of the driver (EnableLoopback) or with a loopback plug to check the adapter and the cabling.
+// The queued data of the driver and the data that is received before the test is discarded. The pattern is sent without the checksum and the EOP.
+// *SelfTestError is returned if the received data differs from the pattern or it's not received in time.
+func (g *GXSerial) SelfTest() error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if err := g.checkWritable(); err != nil {
+		return err
+	}
+	if !g.IsSynchronous() {
+		defer g.GetSynchronous()()
+	}
+	pattern := selfTestPattern(g.dataBits)
+	if err := g.s.flush(); err != nil {
+		return err
+	}
+	g.received.Get(-1)
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	err := g.sendChunk(pattern)
+	g.sendLock.release()
+	if err != nil {
+		return err
+	}
+	timeout := g.charTime()*time.Duration(len(pattern)) + selfTestMargin
+	g.received.Search(nil, len(pattern), timeout)
+	received := g.received.Get(-1)
+	if bytes.Equal(received, pattern) {
+		return nil
+	}
+	offset := 0
+	for offset < len(received) && offset < len(pattern) && received[offset] == pattern[offset] {
+		offset++
+	}
+	return &SelfTestError{Sent: pattern, Received: received, Offset: offset,
+		msg: localize(g.p, MsgSelfTestFailed, g.Port, offset, len(pattern))}
+}
@@ -0,0 +1,263 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// MessageKey identifies a localized message of the media.
+type MessageKey string
+
+// Message keys.
+const (
+	MsgClosingConnection    MessageKey = "msg.closing_connection"
+	MsgConnectionClosed     MessageKey = "msg.connection_closed"
+	MsgConnectionFailed     MessageKey = "msg.connection_failed"
+	MsgCountOrEop           MessageKey = "msg.count_or_eop"
+	MsgConnectedTo          MessageKey = "msg.connected_to"
+	MsgConnectFailed        MessageKey = "msg.connect_failed"
+	MsgConnectingTo         MessageKey = "msg.connecting_to"
+	MsgNoSerialPortSelected MessageKey = "msg.no_serial_port_selected"
+	MsgPortNotOpen          MessageKey = "msg.port_not_open"
+	MsgSendCancelled        MessageKey = "msg.send_cancelled"
+	MsgUnsupportedSetting   MessageKey = "msg.unsupported_setting"
+	MsgMediaNotOpen         MessageKey = "msg.media_not_open"
+	MsgNoReplayRecords      MessageKey = "msg.no_replay_records"
+	MsgReplayEnded          MessageKey = "msg.replay_ended"
+	MsgReplayMismatch       MessageKey = "msg.replay_mismatch"
+	MsgRequestTimeout       MessageKey = "msg.request_timeout"
+	MsgChecksumMismatch     MessageKey = "msg.checksum_mismatch"
+	MsgInvalidFrame         MessageKey = "msg.invalid_frame"
+	MsgNotAcknowledged      MessageKey = "msg.not_acknowledged"
+	MsgReadOnly             MessageKey = "msg.read_only"
+	MsgReaderStopped        MessageKey = "msg.reader_stopped"
+	MsgProfileNotFound      MessageKey = "msg.profile_not_found"
+	MsgHandlerPanic         MessageKey = "msg.handler_panic"
+	MsgUnencodableText      MessageKey = "msg.unencodable_text"
+	MsgRingDetected         MessageKey = "msg.ring_detected"
+	MsgAnswerFailed         MessageKey = "msg.answer_failed"
+	MsgWatchdogExpired      MessageKey = "msg.watchdog_expired"
+	MsgBufferOverflow       MessageKey = "msg.buffer_overflow"
+	MsgAggregatorClosed     MessageKey = "msg.aggregator_closed"
+	MsgSendQueueTimeout     MessageKey = "msg.send_queue_timeout"
+	MsgFramingNotSupported  MessageKey = "msg.framing_not_supported"
+	MsgLineWaitTimeout      MessageKey = "msg.line_wait_timeout"
+	MsgSelfTestFailed       MessageKey = "msg.self_test_failed"
+	MsgLineChangeTimeout    MessageKey = "msg.line_change_timeout"
+	MsgBreakSent            MessageKey = "msg.break_sent"
+	MsgBreakReceived        MessageKey = "msg.break_received"
+	MsgRtsToggleConflict    MessageKey = "msg.rts_toggle_conflict"
+	MsgEchoMismatch         MessageKey = "msg.echo_mismatch"
+	MsgNak                  MessageKey = "msg.nak"
+	MsgFrameDropped         MessageKey = "msg.frame_dropped"
+	MsgOutputDrainTimeout   MessageKey = "msg.output_drain_timeout"
+	MsgInvalidLineStep      MessageKey = "msg.invalid_line_step"
+	MsgInvalidReplayLog     MessageKey = "msg.invalid_replay_log"
+	MsgCatalogNotWritable   MessageKey = "msg.catalog_not_writable"
+	MsgTransientReadError   MessageKey = "msg.transient_read_error"
+	MsgUnknownSetting       MessageKey = "msg.unknown_setting"
+	MsgInvalidSetting       MessageKey = "msg.invalid_setting"
+	MsgInvalidIecMessage    MessageKey = "msg.invalid_iec_identification"
+	MsgInvalidIecBaudRate   MessageKey = "msg.invalid_iec_baud_rate"
+	MsgNoDle                MessageKey = "msg.no_dle"
+	MsgNoPortPair           MessageKey = "msg.no_port_pair"
+	MsgPortPairNotFound     MessageKey = "msg.port_pair_not_found"
+	MsgInvalidModeString    MessageKey = "msg.invalid_mode_string"
+	MsgLayoutTarget         MessageKey = "msg.layout_target"
+	MsgInvalidLayoutTag     MessageKey = "msg.invalid_layout_tag"
+	MsgLayoutField          MessageKey = "msg.layout_field"
+	MsgUnsupportedLayout    MessageKey = "msg.unsupported_layout_type"
+)
+
+// defaultMessages are the built-in English messages.
+var defaultMessages = map[MessageKey]string{
+	MsgClosingConnection:    "Closing serial port '%s' connection",
+	MsgConnectionClosed:     "Serial port connection '%s' closed",
+	MsgConnectionFailed:     "Serial port connection failed: %v",
+	MsgCountOrEop:           "Either Count or EOP must be set",
+	MsgConnectedTo:          "Connected to serial port '%s'",
+	MsgConnectFailed:        "Connect to serial port '%s' failed: %v",
+	MsgConnectingTo:         "Connecting to serial port '%s'",
+	MsgNoSerialPortSelected: "No serial port selected. Please select a serial port.",
+	MsgPortNotOpen:          "Serial port '%s' is not open.",
+	MsgSendCancelled:        "Sending to serial port '%s' was cancelled.",
+	MsgUnsupportedSetting:   "%s %v is not supported on this platform.",
+	MsgMediaNotOpen:         "Media '%s' is not open.",
+	MsgNoReplayRecords:      "Replay media has no records.",
+	MsgReplayEnded:          "All records of replay '%s' are already replayed.",
+	MsgReplayMismatch:       "Sent data % X doesn't match the recorded data % X.",
+	MsgRequestTimeout:       "No reply received from serial port '%s' in %v.",
+	MsgChecksumMismatch:     "Invalid checksum. Expected % X, received % X.",
+	MsgInvalidFrame:         "Invalid frame % X: %v",
+	MsgNotAcknowledged:      "Serial port '%s' didn't acknowledge the data after %d attempts",
+	MsgReadOnly:             "Serial port '%s' is opened in read-only mode.",
+	MsgReaderStopped:        "Reading from serial port '%s' stopped after %d restarts.",
+	MsgProfileNotFound:      "Profile '%s' not found in '%s'.",
+	MsgHandlerPanic:         "%s event handler panicked: %v",
+	MsgUnencodableText:      "Character %q at offset %d can't be encoded with %s.",
+	MsgRingDetected:         "Ring %[2]d detected on serial port '%[1]s'.",
+	MsgAnswerFailed:         "Answering the call on serial port '%s' failed.",
+	MsgWatchdogExpired:      "No data was received from serial port '%s' in %v after data was sent. Action: %v.",
+	MsgBufferOverflow:       "Receive buffer of serial port '%s' exceeded %d bytes before the frame ended.",
+	MsgAggregatorClosed:     "Aggregator is closed.",
+	MsgSendQueueTimeout:     "Serial port '%s' didn't become free for sending in %v",
+	MsgFramingNotSupported:  "the EOP %T can't build the sent frames",
+	MsgLineWaitTimeout:      "The %s line of serial port '%s' didn't become %t in %v",
+	MsgSelfTestFailed:       "Self-test of serial port '%s' failed at byte %d of %d.",
+	MsgLineChangeTimeout:    "The %s lines of serial port '%s' didn't change in %v",
+	MsgBreakSent:            "Break of %v sent on serial port '%s'.",
+	MsgBreakReceived:        "Break received on serial port '%s'.",
+	MsgRtsToggleConflict:    "RTS toggle and the native RS-485 mode can't be used at the same time on serial port '%s'.",
+	MsgEchoMismatch:         "Echo of the sent data doesn't match the received data on serial port '%s'.",
+	MsgNak:                  "Negative acknowledgement (NAK) received.",
+	MsgFrameDropped:         "Serial port '%s' dropped a received frame because the consumer is too slow",
+	MsgOutputDrainTimeout:   "Serial port '%s' didn't send the queued data in %v",
+	MsgInvalidLineStep:      "Invalid line sequence step: %q",
+	MsgInvalidReplayLog:     "Invalid data on line %d of the replay log",
+	MsgCatalogNotWritable:   "Messages can't be set to the catalog %T.",
+	MsgTransientReadError:   "Transient read error on serial port '%s': %v",
+	MsgUnknownSetting:       "Unknown setting %s.",
+	MsgInvalidSetting:       "Invalid %s value: %s",
+	MsgInvalidIecMessage:    "Invalid IEC 61107 identification message: %q",
+	MsgInvalidIecBaudRate:   "Invalid IEC 61107 baud rate identifier: %c",
+	MsgNoDle:                "The partner didn't answer STX with DLE.",
+	MsgNoPortPair:           "No virtual port pair found.",
+	MsgPortPairNotFound:     "Virtual port pair %s not found.",
+	MsgInvalidModeString:    "Invalid serial port string %q. device:baud is expected.",
+	MsgLayoutTarget:         "The layout target must be a pointer to a struct.",
+	MsgInvalidLayoutTag:     "Invalid layout tag %q of field %s",
+	MsgLayoutField:          "Decoding of field %s failed",
+	MsgUnsupportedLayout:    "Unsupported layout field type %s",
+}
+
+var (
+	catalogMu sync.RWMutex
+	// Catalog used by the media. Nil if the default catalog is used.
+	messageCatalog catalog.Catalog
+	// Language of the new media.
+	defaultLanguage = language.AmericanEnglish
+)
+
+// DefaultMessages returns the built-in English messages.
+// Applications can use them as a base when they build their own catalogs.
+func DefaultMessages() map[MessageKey]string {
+	ret := make(map[MessageKey]string, len(defaultMessages))
+	for k, v := range defaultMessages {
+		ret[k] = v
+	}
+	return ret
+}
+
+// SetMessage sets the message for the given key and language.
+// It overrides the built-in message. Media must be localized again after the change.
+// If a custom catalog is set with SetCatalog, it must be a *catalog.Builder. Otherwise an error is returned
+// and the messages are changed in the catalog itself.
+func SetMessage(tag language.Tag, key MessageKey, msg string) error {
+	catalogMu.RLock()
+	c := messageCatalog
+	catalogMu.RUnlock()
+	if c == nil {
+		return message.SetString(tag, string(key), msg)
+	}
+	if b, ok := c.(*catalog.Builder); ok {
+		return b.SetString(tag, string(key), msg)
+	}
+	return errors.New(localizeDefault(MsgCatalogNotWritable, c))
+}
+
+// SetCatalog sets the message catalog that is used instead of the default catalog.
+// The catalog must contain all message keys for the supported languages.
+// Nil restores the default catalog. Media must be localized again after the change.
+func SetCatalog(value catalog.Catalog) {
+	catalogMu.Lock()
+	messageCatalog = value
+	catalogMu.Unlock()
+}
+
+// DefaultLanguage returns the language of the new media.
+func DefaultLanguage() language.Tag {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return defaultLanguage
+}
+
+// SetDefaultLanguage sets the language of the media that are created after the call.
+// Existing media are not changed. Use Localize to change them.
+func SetDefaultLanguage(value language.Tag) {
+	catalogMu.Lock()
+	defaultLanguage = value
+	catalogMu.Unlock()
+}
+
+// newPrinter returns the printer for the language using the current catalog.
+func newPrinter(tag language.Tag) *message.Printer {
+	catalogMu.RLock()
+	c := messageCatalog
+	catalogMu.RUnlock()
+	if c == nil {
+		return message.NewPrinter(tag)
+	}
+	return message.NewPrinter(tag, message.Catalog(c))
+}
+
+// localize returns the localized message for the key.
+func localize(p *message.Printer, key MessageKey, a ...any) string {
+	return p.Sprintf(string(key), a...)
+}
+
+// localizeDefault returns the message for the key in the default language.
+// It's used by the functions that are not bound to a media.
+func localizeDefault(key MessageKey, a ...any) string {
+	return localize(newPrinter(DefaultLanguage()), key, a...)
+}
+
+//nolint:errcheck
+func init() {
+	// --- English (default) ---
+	for k, v := range defaultMessages {
+		message.SetString(language.AmericanEnglish, string(k), v)
+	}
+	// --- Other built-in languages ---
+	for tag, messages := range translations {
+		for k, v := range messages {
+			message.SetString(tag, string(k), v)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// SetMirror sets the sink where all sent and received data is duplicated in real time.
+// The sink is an io.Writer or an IGXMedia. Each send and receive is written as a line
+// with a timestamp and a TX or RX marker, e.g. "2026-01-02T15:04:05.123Z RX: 7E A0 07",
+// so the output can be replayed with ParseReplayLog. Trace redactor is applied to the mirrored data.
+// Nil disables mirroring.
+func (g *GXSerial) SetMirror(sink any) error {
+	var write func(line []byte) error
+	switch v := sink.(type) {
+	case nil:
+	case gxcommon.IGXMedia:
+		write = func(line []byte) error {
+			return v.Send(line, "")
+		}
+	case io.Writer:
+		write = func(line []byte) error {
+			_, err := v.Write(line)
+			return err
+		}
+	default:
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	g.mirror = write
+	g.mu.Unlock()
+	return nil
+}
+
+// mirrorData writes sent or received data to the mirror sink.
+func (g *GXSerial) mirrorData(now time.Time, prefix string, data []byte) {
+	g.mu.RLock()
+	write := g.mirror
+	g.mu.RUnlock()
+	if write == nil {
+		return
+	}
+	line := fmt.Appendf(nil, "%s %s% X\n", now.Format(time.RFC3339Nano), prefix, data)
+	g.mirrorMu.Lock()
+	err := write(line)
+	g.mirrorMu.Unlock()
+	if err != nil {
+		g.errorf(true, "mirror", err)
+	}
+}
@@ -0,0 +1,200 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// linePollInterval is the interval how often the modem status lines are read when the driver can't wait for the changes.
+const linePollInterval = 10 * time.Millisecond
+
+// ModemLine identifies a modem status input line.
+type ModemLine int
+
+const (
+	// ModemLineCts is the Clear To Send line.
+	ModemLineCts ModemLine = iota
+	// ModemLineDsr is the Data Set Ready line.
+	ModemLineDsr
+	// ModemLineDcd is the Data Carrier Detect line.
+	ModemLineDcd
+	// ModemLineRi is the Ring Indicator line.
+	ModemLineRi
+)
+
+// String returns the name of the modem line.
+func (l ModemLine) String() string {
+	switch l {
+	case ModemLineCts:
+		return "CTS"
+	case ModemLineDsr:
+		return "DSR"
+	case ModemLineDcd:
+		return "DCD"
+	case ModemLineRi:
+		return "RI"
+	}
+	return "Unknown"
+}
+
+// ModemLineMask selects modem status lines.
+type ModemLineMask int
+
+const (
+	// ModemLineMaskCts selects the Clear To Send line.
+	ModemLineMaskCts ModemLineMask = 1 << iota
+	// ModemLineMaskDsr selects the Data Set Ready line.
+	ModemLineMaskDsr
+	// ModemLineMaskDcd selects the Data Carrier Detect line.
+	ModemLineMaskDcd
+	// ModemLineMaskRi selects the Ring Indicator line.
+	ModemLineMaskRi
+	// ModemLineMaskAll selects all modem status lines.
+	ModemLineMaskAll = ModemLineMaskCts | ModemLineMaskDsr | ModemLineMaskDcd | ModemLineMaskRi
+)
+
+// String returns the names of the selected lines separated with '|'.
+func (m ModemLineMask) String() string {
+	var names []string
+	for line := ModemLineCts; line <= ModemLineRi; line++ {
+		if m&line.mask() != 0 {
+			names = append(names, line.String())
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// mask returns the mask that selects the line.
+func (l ModemLine) mask() ModemLineMask {
+	return 1 << l
+}
+
+// state returns the state of the line.
+func (l ModemLine) state(s ModemStatus) bool {
+	switch l {
+	case ModemLineCts:
+		return s.Cts
+	case ModemLineDsr:
+		return s.Dsr
+	case ModemLineDcd:
+		return s.Dcd
+	}
+	return s.Ri
+}
+
+// ModemStatus returns the states of the modem status lines. The port must be open.
+// Use it instead of the single line getters when several lines are needed, so they are read at the same time.
+func (g *GXSerial) ModemStatus() (ModemStatus, error) {
+	if !g.s.isOpen() {
+		return ModemStatus{}, errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	return g.s.getModemStatus()
+}
+
+// lineHolding returns the state of the modem status line.
+func (g *GXSerial) lineHolding(line ModemLine) (bool, error) {
+	status, err := g.ModemStatus()
+	if err != nil {
+		return false, err
+	}
+	return line.state(status), nil
+}
+
+// CtsHolding returns true if the Clear To Send line is asserted. The port must be open.
+func (g *GXSerial) CtsHolding() (bool, error) {
+	return g.lineHolding(ModemLineCts)
+}
+
+// DsrHolding returns true if the Data Set Ready line is asserted. The port must be open.
+func (g *GXSerial) DsrHolding() (bool, error) {
+	return g.lineHolding(ModemLineDsr)
+}
+
+// CDHolding returns true if the Data Carrier Detect line is asserted. The port must be open.
+func (g *GXSerial) CDHolding() (bool, error) {
+	return g.lineHolding(ModemLineDcd)
+}
+
+// RingIndicator returns true if the Ring Indicator line is asserted. The port must be open.
+// Use SetOnRing to be notified of the rings.
+func (g *GXSerial) RingIndicator() (bool, error) {
+	return g.lineHolding(ModemLineRi)
+}
+
+// WaitForLine waits until the modem status line has the given state, e.g. until the device
+// asserts CTS or DSR to tell that it accepts commands. It returns immediately if the line
+// already has the state. If the state is not reached before the timeout, an error that wraps
+// os.ErrDeadlineExceeded is returned. Zero timeout waits until the state is reached or the port is closed.
+// The changes of the line are waited as with WaitForLineChange.
+func (g *GXSerial) WaitForLine(line ModemLine, state bool, timeout time.Duration) error {
+	if line < ModemLineCts || line > ModemLineRi || timeout < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	// The waiter is added before the state is read so that a change after the read isn't missed.
+	ch, stop, err := g.addPinWaiter(line.mask())
+	if err != nil {
+		return err
+	}
+	defer g.removePinWaiter(ch)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		status, err := g.s.getModemStatus()
+		if err != nil {
+			return err
+		}
+		if line.state(status) == state {
+			return nil
+		}
+		// The state is read again after the change, because the changes that follow quickly are merged.
+		select {
+		case <-ch:
+		case <-stop:
+			return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+		case <-expired:
+			return fmt.Errorf("%s: %w", localize(g.p, MsgLineWaitTimeout, line, g.Port, state, timeout), os.ErrDeadlineExceeded)
+		}
+	}
+}
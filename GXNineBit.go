@@ -0,0 +1,188 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// NineBitAddress is the 9th bit of a 9-bit word. It's set in the address words of multidrop protocols.
+const NineBitAddress uint16 = 0x100
+
+// SendNineBit sends 9-bit words. The low byte of the word is sent as data and the 9th bit as parity.
+// Words with the 9th bit set are sent with mark parity and other words with space parity.
+// The output is drained before the parity is changed and the parity is restored after the words are sent.
+func (g *GXSerial) SendNineBit(words []uint16) error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if !g.s.capabilities().MarkSpaceParity {
+		return g.unsupported("Parity", gxcommon.ParityMark)
+	}
+	if err := g.checkWritable(); err != nil {
+		return err
+	}
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	defer g.sendLock.release()
+	var err error
+	for start := 0; start < len(words) && err == nil; {
+		bit := words[start] & NineBitAddress
+		end := start
+		buf := make([]byte, 0, len(words)-start)
+		for end < len(words) && words[end]&NineBitAddress == bit {
+			buf = append(buf, byte(words[end]))
+			end++
+		}
+		parity := gxcommon.ParitySpace
+		if bit != 0 {
+			parity = gxcommon.ParityMark
+		}
+		if err = g.s.setParity(parity); err != nil {
+			break
+		}
+		if err = g.sendChunk(buf); err != nil {
+			break
+		}
+		err = g.drain()
+		start = end
+	}
+	if g.nineBitReceive {
+		//Space parity with marking is used when 9-bit receive is enabled.
+		err = errors.Join(err, g.s.setNineBitReceive(true))
+	} else {
+		err = errors.Join(err, g.s.setParity(g.parity))
+	}
+	if err != nil {
+		g.errorf(true, "send", err)
+	} else {
+		g.meter.add(0, 0, 0, 1)
+	}
+	return err
+}
+
+// SendMultidrop sends a frame of a 9-bit multidrop protocol. The address byte is sent
+// with mark parity (the 9th bit set) and the data bytes with space parity.
+func (g *GXSerial) SendMultidrop(address byte, data []byte) error {
+	words := make([]uint16, 0, len(data)+1)
+	words = append(words, NineBitAddress|uint16(address))
+	for _, it := range data {
+		words = append(words, uint16(it))
+	}
+	return g.SendNineBit(words)
+}
+
+// drain waits until the driver output queue is empty and the last byte is shifted out.
+func (g *GXSerial) drain() error {
+	if err := g.waitOutputEmpty(); err != nil {
+		return err
+	}
+	time.Sleep(g.charTime())
+	return nil
+}
+
+// NineBitReceive returns true if the 9th bit of the received bytes is reported.
+func (g *GXSerial) NineBitReceive() bool {
+	return g.nineBitReceive
+}
+
+// SetNineBitReceive enables or disables reporting of the 9th bit of the received bytes.
+// When enabled, space parity is used and the received bytes whose 9th bit is set are prefixed with 0xFF 0x00.
+// Received 0xFF bytes are doubled. Use NineBitDecoder to decode the received data to 9-bit words.
+// EOP framing should not be used at the same time. See Capabilities.NineBitReceive.
+func (g *GXSerial) SetNineBitReceive(value bool) error {
+	if value && !g.s.capabilities().NineBitReceive {
+		return g.unsupported("NineBitReceive", value)
+	}
+	if g.s.isOpen() {
+		var err error
+		if value {
+			err = g.s.setNineBitReceive(true)
+		} else if err = g.s.setNineBitReceive(false); err == nil {
+			err = g.s.setParity(g.parity)
+			if err == nil && g.onBreak != nil {
+				// Break detection uses the marks.
+				err = g.s.setBreakDetection(true)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	g.nineBitReceive = value
+	return nil
+}
+
+// NineBitDecoder decodes the data that is received when 9-bit receive is enabled to 9-bit words.
+// The decoder keeps the incomplete escape sequence between the calls.
+type NineBitDecoder struct {
+	pending []byte
+}
+
+// Decode decodes the received data to 9-bit words.
+func (d *NineBitDecoder) Decode(data []byte) []uint16 {
+	buf := append(d.pending, data...)
+	d.pending = nil
+	ret := make([]uint16, 0, len(buf))
+	for pos := 0; pos < len(buf); pos++ {
+		if buf[pos] != 0xFF {
+			ret = append(ret, uint16(buf[pos]))
+			continue
+		}
+		if pos+1 == len(buf) {
+			d.pending = append(d.pending, buf[pos:]...)
+			break
+		}
+		switch buf[pos+1] {
+		case 0xFF:
+			//Escaped 0xFF data byte.
+			ret = append(ret, 0xFF)
+			pos++
+		case 0x00:
+			if pos+2 == len(buf) {
+				d.pending = append(d.pending, buf[pos:]...)
+				return ret
+			}
+			ret = append(ret, NineBitAddress|uint16(buf[pos+2]))
+			pos += 2
+		default:
+			ret = append(ret, 0xFF)
+		}
+	}
+	return ret
+}
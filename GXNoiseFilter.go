@@ -0,0 +1,106 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "bytes"
+
+// NoiseFilter strips the idle and fill bytes that are received while no frame is in progress,
+// e.g. the runs of 0xFF or 0x00 that optical heads emit at power-up.
+// The bytes are stripped after the port is opened and, when the EOP is set, between the frames.
+// Without the EOP the frames can't be followed and the bytes are stripped only until
+// the first other byte is received after the port is opened.
+type NoiseFilter struct {
+	// Bytes are the idle and fill bytes that are stripped.
+	Bytes []byte
+}
+
+// strip returns the data without the leading fill bytes and the amount of the stripped bytes.
+func (f *NoiseFilter) strip(data []byte) ([]byte, int) {
+	pos := 0
+	for pos < len(data) && bytes.IndexByte(f.Bytes, data[pos]) != -1 {
+		pos++
+	}
+	return data[pos:], pos
+}
+
+// NoiseFilter returns the noise filter. Nil if the fill bytes are not stripped.
+func (g *GXSerial) NoiseFilter() *NoiseFilter {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.noiseFilter
+}
+
+// SetNoiseFilter sets the filter that strips the idle and fill bytes before the data is framed.
+// Stripped bytes are counted and traced with the received data. Nil disables the filter.
+func (g *GXSerial) SetNoiseFilter(value *NoiseFilter) {
+	g.mu.Lock()
+	g.noiseFilter = value
+	g.mu.Unlock()
+}
+
+// DiscardedNoise returns the amount of the fill bytes that the noise filter has stripped.
+func (g *GXSerial) DiscardedNoise() uint64 {
+	return g.discardedNoise.Load()
+}
+
+// filterNoise strips the fill bytes from the received data if no frame is in progress.
+func (g *GXSerial) filterNoise(data []byte) []byte {
+	g.mu.RLock()
+	f := g.noiseFilter
+	p := g.parser
+	eop := g.eop
+	g.mu.RUnlock()
+	if f == nil || len(f.Bytes) == 0 {
+		return data
+	}
+	idle := g.noiseIdle
+	if !idle && eop != nil {
+		if g.synchronous && !g.framedSynchronous() {
+			idle = g.received.Len() == 0
+		} else {
+			idle = p != nil && len(p.buf) == 0
+		}
+	}
+	if !idle {
+		return data
+	}
+	data, n := f.strip(data)
+	if n != 0 {
+		g.discardedNoise.Add(uint64(n))
+	}
+	if len(data) != 0 {
+		g.noiseIdle = false
+	}
+	return data
+}
@@ -0,0 +1,71 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// OpenError is returned from Open when open diagnostics are enabled and
+// a configuration step fails. It names the failed system call and the error number.
+type OpenError struct {
+	// Op is the failed system call or configuration step, e.g. "tcsetattr".
+	Op string
+	// Err is the error returned by the system call.
+	Err error
+}
+
+// Errno returns the error number of the failed system call or zero if it's not known.
+func (e *OpenError) Errno() uintptr {
+	var errno syscall.Errno
+	if errors.As(e.Err, &errno) {
+		return uintptr(errno)
+	}
+	return 0
+}
+
+// Error implements error.
+func (e *OpenError) Error() string {
+	if n := e.Errno(); n != 0 {
+		return fmt.Sprintf("%s failed: %v (errno %d)", e.Op, e.Err, n)
+	}
+	return fmt.Sprintf("%s failed: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the error of the system call.
+func (e *OpenError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,90 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// PanicError is notified through the error event when an event handler panics.
+// The panic is recovered so that the media keeps working.
+type PanicError struct {
+	// Handler is the name of the event handler, e.g. Received.
+	Handler string
+	// Value is the value that was passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+	msg   string
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return e.msg
+}
+
+// callHandler calls the event handler and recovers if it panics.
+// The handler is not called after the port is closed and Close waits until it returns.
+// The panic is notified through the error event. lock tells if the error handler is read under the lock.
+func (g *GXSerial) callHandler(lock bool, handler string, f func()) {
+	if !g.enterHandler() {
+		return
+	}
+	defer g.handlers.Done()
+	g.invokeHandler(lock, handler, f)
+}
+
+// invokeHandler calls the event handler and recovers if it panics.
+// The handler is called also when the port is closed.
+func (g *GXSerial) invokeHandler(lock bool, handler string, f func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err := &PanicError{Handler: handler, Value: r, Stack: debug.Stack(),
+			msg: localize(g.p, MsgHandlerPanic, handler, r)}
+		if handler == "Error" {
+			// The error handler is not called again.
+			g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: "panic", Err: err})
+			g.record(FlightEvent{Type: FlightEventError, Err: err})
+			g.log(slog.LevelError, "error handler panicked", "err", err, "stack", string(err.Stack))
+			return
+		}
+		g.errorf(lock, "panic", err)
+	}()
+	f()
+}
@@ -0,0 +1,133 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// ParityErrorChar returns the byte that replaces the received bytes with a parity error
+// and true if the bytes are replaced.
+func (g *GXSerial) ParityErrorChar() (byte, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.parityErrorChar, g.parityErrorCharOn
+}
+
+// SetParityErrorChar sets the byte that replaces the received bytes with a parity error,
+// so that corrupted bytes can be told apart from the valid data. Parity must be used.
+// On Windows the driver replaces the bytes (ErrorChar of DCB). On Linux and macOS the driver marks
+// the bytes (PARMRK) and the marks are replaced before the data is traced and delivered.
+// The marks are not replaced when 9-bit receive is enabled.
+// If the port is open, the change is applied immediately.
+func (g *GXSerial) SetParityErrorChar(value byte, enabled bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() {
+		if err := g.applyParityErrorChar(value, enabled); err != nil {
+			return err
+		}
+	}
+	g.parityErrorChar = value
+	g.parityErrorCharOn = enabled
+	return nil
+}
+
+// applyParityErrorChar sets the parity error character to the driver. The caller holds the lock.
+func (g *GXSerial) applyParityErrorChar(value byte, enabled bool) error {
+	if !enabled && parityMarking && g.nineBitReceive {
+		// 9-bit receive uses the marks.
+		return nil
+	}
+	if err := g.s.setParityErrorChar(enabled, value); err != nil {
+		return err
+	}
+	if !enabled && g.onBreak != nil {
+		// Break detection uses the marks.
+		return g.s.setBreakDetection(true)
+	}
+	return nil
+}
+
+// unmarkErrors removes the error marks of the driver. The bytes with a parity error are replaced
+// with the parity error character and the breaks are removed and notified.
+// A mark that is split between the reads is kept until the rest of it is received.
+func (g *GXSerial) unmarkErrors(data []byte) []byte {
+	if !parityMarking {
+		return data
+	}
+	g.mu.RLock()
+	value, replace := g.parityErrorChar, g.parityErrorCharOn && !g.nineBitReceive
+	brk := g.onBreak != nil && !g.nineBitReceive
+	g.mu.RUnlock()
+	if !replace && !brk {
+		return data
+	}
+	breaks := 0
+	defer func() {
+		for range breaks {
+			g.breakReceived()
+		}
+	}()
+	if len(g.parityPending) != 0 {
+		data = append(g.parityPending, data...)
+		g.parityPending = nil
+	}
+	ret := make([]byte, 0, len(data))
+	for pos := 0; pos < len(data); pos++ {
+		if data[pos] != 0xFF {
+			ret = append(ret, data[pos])
+			continue
+		}
+		// 0xFF is received as 0xFF 0xFF and a byte with a parity error as 0xFF 0x00 byte.
+		if pos+1 == len(data) || (data[pos+1] == 0 && pos+2 == len(data)) {
+			g.parityPending = append([]byte(nil), data[pos:]...)
+			break
+		}
+		switch data[pos+1] {
+		case 0:
+			// A break is received as 0xFF 0x00 0x00.
+			if brk && data[pos+2] == 0 {
+				breaks++
+			} else if replace {
+				ret = append(ret, value)
+			} else {
+				ret = append(ret, data[pos+2])
+			}
+			pos += 2
+		case 0xFF:
+			ret = append(ret, 0xFF)
+			pos++
+		default:
+			ret = append(ret, 0xFF)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// Parser splits a byte stream to frames that are ended by the EOP.
+// It has no goroutines and no I/O, so the framing can be tested without a serial port.
+// The EOP is a byte, string, byte slice, Matcher, func([]byte) (int, bool) predicate, EscapedEop, HdlcFrame,
+// WmbusImst, WmbusAmber, SmlFrame or Dnp3Frame.
+// A Parser is not safe for concurrent use.
+type Parser struct {
+	eop   any
+	match Matcher
+	buf   []byte
+}
+
+// NewParser creates a parser for the EOP.
+// If the EOP is nil, each fed chunk is returned as a frame.
+func NewParser(eop any) (*Parser, error) {
+	m, err := toMatcher(eop)
+	if err != nil {
+		return nil, err
+	}
+	return &Parser{eop: eop, match: m}, nil
+}
+
+// Eop returns the EOP of the parser.
+func (p *Parser) Eop() any {
+	return p.eop
+}
+
+// Feed adds the received data and returns the completed frames in the order they are received.
+// The frames are returned as they were received, including the EOP. Use Decode to get the payload.
+// Data that doesn't end a frame is kept until the next call.
+func (p *Parser) Feed(data []byte) [][]byte {
+	if p.match == nil {
+		if len(data) == 0 {
+			return nil
+		}
+		return [][]byte{append([]byte(nil), data...)}
+	}
+	p.buf = append(p.buf, data...)
+	var ret [][]byte
+	for len(p.buf) != 0 {
+		end, ok := p.match(p.buf)
+		if !ok || end <= 0 {
+			break
+		}
+		end = min(end, len(p.buf))
+		ret = append(ret, p.buf[:end:end])
+		p.buf = append([]byte(nil), p.buf[end:]...)
+	}
+	return ret
+}
+
+// Decode returns the payload of the frame if the EOP transforms it, e.g. EscapedEop.
+// Other frames are returned as is.
+func (p *Parser) Decode(frame []byte) []byte {
+	return decode(p.eop, frame)
+}
+
+// Pending returns a copy of the data that doesn't end a frame yet.
+func (p *Parser) Pending() []byte {
+	return append([]byte(nil), p.buf...)
+}
+
+// Reset discards the data that doesn't end a frame.
+func (p *Parser) Reset() {
+	p.buf = nil
+}
@@ -0,0 +1,123 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"testing"
+)
+
+// fuzzEops are the EOPs whose framing is fuzzed.
+var fuzzEops = []struct {
+	name string
+	eop  any
+}{
+	{"byte", byte('\n')},
+	{"string", "OK"},
+	{"Matcher", Matcher(func(data []byte) (int, bool) {
+		// The frame ends at the first zero byte.
+		pos := bytes.IndexByte(data, 0)
+		return pos + 1, pos != -1
+	})},
+	{"EscapedEop", EscapedEop{Eop: 0x7E, Escape: 0x7D, Xor: 0x20, Unstuff: true}},
+	{"HdlcFrame", HdlcFrame{}},
+	{"WmbusImst", WmbusImst{Unwrap: true}},
+	{"WmbusAmber", WmbusAmber{Unwrap: true}},
+	{"SmlFrame", SmlFrame{Unwrap: true}},
+	{"Dnp3Frame", Dnp3Frame{StripCrc: true}},
+}
+
+// parseChunks feeds the chunks to a new parser and returns the decoded frames and the pending data.
+func parseChunks(t *testing.T, eop any, chunks [][]byte) ([][]byte, []byte) {
+	p, err := NewParser(eop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames [][]byte
+	for _, it := range chunks {
+		for _, frame := range p.Feed(it) {
+			if fc, ok := eop.(frameChecker); ok {
+				fc.check(frame)
+			}
+			frames = append(frames, p.Decode(frame))
+		}
+	}
+	return frames, p.Pending()
+}
+
+// splitData splits the data to chunks. Each byte of sizes is the size of the next chunk.
+func splitData(data []byte, sizes []byte) [][]byte {
+	var ret [][]byte
+	for _, it := range sizes {
+		if len(data) == 0 {
+			break
+		}
+		n := min(int(it), len(data))
+		ret = append(ret, data[:n])
+		data = data[n:]
+	}
+	return append(ret, data)
+}
+
+// FuzzParser checks that the framing doesn't panic and that the frames don't depend on
+// how the data is split between the reads.
+func FuzzParser(f *testing.F) {
+	f.Add([]byte("AB\nCD\nE"), []byte{1, 3})
+	f.Add([]byte("xOKyyOKO"), []byte{2, 0, 1})
+	f.Add([]byte{0x7D, 0x5E, 0x01, 0x7E, 0x02, 0x7D}, []byte{1, 1, 1})
+	f.Add([]byte{0x7E, 0xA0, 0x07, 0x03, 0x21, 0x93, 0x0F, 0x01, 0x7E, 0x7E, 0xA0}, []byte{4, 5})
+	f.Add([]byte{0x7E, 0xA0, 0x05, 0x03, 0x21, 0x93, 0x7E, 0xA0, 0x05, 0x03, 0x21, 0x73, 0x7E}, []byte{7, 1})
+	f.Add([]byte{0xA5, 0x83, 0x03, 0x02, 0x01, 0x02, 0xAA, 0xBB, 0xCC}, []byte{3})
+	f.Add([]byte{0xFF, 0x03, 0x02, 0x01, 0x02, 0xFF}, []byte{2})
+	f.Add([]byte{0x1B, 0x1B, 0x1B, 0x1B, 0x01, 0x01, 0x01, 0x01, 0x76, 0x05, 0x00, 0x00,
+		0x1B, 0x1B, 0x1B, 0x1B, 0x1A, 0x00, 0x00, 0x00}, []byte{5, 9})
+	f.Add([]byte{0x05, 0x64, 0x05, 0xC0, 0x01, 0x00, 0x00, 0x04, 0xE9, 0x21}, []byte{2, 2})
+	f.Fuzz(func(t *testing.T, data []byte, sizes []byte) {
+		for _, it := range fuzzEops {
+			whole, wholePending := parseChunks(t, it.eop, [][]byte{data})
+			split, splitPending := parseChunks(t, it.eop, splitData(data, sizes))
+			if len(whole) != len(split) {
+				t.Fatalf("%s: %d frames when the data is not split, %d when split", it.name, len(whole), len(split))
+			}
+			for i := range whole {
+				if !bytes.Equal(whole[i], split[i]) {
+					t.Fatalf("%s: frame %d is % X when the data is not split, % X when split", it.name, i, whole[i], split[i])
+				}
+			}
+			if !bytes.Equal(wholePending, splitPending) {
+				t.Fatalf("%s: pending data is % X when the data is not split, % X when split", it.name, wholePending, splitPending)
+			}
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// Patterns is an EOP that ends the frame at the first of several expected patterns,
+// so a single Receive can resolve branching replies, e.g. "OK", "ERROR" or ">".
+// Use it as the EOP of Receive or SetEop. After Receive, Matched tells which pattern ended the reply.
+//
+// Example:
+//
+//	p, _ := gxserial.NewPatterns("OK\r\n", "ERROR\r\n", ">")
+//	r := gxcommon.NewReceiveParameters[string]()
+//	r.EOP = p
+//	if ok, err := media.Receive(r); ok && err == nil && p.Matched() == 1 {
+//	    // The device replied ERROR.
+//	}
+type Patterns struct {
+	items   [][]byte
+	matched atomic.Int32
+}
+
+// NewPatterns returns an EOP that ends the frame at any of the patterns.
+// A pattern can be a byte, a string or a byte slice. Empty patterns are not allowed.
+func NewPatterns(patterns ...any) (*Patterns, error) {
+	if len(patterns) == 0 {
+		return nil, gxcommon.ErrInvalidArgument
+	}
+	ret := &Patterns{}
+	for _, it := range patterns {
+		tmp, err := gxcommon.ToBytes(it, binary.BigEndian)
+		if err != nil {
+			return nil, err
+		}
+		if len(tmp) == 0 {
+			return nil, gxcommon.ErrInvalidArgument
+		}
+		ret.items = append(ret.items, append([]byte(nil), tmp...))
+	}
+	ret.matched.Store(-1)
+	return ret, nil
+}
+
+// End returns the length of the first frame in data.
+// The frame ends at the pattern that ends first. If several patterns end at the same byte,
+// the first of them in the order of NewPatterns is used.
+func (p *Patterns) End(data []byte) (int, bool) {
+	_, end := p.find(data)
+	return end, end != 0
+}
+
+// find returns the index of the pattern that ends first in data and the end of it.
+// Zero end is returned if no pattern is found.
+func (p *Patterns) find(data []byte) (int, int) {
+	index, end := -1, 0
+	for i, it := range p.items {
+		// Only the data before the current end can hold a pattern that ends earlier.
+		limit := len(data)
+		if end != 0 {
+			limit = end - 1
+		}
+		if pos := bytes.Index(data[:limit], it); pos != -1 {
+			index, end = i, pos+len(it)
+		}
+	}
+	return index, end
+}
+
+// Index returns the index of the pattern that ends the frame. -1 is returned if the frame
+// doesn't end with any of the patterns.
+func (p *Patterns) Index(frame []byte) int {
+	for i, it := range p.items {
+		if bytes.HasSuffix(frame, it) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Matched returns the index of the pattern that ended the reply of the last Receive that used
+// the patterns as the EOP. -1 is returned if the last Receive didn't get a reply.
+func (p *Patterns) Matched() int {
+	return int(p.matched.Load())
+}
@@ -0,0 +1,217 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// errModemWaitUnsupported is returned by the port when the driver can't wait for the modem status changes.
+// The modem status lines are polled instead.
+var errModemWaitUnsupported = errors.New("modem status wait not supported")
+
+// PinChangedEventArgs describes the changed modem status line.
+type PinChangedEventArgs struct {
+	// Line is the changed line.
+	Line ModemLine
+	// State is the new state of the line.
+	State bool
+	// Status is the state of all modem status lines after the change.
+	Status ModemStatus
+	// Time is the time when the change was detected.
+	Time time.Time
+}
+
+// PinChangedEventHandler is called when a modem status line changes state.
+type PinChangedEventHandler func(m gxcommon.IGXMedia, e PinChangedEventArgs)
+
+// SetOnPinChanged sets the handler that is called when CTS, DSR, DCD or RI changes state.
+// The handler is called once for each changed line.
+// The changes are waited with TIOCMIWAIT on Linux and with WaitCommEvent on Windows.
+// If the driver doesn't support the wait, e.g. on macOS, the lines are polled.
+// Short pulses that end before the lines are read may be missed.
+func (g *GXSerial) SetOnPinChanged(value PinChangedEventHandler) {
+	g.mu.Lock()
+	g.onPinChanged = value
+	g.startPinWatch()
+	g.mu.Unlock()
+}
+
+// WaitForLineChange waits until one of the selected modem status lines changes state and returns the change.
+// It's the synchronous counterpart of SetOnPinChanged, e.g. for scripted device bring-up.
+// If no line changes before the timeout, an error that wraps os.ErrDeadlineExceeded is returned.
+// Zero timeout waits until a line changes or the port is closed.
+func (g *GXSerial) WaitForLineChange(mask ModemLineMask, timeout time.Duration) (PinChangedEventArgs, error) {
+	if mask == 0 || mask&^ModemLineMaskAll != 0 || timeout < 0 {
+		return PinChangedEventArgs{}, gxcommon.ErrInvalidArgument
+	}
+	ch, stop, err := g.addPinWaiter(mask)
+	if err != nil {
+		return PinChangedEventArgs{}, err
+	}
+	defer g.removePinWaiter(ch)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case e := <-ch:
+		return e, nil
+	case <-stop:
+		return PinChangedEventArgs{}, errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	case <-expired:
+		return PinChangedEventArgs{}, fmt.Errorf("%s: %w", localize(g.p, MsgLineChangeTimeout, mask, g.Port, timeout), os.ErrDeadlineExceeded)
+	}
+}
+
+// addPinWaiter starts receiving the changes of the selected lines to the returned channel.
+// The channel receives one change at a time. The stop channel is closed when the port is closed.
+func (g *GXSerial) addPinWaiter(mask ModemLineMask) (chan PinChangedEventArgs, chan struct{}, error) {
+	ch := make(chan PinChangedEventArgs, 1)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.s.isOpen() || g.closing.Load() {
+		return nil, nil, errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if g.pinWaiters == nil {
+		g.pinWaiters = make(map[chan PinChangedEventArgs]ModemLineMask)
+	}
+	g.pinWaiters[ch] = mask
+	g.startPinWatch()
+	return ch, g.stop, nil
+}
+
+// removePinWaiter stops receiving the changes to the channel.
+func (g *GXSerial) removePinWaiter(ch chan PinChangedEventArgs) {
+	g.mu.Lock()
+	delete(g.pinWaiters, ch)
+	g.mu.Unlock()
+}
+
+// startPinWatch starts watching the modem status lines if it's needed. The caller holds the lock.
+// The watcher of a closed session may still wait for the driver, so a new watcher is started for each session.
+func (g *GXSerial) startPinWatch() {
+	if (g.onPinChanged == nil && len(g.pinWaiters) == 0) || !g.s.isOpen() || g.pinStop == g.stop {
+		return
+	}
+	g.pinStop = g.stop
+	go g.watchPins(g.stop)
+}
+
+// watching returns false if the watcher of the session must stop. The watcher is released when false is returned.
+func (g *GXSerial) watching(stop chan struct{}) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+	if (g.onPinChanged == nil && len(g.pinWaiters) == 0) || g.closing.Load() {
+		if g.pinStop == stop {
+			g.pinStop = nil
+		}
+		return false
+	}
+	return true
+}
+
+// pinChanged notifies the change to the handler and to the waiters of the line.
+func (g *GXSerial) pinChanged(e PinChangedEventArgs) {
+	g.mu.RLock()
+	cb := g.onPinChanged
+	for ch, mask := range g.pinWaiters {
+		if mask&e.Line.mask() != 0 {
+			select {
+			case ch <- e:
+			default:
+				// The waiter has already got a change.
+			}
+		}
+	}
+	g.mu.RUnlock()
+	if cb != nil {
+		g.callHandler(true, "PinChanged", func() {
+			cb(g, e)
+		})
+	}
+}
+
+// watchPins notifies the changes of the modem status lines until the port is closed or the changes are not needed.
+// The watcher is not waited when the port is closed, because the wait of the driver can't be cancelled on all systems.
+func (g *GXSerial) watchPins(stop chan struct{}) {
+	prev, err := g.s.getModemStatus()
+	if err != nil {
+		return
+	}
+	poll := false
+	for {
+		if !g.watching(stop) {
+			return
+		}
+		if !poll {
+			if err = g.s.waitModemChange(); errors.Is(err, errModemWaitUnsupported) {
+				poll = true
+			} else if err != nil {
+				// E.g. a concurrent wait of the comm events. A closed port is noticed when the lines are read.
+				time.Sleep(linePollInterval)
+			}
+		}
+		if poll {
+			time.Sleep(linePollInterval)
+		}
+		if !g.watching(stop) {
+			return
+		}
+		status, err := g.s.getModemStatus()
+		if err != nil {
+			// The port is closed. The next session starts a new watcher.
+			return
+		}
+		now := time.Now()
+		for line := ModemLineCts; line <= ModemLineRi; line++ {
+			if state := line.state(status); state != line.state(prev) {
+				g.pinChanged(PinChangedEventArgs{Line: line, State: state, Status: status, Time: now})
+			}
+		}
+		prev = status
+	}
+}
@@ -0,0 +1,105 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PortInfo describes an available serial port.
+type PortInfo struct {
+	// Name is the port name that is used with NewGXSerial, e.g. COM3 or /dev/ttyUSB0.
+	Name string `json:"name"`
+	// Description is the product name or the friendly name of the device. Empty if it's unknown.
+	Description string `json:"description,omitempty"`
+	// Manufacturer is the manufacturer of the device. Empty if it's unknown.
+	Manufacturer string `json:"manufacturer,omitempty"`
+	// SerialNumber is the USB serial number of the device. Empty if it's unknown.
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// VID is the USB vendor ID. Zero if the device is not a USB device.
+	VID uint16 `json:"vid,omitempty"`
+	// PID is the USB product ID. Zero if the device is not a USB device.
+	PID uint16 `json:"pid,omitempty"`
+	// Busy is true if another process has the port open.
+	// macOS doesn't tell it and the value is always false.
+	Busy bool `json:"busy"`
+	// Virtual is true if the port is a virtual port, e.g. a com0com port. Only Windows tells it.
+	Virtual bool `json:"virtual,omitempty"`
+	// Bus is the bus name of a com0com port, e.g. CNCA0. Empty for the other ports.
+	Bus string `json:"bus,omitempty"`
+}
+
+// IsUSB returns true if the port is a USB device.
+func (p PortInfo) IsUSB() bool {
+	return p.VID != 0
+}
+
+// GetPortsInfo returns the available serial ports with the device metadata.
+// The ports are returned in the same order as GetPortNames returns them.
+func GetPortsInfo() ([]PortInfo, error) {
+	return getPortsInfo()
+}
+
+// FindVirtualPair returns the port names of an installed com0com port pair.
+// The bus is the number of the pair or the bus name of either port, e.g. 0, CNCA0 or CNCB0.
+// If the bus is empty, the pair with the lowest number is returned.
+func FindVirtualPair(bus string) (string, string, error) {
+	ports, err := GetPortsInfo()
+	if err != nil {
+		return "", "", err
+	}
+	names := map[string]string{}
+	for _, it := range ports {
+		if it.Virtual && it.Bus != "" {
+			names[it.Bus] = it.Name
+		}
+	}
+	bus = strings.ToUpper(bus)
+	for _, id := range slices.Sorted(maps.Keys(names)) {
+		num, ok := strings.CutPrefix(id, "CNCA")
+		if !ok || (bus != "" && bus != num && bus != "CNCA"+num && bus != "CNCB"+num) {
+			continue
+		}
+		if b, ok := names["CNCB"+num]; ok {
+			return names[id], b, nil
+		}
+	}
+	if bus == "" {
+		return "", "", errors.New(localizeDefault(MsgNoPortPair))
+	}
+	return "", "", errors.New(localizeDefault(MsgPortPairNotFound, bus))
+}
@@ -0,0 +1,90 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModemStatus contains the states of the modem status lines.
+type ModemStatus struct {
+	// Cts is the state of the Clear To Send line.
+	Cts bool
+	// Dsr is the state of the Data Set Ready line.
+	Dsr bool
+	// Dcd is the state of the Data Carrier Detect line.
+	Dcd bool
+	// Ri is the state of the Ring Indicator line.
+	Ri bool
+}
+
+// PortStateItem is a single named value of the port state.
+type PortStateItem struct {
+	Name  string
+	Value string
+}
+
+// PortState is a diagnostic snapshot of the effective serial port state.
+type PortState struct {
+	// Settings are the platform-specific settings, e.g. termios or DCB flags, in the order they are read.
+	Settings []PortStateItem
+	// Rts is the state of the RTS line.
+	Rts bool
+	// Dtr is the state of the DTR line.
+	Dtr bool
+	// Modem contains the states of the modem status lines.
+	Modem ModemStatus
+	// BytesToRead is the amount of bytes in the driver input queue.
+	BytesToRead int
+	// BytesToWrite is the amount of bytes in the driver output queue.
+	BytesToWrite int
+}
+
+// add adds a setting to the state.
+func (s *PortState) add(name string, format string, a ...any) {
+	s.Settings = append(s.Settings, PortStateItem{Name: name, Value: fmt.Sprintf(format, a...)})
+}
+
+// String returns the state in human-readable form.
+func (s *PortState) String() string {
+	var b strings.Builder
+	for _, it := range s.Settings {
+		fmt.Fprintf(&b, "%s: %s\n", it.Name, it.Value)
+	}
+	fmt.Fprintf(&b, "RTS: %t DTR: %t CTS: %t DSR: %t DCD: %t RI: %t\n",
+		s.Rts, s.Dtr, s.Modem.Cts, s.Modem.Dsr, s.Modem.Dcd, s.Modem.Ri)
+	fmt.Fprintf(&b, "Input queue: %d Output queue: %d", s.BytesToRead, s.BytesToWrite)
+	return b.String()
+}
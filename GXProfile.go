@@ -0,0 +1,184 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// ProfileStore keeps named serial port configurations in a file.
+// A profile holds the settings of GetSettings, e.g. port, baud rate, EOP, trace level and timeouts,
+// so that applications can refer to a configuration by its name.
+type ProfileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// profileFile is the content of the profile file.
+type profileFile struct {
+	XMLName  xml.Name  `xml:"Profiles"`
+	Profiles []profile `xml:"Profile"`
+}
+
+// profile is a named configuration.
+type profile struct {
+	Name     string `xml:"Name,attr"`
+	Settings string `xml:",innerxml"`
+}
+
+// NewProfileStore creates a profile store that is backed by the given file.
+// The file is created when the first profile is saved.
+func NewProfileStore(path string) *ProfileStore {
+	return &ProfileStore{path: path}
+}
+
+// Path returns the file of the profiles.
+func (s *ProfileStore) Path() string {
+	return s.path
+}
+
+// read reads the profiles from the file. The file doesn't need to exist.
+func (s *ProfileStore) read() (*profileFile, error) {
+	ret := &profileFile{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = xml.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// write replaces the profile file.
+func (s *ProfileStore) write(f *profileFile) error {
+	data, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, append([]byte(xml.Header), data...), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// find returns the index of the profile. -1 if the profile doesn't exist.
+func (f *profileFile) find(name string) int {
+	for pos, it := range f.Profiles {
+		if it.Name == name {
+			return pos
+		}
+	}
+	return -1
+}
+
+// Save saves the configuration of the media with the given name.
+// An existing profile with the same name is replaced.
+func (s *ProfileStore) Save(name string, media *GXSerial) error {
+	if name == "" || media == nil {
+		return gxcommon.ErrInvalidArgument
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	p := profile{Name: name, Settings: "\n" + media.GetSettings()}
+	if pos := f.find(name); pos != -1 {
+		f.Profiles[pos] = p
+	} else {
+		f.Profiles = append(f.Profiles, p)
+	}
+	return s.write(f)
+}
+
+// Load configures the media with the named profile.
+func (s *ProfileStore) Load(name string, media *GXSerial) error {
+	if media == nil {
+		return gxcommon.ErrInvalidArgument
+	}
+	s.mu.Lock()
+	f, err := s.read()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	pos := f.find(name)
+	if pos == -1 {
+		return errors.New(localize(media.p, MsgProfileNotFound, name, s.path))
+	}
+	return media.SetSettings(f.Profiles[pos].Settings)
+}
+
+// List returns the names of the saved profiles.
+func (s *ProfileStore) List() ([]string, error) {
+	s.mu.Lock()
+	f, err := s.read()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(f.Profiles))
+	for _, it := range f.Profiles {
+		ret = append(ret, it.Name)
+	}
+	return ret, nil
+}
+
+// Delete removes the named profile. Removing a profile that doesn't exist is not an error.
+func (s *ProfileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	pos := f.find(name)
+	if pos == -1 {
+		return nil
+	}
+	f.Profiles = append(f.Profiles[:pos], f.Profiles[pos+1:]...)
+	return s.write(f)
+}
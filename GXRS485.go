@@ -0,0 +1,104 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// RS485 is the native RS-485 mode of the serial port driver.
+// The driver switches the transceiver direction with RTS in hardware, which is
+// more accurate than switching it from the application. See Capabilities.RS485.
+type RS485 struct {
+	// Enabled enables the RS-485 mode. False disables it in the driver.
+	Enabled bool
+	// RtsOnSend is the logical level of RTS while the data is sent.
+	RtsOnSend bool
+	// RtsAfterSend is the logical level of RTS after the data is sent.
+	RtsAfterSend bool
+	// DelayBeforeSend is the delay after RTS is set before the data is sent.
+	// The driver uses millisecond resolution.
+	DelayBeforeSend time.Duration
+	// DelayAfterSend is the delay after the data is sent before RTS is set back.
+	// The driver uses millisecond resolution.
+	DelayAfterSend time.Duration
+	// RxDuringTx keeps the receiver enabled while the data is sent.
+	// The sent data is received back on the transceivers that echo the bus.
+	RxDuringTx bool
+}
+
+// RS485 returns the native RS-485 configuration. Nil if the driver configuration is not changed.
+func (g *GXSerial) RS485() *RS485 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.rs485
+}
+
+// SetRS485 sets the native RS-485 configuration that is applied when the port is opened.
+// If the port is open, the configuration is applied immediately.
+// Nil doesn't change the configuration of the driver.
+// *UnsupportedSettingError is returned if the driver doesn't support the RS-485 mode.
+// The mode can't be enabled with the RTS toggle mode.
+func (g *GXSerial) SetRS485(value *RS485) error {
+	if value != nil && (value.DelayBeforeSend < 0 || value.DelayAfterSend < 0) {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if value != nil && value.Enabled && g.rtsToggle != nil {
+		return errors.New(localize(g.p, MsgRtsToggleConflict, g.Port))
+	}
+	if value != nil && g.s.isOpen() {
+		if err := g.applyRS485(value); err != nil {
+			return err
+		}
+	}
+	if value != nil {
+		v := *value
+		value = &v
+	}
+	g.rs485 = value
+	return nil
+}
+
+// applyRS485 sets the RS-485 configuration to the driver. The caller holds the lock.
+func (g *GXSerial) applyRS485(value *RS485) error {
+	if !g.s.capabilities().RS485 {
+		return g.unsupported("RS485", value.Enabled)
+	}
+	return g.s.setRS485(value)
+}
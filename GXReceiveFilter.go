@@ -0,0 +1,104 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "github.com/Gurux/gxcommon-go"
+
+// ReceiveVerdict tells how a received frame is handled after the receive filter.
+type ReceiveVerdict int
+
+const (
+	// ReceiveVerdictPass passes the frame on: in synchronous mode to the synchronous buffer, otherwise to the received event.
+	ReceiveVerdictPass ReceiveVerdict = iota
+	// ReceiveVerdictConsumed tells that the filter handled the frame. It's not delivered further.
+	ReceiveVerdictConsumed
+	// ReceiveVerdictDrop discards the frame. It's counted in DiscardedFrames.
+	ReceiveVerdictDrop
+)
+
+// String returns the name of the verdict.
+func (v ReceiveVerdict) String() string {
+	switch v {
+	case ReceiveVerdictPass:
+		return "Pass"
+	case ReceiveVerdictConsumed:
+		return "Consumed"
+	case ReceiveVerdictDrop:
+		return "Drop"
+	}
+	return "Unknown"
+}
+
+// ReceiveFilterHandler is called with each received frame before it's delivered.
+// The returned verdict tells how the frame is handled.
+type ReceiveFilterHandler func(m gxcommon.IGXMedia, e gxcommon.ReceiveEventArgs) ReceiveVerdict
+
+// ReceiveFilter returns the receive filter. Nil if it's not set.
+func (g *GXSerial) ReceiveFilter() ReceiveFilterHandler {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.onReceiveFilter
+}
+
+// SetOnReceiveFilter sets the handler that sees each received frame first, also in synchronous mode.
+// This allows a background parser to consume unsolicited frames while synchronous
+// request/response code still receives its replies. Frames are ended with the EOP;
+// if the EOP is not set, the received chunks are filtered as they are. Nil removes the filter.
+func (g *GXSerial) SetOnReceiveFilter(value ReceiveFilterHandler) {
+	g.mu.Lock()
+	g.onReceiveFilter = value
+	g.mu.Unlock()
+}
+
+// framedSynchronous returns true if the received data is framed also in synchronous mode.
+// The data is framed only when the receive filter or the address filter needs the frames.
+// Otherwise it's buffered as it's received so that Receive can read it by Count.
+func (g *GXSerial) framedSynchronous() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.onReceiveFilter != nil || g.parser != nil && g.addressFilter != nil
+}
+
+// filterFrame returns the verdict of the receive filter for the frame.
+func (g *GXSerial) filterFrame(frame []byte) ReceiveVerdict {
+	cb := g.ReceiveFilter()
+	if cb == nil {
+		return ReceiveVerdictPass
+	}
+	verdict := ReceiveVerdictPass
+	g.callHandler(true, "ReceiveFilter", func() {
+		verdict = cb(g, *gxcommon.NewReceiveEventArgs(frame, g.Port))
+	})
+	return verdict
+}
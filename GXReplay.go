@@ -0,0 +1,545 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// ReplayRecord is a single recorded transfer.
+type ReplayRecord struct {
+	// Sent is true for transmitted data and false for received data.
+	Sent bool
+	// Data is the transferred data.
+	Data []byte
+}
+
+// ParseReplayLog parses a hex log where each line contains a "TX:" or "RX:"
+// marker followed by hex bytes, e.g. "RX: 7E A0 07 03". Text before the
+// marker, e.g. a timestamp, is ignored. Empty lines and lines starting with
+// # are skipped. This is the format of GXSerial TX and RX traces.
+func ParseReplayLog(r io.Reader) ([]ReplayRecord, error) {
+	var ret []ReplayRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		str := strings.TrimSpace(scanner.Text())
+		if str == "" || strings.HasPrefix(str, "#") {
+			continue
+		}
+		var rec ReplayRecord
+		pos := strings.Index(str, "TX:")
+		if pos != -1 {
+			rec.Sent = true
+		} else if pos = strings.Index(str, "RX:"); pos == -1 {
+			continue
+		}
+		data, err := hex.DecodeString(strings.Join(strings.Fields(str[pos+3:]), ""))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", localizeDefault(MsgInvalidReplayLog, line), err)
+		}
+		rec.Data = data
+		ret = append(ret, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GXReplay is a media that replays previously recorded received data in
+// response to sent data. It's used in regression tests and demos without
+// hardware.
+//
+// Received records that precede the first sent record are delivered when
+// the media is opened. After that, each Send delivers the received records
+// that follow the next sent record.
+type GXReplay struct {
+	// Name is the name of the replayed media.
+	Name    string
+	records []ReplayRecord
+	index   int
+	strict  bool
+	eop     any
+	// Splits the asynchronously received data to frames. Nil if the EOP is not set.
+	parser *Parser
+	// The trace level specifies which types of trace messages are emitted.
+	traceLevel gxcommon.TraceLevel
+
+	mu          sync.RWMutex
+	wg          sync.WaitGroup
+	open        bool
+	synchronous bool
+	queue       chan []byte
+
+	bytesSent     uint64
+	bytesReceived uint64
+
+	//Called when the Media state is changed.
+	onState gxcommon.MediaStateHandler
+	//Called when the new data is received.
+	onReceive gxcommon.ReceivedEventHandler
+	//Called when the Media is sending or receiving data.
+	onTrace gxcommon.TraceEventHandler
+	//Called when the Media is sending or receiving data.
+	onErr gxcommon.ErrorEventHandler
+
+	received synchronousMediaBase
+	faults   FaultInjector
+	link     LinkModel
+	rnd      *rand.Rand
+	// Time when the last sent data has left the simulated wire.
+	txDone time.Time
+	// Printer for localized messages.
+	p *message.Printer
+}
+
+// NewGXReplay creates a replay media for the given records.
+func NewGXReplay(name string, records []ReplayRecord) *GXReplay {
+	g := &GXReplay{Name: name, records: records}
+	g.Localize(DefaultLanguage())
+	g.received = *newGXSynchronousMediaBase()
+	return g
+}
+
+// NewGXReplayFromFile creates a replay media from the given hex log file.
+func NewGXReplayFromFile(name string) (*GXReplay, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := ParseReplayLog(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewGXReplay(name, records), nil
+}
+
+// Strict returns true if sent data must match the recorded data.
+func (g *GXReplay) Strict() bool {
+	return g.strict
+}
+
+// SetStrict sets if sent data must match the recorded data.
+// When strict, Send returns an error on mismatch.
+func (g *GXReplay) SetStrict(value bool) {
+	g.strict = value
+}
+
+// Faults returns the fault injector of the media.
+func (g *GXReplay) Faults() *FaultInjector {
+	return &g.faults
+}
+
+// LinkModel returns the simulated link characteristics.
+func (g *GXReplay) LinkModel() LinkModel {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.link
+}
+
+// SetLinkModel sets the simulated link characteristics. Received data is
+// delivered after the sent data and the reply have been transmitted with
+// the simulated baud rate, plus latency and jitter.
+func (g *GXReplay) SetLinkModel(value LinkModel) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.link = value
+	g.rnd = value.newRand()
+}
+
+// Done returns true when all records are replayed.
+func (g *GXReplay) Done() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.index >= len(g.records)
+}
+
+// Localize messages for the specified language.
+// No errors is returned if language is not supported.
+func (g *GXReplay) Localize(language language.Tag) {
+	g.p = newPrinter(language)
+}
+
+// String implements IGXMedia
+func (g *GXReplay) String() string {
+	return fmt.Sprintf("%s %d records", g.Name, len(g.records))
+}
+
+// GetName implements IGXMedia
+func (g *GXReplay) GetName() string {
+	return g.Name
+}
+
+// IsOpen implements IGXMedia
+func (g *GXReplay) IsOpen() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.open
+}
+
+// Copy implements IGXMedia
+func (g *GXReplay) Copy(target gxcommon.IGXMedia) error {
+	switch dst := target.(type) {
+	case *GXReplay:
+		dst.Name = g.Name
+		dst.records = g.records
+		dst.strict = g.strict
+		dst.SetEop(g.eop)
+		dst.traceLevel = g.traceLevel
+	default:
+		return fmt.Errorf("copy: target is %T; want *GXReplay", target)
+	}
+	return nil
+}
+
+// GetMediaType implements IGXMedia
+func (g *GXReplay) GetMediaType() string {
+	return "Replay"
+}
+
+// GetSettings implements IGXMedia
+func (g *GXReplay) GetSettings() string {
+	if g.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf("<Name>%s</Name>\n", xmlEscape(g.Name))
+}
+
+// SetSettings implements IGXMedia
+func (g *GXReplay) SetSettings(value string) error {
+	return nil
+}
+
+// GetSynchronous implements IGXMedia
+func (g *GXReplay) GetSynchronous() func() {
+	g.mu.Lock()
+	g.synchronous = true
+	g.mu.Unlock()
+	return func() {
+		g.mu.Lock()
+		g.synchronous = false
+		g.mu.Unlock()
+	}
+}
+
+// IsSynchronous implements IGXMedia
+func (g *GXReplay) IsSynchronous() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.synchronous
+}
+
+// ResetSynchronousBuffer implements IGXMedia
+func (g *GXReplay) ResetSynchronousBuffer() {
+	g.received.Get(-1)
+}
+
+// GetBytesSent implements IGXMedia
+func (g *GXReplay) GetBytesSent() uint64 {
+	return g.bytesSent
+}
+
+// GetBytesReceived implements IGXMedia
+func (g *GXReplay) GetBytesReceived() uint64 {
+	return g.bytesReceived
+}
+
+// ResetByteCounters implements IGXMedia
+func (g *GXReplay) ResetByteCounters() {
+	g.bytesSent = 0
+	g.bytesReceived = 0
+}
+
+// Validate implements IGXMedia
+func (g *GXReplay) Validate() error {
+	if len(g.records) == 0 {
+		return errors.New(localize(g.p, MsgNoReplayRecords))
+	}
+	return nil
+}
+
+// SetEop implements IGXMedia
+// In asynchronous mode the received data is delivered in frames that are ended by the EOP.
+func (g *GXReplay) SetEop(eop any) {
+	p, err := newFrameParser(eop, nil)
+	if err != nil {
+		g.tracef(gxcommon.TraceTypesError, "EOP: %v", err)
+	}
+	g.mu.Lock()
+	g.eop = eop
+	g.parser = p
+	g.mu.Unlock()
+}
+
+// GetEop implements IGXMedia
+func (g *GXReplay) GetEop() any {
+	return g.eop
+}
+
+// GetTrace implements IGXMedia
+func (g *GXReplay) GetTrace() gxcommon.TraceLevel {
+	return g.traceLevel
+}
+
+// SetTrace implements IGXMedia
+func (g *GXReplay) SetTrace(traceLevel gxcommon.TraceLevel) error {
+	g.traceLevel = traceLevel
+	return nil
+}
+
+// SetOnReceived implements IGXMedia
+func (g *GXReplay) SetOnReceived(value gxcommon.ReceivedEventHandler) {
+	g.mu.Lock()
+	g.onReceive = value
+	g.mu.Unlock()
+}
+
+// SetOnError implements IGXMedia
+func (g *GXReplay) SetOnError(value gxcommon.ErrorEventHandler) {
+	g.mu.Lock()
+	g.onErr = value
+	g.mu.Unlock()
+}
+
+// SetOnMediaStateChange implements IGXMedia
+func (g *GXReplay) SetOnMediaStateChange(value gxcommon.MediaStateHandler) {
+	g.mu.Lock()
+	g.onState = value
+	g.mu.Unlock()
+}
+
+// SetOnTrace implements IGXMedia
+func (g *GXReplay) SetOnTrace(value gxcommon.TraceEventHandler) {
+	g.mu.Lock()
+	g.onTrace = value
+	g.mu.Unlock()
+}
+
+// Open implements IGXMedia
+func (g *GXReplay) Open() error {
+	g.mu.Lock()
+	if g.open {
+		g.mu.Unlock()
+		return nil
+	}
+	g.open = true
+	g.index = 0
+	g.queue = make(chan []byte, len(g.records)+1)
+	g.mu.Unlock()
+	g.statef(gxcommon.MediaStateOpening)
+	g.wg.Add(1)
+	go g.deliver(g.queue)
+	g.statef(gxcommon.MediaStateOpen)
+	g.mu.Lock()
+	if g.open {
+		g.replyLocked()
+	}
+	g.mu.Unlock()
+	return nil
+}
+
+// Send implements IGXMedia
+func (g *GXReplay) Send(data any, receiver string) error {
+	tmp, err := gxcommon.ToBytes(data, binary.BigEndian)
+	if err != nil {
+		return err
+	}
+	if !g.IsOpen() {
+		return errors.New(localize(g.p, MsgMediaNotOpen, g.Name))
+	}
+	if err := g.faults.writeError(); err != nil {
+		return err
+	}
+	g.bytesSent += uint64(len(tmp))
+	g.tracef(gxcommon.TraceTypesSent, "TX: % X", tmp)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// Close may have closed the queue after the open check.
+	if !g.open {
+		return errors.New(localize(g.p, MsgMediaNotOpen, g.Name))
+	}
+	g.txDone = time.Now().Add(g.link.transmissionTime(len(tmp)))
+	if g.index >= len(g.records) {
+		if g.strict {
+			return errors.New(localize(g.p, MsgReplayEnded, g.Name))
+		}
+		return nil
+	}
+	expected := g.records[g.index]
+	g.index++
+	if g.strict && !bytes.Equal(expected.Data, tmp) {
+		return errors.New(localize(g.p, MsgReplayMismatch, tmp, expected.Data))
+	}
+	g.replyLocked()
+	return nil
+}
+
+// replyLocked queues the received records that follow the current position.
+func (g *GXReplay) replyLocked() {
+	for g.index < len(g.records) && !g.records[g.index].Sent {
+		g.queue <- g.records[g.index].Data
+		g.index++
+	}
+}
+
+// deliver delivers the queued received data in order.
+func (g *GXReplay) deliver(queue chan []byte) {
+	defer g.wg.Done()
+	for data := range queue {
+		chunks, delay := g.faults.apply(data)
+		g.mu.Lock()
+		if g.rnd != nil {
+			delay += max(0, time.Until(g.txDone)) + g.link.delay(len(data), g.rnd)
+		}
+		g.mu.Unlock()
+		time.Sleep(delay)
+		for _, it := range chunks {
+			g.handleData(it)
+		}
+	}
+}
+
+func (g *GXReplay) handleData(data []byte) {
+	g.bytesReceived += uint64(len(data))
+	g.tracef(gxcommon.TraceTypesReceived, "RX: % X", data)
+	g.mu.RLock()
+	synchronous, cb, p := g.synchronous, g.onReceive, g.parser
+	g.mu.RUnlock()
+	if synchronous {
+		g.received.Append(data)
+	} else if cb != nil {
+		if p == nil {
+			cb(g, *gxcommon.NewReceiveEventArgs(data, g.Name))
+			return
+		}
+		for _, it := range p.Feed(data) {
+			cb(g, *gxcommon.NewReceiveEventArgs(p.Decode(it), g.Name))
+		}
+	}
+}
+
+// Receive implements IGXMedia
+func (g *GXReplay) Receive(args *gxcommon.ReceiveParameters) (bool, error) {
+	if args.EOP == nil && args.Count == 0 && !args.AllData {
+		return false, errors.New(localize(g.p, MsgCountOrEop))
+	}
+	// The frames are found with the same framing as the asynchronously received data.
+	p, err := NewParser(args.EOP)
+	if err != nil {
+		return false, err
+	}
+	var waitTime time.Duration
+	if args.WaitTime > 0 {
+		waitTime = time.Duration(args.WaitTime) * time.Millisecond
+	}
+	var index int
+	if p.match != nil {
+		index = g.received.SearchFunc(p.match, args.Count, waitTime)
+	} else {
+		index = g.received.Search(nil, args.Count, waitTime)
+	}
+	if index < 0 {
+		return false, nil
+	}
+	if args.AllData {
+		//Read all data.
+		index = -1
+	} else if args.EOP == nil {
+		//Without the EOP the reply is Count bytes.
+		index = args.Count
+	}
+	reply := g.received.Get(index)
+	if args.EOP != nil {
+		reply = p.Decode(reply)
+	}
+	args.Reply, err = gxcommon.BytesToAny2(reply, args.ReplyType, binary.ByteOrder(binary.BigEndian))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Close implements IGXMedia
+func (g *GXReplay) Close() error {
+	g.mu.Lock()
+	if !g.open {
+		g.mu.Unlock()
+		return nil
+	}
+	g.open = false
+	close(g.queue)
+	g.mu.Unlock()
+	g.statef(gxcommon.MediaStateClosing)
+	g.wg.Wait()
+	g.statef(gxcommon.MediaStateClosed)
+	return nil
+}
+
+func (g *GXReplay) tracef(traceType gxcommon.TraceTypes, fmtStr string, a ...any) {
+	g.mu.RLock()
+	trace := !(int(g.traceLevel) < int(traceType))
+	cb := g.onTrace
+	g.mu.RUnlock()
+	if cb != nil && trace {
+		cb(g, *gxcommon.NewTraceEventArgs(traceType, fmt.Sprintf(fmtStr, a...), ""))
+	}
+}
+
+func (g *GXReplay) statef(state gxcommon.MediaState) {
+	g.mu.RLock()
+	cb := g.onState
+	g.mu.RUnlock()
+	if cb != nil {
+		cb(g, *gxcommon.NewMediaStateEventArgs(state))
+	}
+}
@@ -0,0 +1,180 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// Matcher finds a complete reply from the received data.
+// It returns the length of the reply and true when data contains a complete reply.
+type Matcher func(data []byte) (end int, ok bool)
+
+// Future is the result of an asynchronous request.
+type Future struct {
+	done  chan struct{}
+	once  sync.Once
+	reply []byte
+	err   error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// Done returns a channel that is closed when the request is completed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits until the request is completed and returns the reply.
+func (f *Future) Wait() ([]byte, error) {
+	<-f.done
+	return f.reply, f.err
+}
+
+func (f *Future) complete(reply []byte, err error) {
+	f.once.Do(func() {
+		f.reply = reply
+		f.err = err
+		close(f.done)
+	})
+}
+
+// request is a sent request that is waiting for the reply.
+type request struct {
+	data    any
+	matcher Matcher
+	timeout time.Duration
+	buf     []byte
+	future  *Future
+}
+
+// add adds received data to the reply.
+// It returns the data that doesn't belong to the reply.
+func (r *request) add(data []byte) []byte {
+	select {
+	case <-r.future.done:
+		return data
+	default:
+	}
+	r.buf = append(r.buf, data...)
+	end, ok := r.matcher(r.buf)
+	if !ok {
+		return nil
+	}
+	end = min(max(end, 0), len(r.buf))
+	rest := r.buf[end:]
+	r.future.complete(r.buf[:end:end], nil)
+	return rest
+}
+
+// SendRequest sends data and returns a future that is completed when the matcher finds the reply
+// from the received data or when the timeout elapses. Zero timeout waits until the media is closed.
+// Requests are sent one at a time in the order they are made, so several goroutines can share the media
+// without entering synchronous mode. Received data that doesn't belong to a reply is handled as usual.
+// The matcher is called from the reader goroutine.
+func (g *GXSerial) SendRequest(data any, matcher Matcher, timeout time.Duration) *Future {
+	f := newFuture()
+	if matcher == nil {
+		f.complete(nil, gxcommon.ErrInvalidArgument)
+		return f
+	}
+	r := &request{data: data, matcher: matcher, timeout: timeout, future: f}
+	g.requestMu.Lock()
+	g.requests = append(g.requests, r)
+	start := len(g.requests) == 1
+	g.requestMu.Unlock()
+	if start {
+		go g.serveRequests()
+	}
+	return f
+}
+
+// serveRequests sends queued requests until the queue is empty.
+func (g *GXSerial) serveRequests() {
+	for {
+		g.requestMu.Lock()
+		r := g.requests[0]
+		g.requestMu.Unlock()
+		g.runRequest(r)
+		g.requestMu.Lock()
+		g.requests = g.requests[1:]
+		empty := len(g.requests) == 0
+		g.requestMu.Unlock()
+		if empty {
+			return
+		}
+	}
+}
+
+func (g *GXSerial) runRequest(r *request) {
+	select {
+	case <-r.future.done:
+		//Request is cancelled.
+		return
+	default:
+	}
+	g.request.Store(r)
+	defer g.request.Store(nil)
+	if err := g.Send(r.data, ""); err != nil {
+		r.future.complete(nil, err)
+		return
+	}
+	var timeout <-chan time.Time
+	if r.timeout > 0 {
+		t := time.NewTimer(r.timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-r.future.done:
+	case <-timeout:
+		r.future.complete(nil, fmt.Errorf("%s: %w", localize(g.p, MsgRequestTimeout, g.Port, r.timeout), os.ErrDeadlineExceeded))
+	}
+}
+
+// cancelRequests completes all queued requests with the given error.
+func (g *GXSerial) cancelRequests(err error) {
+	g.requestMu.Lock()
+	defer g.requestMu.Unlock()
+	for _, r := range g.requests {
+		r.future.complete(nil, err)
+	}
+}
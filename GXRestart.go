@@ -0,0 +1,107 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// RestartPolicy defines how the reader is restarted after a read error while the serial port is still open.
+type RestartPolicy struct {
+	// Attempts is the maximum amount of consecutive restarts.
+	Attempts int
+	// Backoff is the delay before the first restart. The delay is doubled after each failed restart.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between the restarts. Zero if the delay is not limited.
+	MaxBackoff time.Duration
+}
+
+// delay returns the delay before the restart after the given amount of failed restarts.
+func (p *RestartPolicy) delay(failures int) time.Duration {
+	ret := p.Backoff
+	for i := 0; i < failures && (p.MaxBackoff <= 0 || ret < p.MaxBackoff); i++ {
+		ret *= 2
+	}
+	if p.MaxBackoff > 0 {
+		ret = min(ret, p.MaxBackoff)
+	}
+	return ret
+}
+
+// ReaderRestart returns the restart policy of the reader. Nil if the reader is not restarted.
+func (g *GXSerial) ReaderRestart() *RestartPolicy {
+	return g.readerRestart.Load()
+}
+
+// SetReaderRestart sets the restart policy of the reader.
+// When the reader stops on an error while the serial port is still open, it's restarted after the backoff.
+// When the attempts are used, the error event is notified and the reader stops. Nil disables restarting.
+func (g *GXSerial) SetReaderRestart(value *RestartPolicy) error {
+	if value != nil && (value.Attempts < 0 || value.Backoff < 0 || value.MaxBackoff < 0) {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.readerRestart.Store(value)
+	return nil
+}
+
+// restartReader waits for the backoff after a read error.
+// It returns false if the reader is not restarted.
+func (g *GXSerial) restartReader(failures *int) bool {
+	policy := g.ReaderRestart()
+	if policy == nil || !g.s.isOpen() {
+		return false
+	}
+	if *failures >= policy.Attempts {
+		g.errorf(true, "read", errors.New(localize(g.p, MsgReaderStopped, g.Port, *failures)))
+		return false
+	}
+	delay := policy.delay(*failures)
+	*failures++
+	g.tracef(true, gxcommon.TraceTypesInfo, "Restarting reader in %v (%d/%d)", delay, *failures, policy.Attempts)
+	//Wait in short slices so that Close is not delayed.
+	for end := time.Now().Add(delay); time.Now().Before(end); {
+		if g.closing.Load() || !g.s.isOpen() {
+			return false
+		}
+		time.Sleep(min(transientErrorDelay, time.Until(end)))
+	}
+	if !g.s.isOpen() {
+		return false
+	}
+	_ = g.s.clearError()
+	return true
+}
@@ -0,0 +1,155 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// defaultRetainLimit is the default amount of retained bytes.
+const defaultRetainLimit = 4096
+
+// retainBuffer keeps the asynchronously received frames that no handler receives.
+type retainBuffer struct {
+	mu     sync.Mutex
+	frames [][]byte
+	size   int
+	// Maximum amount of retained bytes. Zero if the data is not retained.
+	limit atomic.Int64
+	// Amount of the retained bytes that are discarded because the buffer is full.
+	discarded atomic.Uint64
+}
+
+// add adds the frame. The oldest frames are discarded if the buffer is full. The caller holds the lock.
+func (r *retainBuffer) add(frame []byte) {
+	limit := int(r.limit.Load())
+	if len(frame) > limit {
+		r.discarded.Add(uint64(len(frame) - limit))
+		frame = frame[len(frame)-limit:]
+	}
+	r.frames = append(r.frames, append([]byte(nil), frame...))
+	r.size += len(frame)
+	r.trim(limit)
+}
+
+// trim discards the oldest frames until the retained data fits in the limit. The caller holds the lock.
+func (r *retainBuffer) trim(limit int) {
+	for r.size > limit {
+		r.discarded.Add(uint64(len(r.frames[0])))
+		r.size -= len(r.frames[0])
+		r.frames[0] = nil
+		r.frames = r.frames[1:]
+	}
+}
+
+// take removes and returns the retained frames. The caller holds the lock.
+func (r *retainBuffer) take() [][]byte {
+	ret := r.frames
+	r.frames = nil
+	r.size = 0
+	return ret
+}
+
+// RetainLimit returns the maximum amount of bytes that are retained when no handler receives them.
+func (g *GXSerial) RetainLimit() int {
+	return int(g.retained.limit.Load())
+}
+
+// SetRetainLimit sets the maximum amount of bytes that are retained when the asynchronously
+// received data has no handler, i.e. the received event, the timestamped received event and
+// the frame subscriptions are not used. When the buffer is full, the oldest frames are discarded.
+// The retained data is read with ReadAvailable or passed to the handler that SetOnReceived sets.
+// Zero disables retaining. Default is 4096 bytes.
+func (g *GXSerial) SetRetainLimit(value int) error {
+	if value < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.retained.mu.Lock()
+	g.retained.limit.Store(int64(value))
+	g.retained.trim(value)
+	g.retained.mu.Unlock()
+	return nil
+}
+
+// ReadAvailable removes and returns the retained data. Nil if no data is retained.
+func (g *GXSerial) ReadAvailable() []byte {
+	g.retained.mu.Lock()
+	frames := g.retained.take()
+	g.retained.mu.Unlock()
+	var ret []byte
+	for _, it := range frames {
+		ret = append(ret, it...)
+	}
+	return ret
+}
+
+// DiscardedRetained returns the amount of the retained bytes that are discarded because the buffer was full.
+func (g *GXSerial) DiscardedRetained() uint64 {
+	return g.retained.discarded.Load()
+}
+
+// retain keeps the received frame if no handler receives it.
+// It returns true if the frame is retained.
+func (g *GXSerial) retain(frame []byte) bool {
+	g.retained.mu.Lock()
+	defer g.retained.mu.Unlock()
+	if g.retained.limit.Load() == 0 {
+		return false
+	}
+	g.mu.RLock()
+	handled := g.onReceive != nil || g.onTimestamped != nil
+	g.mu.RUnlock()
+	if handled || g.hasFrameSubscriptions() {
+		return false
+	}
+	g.retained.add(frame)
+	return true
+}
+
+// setOnReceived sets the received handler and returns the retained frames that are passed to it.
+// The data stays retained if the port is closed because the handler is not called then.
+func (g *GXSerial) setOnReceived(value gxcommon.ReceivedEventHandler) [][]byte {
+	g.retained.mu.Lock()
+	defer g.retained.mu.Unlock()
+	g.mu.Lock()
+	g.onReceive = value
+	g.mu.Unlock()
+	if value == nil || !g.s.isOpen() {
+		return nil
+	}
+	return g.retained.take()
+}
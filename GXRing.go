@@ -0,0 +1,98 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "sync"
+
+// ring is a bounded buffer that keeps the latest items.
+type ring[T any] struct {
+	mu    sync.Mutex
+	items []T
+	start int
+	count int
+}
+
+// newRing creates a ring with the given capacity.
+func newRing[T any](capacity int) *ring[T] {
+	return &ring[T]{items: make([]T, capacity)}
+}
+
+// Add adds an item. The oldest item is removed if the ring is full.
+func (r *ring[T]) Add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.items) == 0 {
+		return
+	}
+	r.items[(r.start+r.count)%len(r.items)] = item
+	if r.count == len(r.items) {
+		r.start = (r.start + 1) % len(r.items)
+	} else {
+		r.count++
+	}
+}
+
+// Items returns the items from the oldest to the latest.
+func (r *ring[T]) Items() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ret := make([]T, 0, r.count)
+	for i := 0; i < r.count; i++ {
+		ret = append(ret, r.items[(r.start+i)%len(r.items)])
+	}
+	return ret
+}
+
+// Resize changes the capacity. The latest items are kept.
+func (r *ring[T]) Resize(capacity int) {
+	items := r.Items()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(items) > capacity {
+		items = items[len(items)-capacity:]
+	}
+	r.items = make([]T, capacity)
+	copy(r.items, items)
+	r.start = 0
+	r.count = len(items)
+}
+
+// Clear removes all items.
+func (r *ring[T]) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.items)
+	r.start = 0
+	r.count = 0
+}
@@ -0,0 +1,140 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// ringPollInterval is the interval how often the ring indicator line is read.
+const ringPollInterval = 50 * time.Millisecond
+
+// RingEventArgs describes the detected ring.
+type RingEventArgs struct {
+	// Time is the time when the ring was detected.
+	Time time.Time
+	// Count is the amount of rings after the port was opened or the counter was reset.
+	Count int
+}
+
+// RingEventHandler is called when the ring indicator line is asserted.
+type RingEventHandler func(m gxcommon.IGXMedia, e RingEventArgs)
+
+// AnswerHandler answers the incoming call, e.g. by sending ATA to the modem.
+type AnswerHandler func(m *GXSerial) error
+
+// SetOnRing sets the handler that is called when a ring is detected.
+// The ring indicator line is polled while the port is open and a handler or an answer routine is set.
+func (g *GXSerial) SetOnRing(value RingEventHandler) {
+	g.mu.Lock()
+	g.onRing = value
+	g.startRingWatch()
+	g.mu.Unlock()
+}
+
+// SetAutoAnswer sets the routine that answers the call after the given amount of rings.
+// The routine is called in its own goroutine and the ring counter is reset before it's called.
+// An error from the routine is notified through the error event. Nil answer disables auto-answer.
+func (g *GXSerial) SetAutoAnswer(rings int, answer AnswerHandler) error {
+	if rings < 1 && answer != nil {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	g.answerRings = rings
+	g.answer = answer
+	g.startRingWatch()
+	g.mu.Unlock()
+	return nil
+}
+
+// RingCount returns the amount of rings after the port was opened or the counter was reset.
+func (g *GXSerial) RingCount() int {
+	return int(g.rings.Load())
+}
+
+// ResetRingCount resets the ring counter.
+func (g *GXSerial) ResetRingCount() {
+	g.rings.Store(0)
+}
+
+// startRingWatch starts polling the ring indicator if it's needed. The caller holds the lock.
+func (g *GXSerial) startRingWatch() {
+	if (g.onRing == nil && g.answer == nil) || !g.s.isOpen() {
+		return
+	}
+	if g.ringWatching.CompareAndSwap(false, true) {
+		go g.watchRing()
+	}
+}
+
+// watchRing polls the ring indicator line until the port is closed or ring detection is not used.
+// The ring is counted when the line is asserted.
+func (g *GXSerial) watchRing() {
+	defer g.ringWatching.Store(false)
+	prev := false
+	for {
+		g.mu.RLock()
+		cb, answer, rings := g.onRing, g.answer, g.answerRings
+		g.mu.RUnlock()
+		if cb == nil && answer == nil {
+			return
+		}
+		status, err := g.s.getModemStatus()
+		if err != nil {
+			return
+		}
+		if status.Ri && !prev {
+			e := RingEventArgs{Time: time.Now(), Count: int(g.rings.Add(1))}
+			g.trace(true, gxcommon.TraceTypesInfo, localize(g.p, MsgRingDetected, g.Port, e.Count))
+			if cb != nil {
+				g.callHandler(true, "Ring", func() {
+					cb(g, e)
+				})
+			}
+			if answer != nil && e.Count >= rings {
+				g.rings.Store(0)
+				go g.callHandler(true, "Answer", func() {
+					if err := answer(g); err != nil {
+						g.errorf(true, "answer", errors.Join(errors.New(localize(g.p, MsgAnswerFailed, g.Port)), err))
+					}
+				})
+			}
+		}
+		prev = status.Ri
+		time.Sleep(ringPollInterval)
+	}
+}
@@ -0,0 +1,138 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// RtsToggle raises RTS while the data is sent, so RS-485 converters without automatic
+// direction control enable the transmitter only during the transmission.
+// Use the native RS-485 mode (SetRS485) instead when the driver supports it.
+type RtsToggle struct {
+	// DelayBeforeSend is the delay after RTS is raised before the data is sent.
+	DelayBeforeSend time.Duration
+	// DelayAfterSend is the delay after the last byte is sent before RTS is lowered.
+	DelayAfterSend time.Duration
+}
+
+// RtsToggle returns the RTS toggle mode. Nil if the mode is not used.
+func (g *GXSerial) RtsToggle() *RtsToggle {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.rtsToggle
+}
+
+// SetRtsToggle sets the RTS toggle mode that drives RTS automatically during the transmission.
+// On Windows the driver toggles RTS (RTS_CONTROL_TOGGLE) and the delays are not used.
+// On Linux and macOS RTS is raised before the data is written and lowered after the transmitter is empty.
+// The mode can't be used with the native RS-485 mode. If the port is open, the mode is applied immediately.
+// Nil disables the mode.
+func (g *GXSerial) SetRtsToggle(value *RtsToggle) error {
+	if value != nil && (value.DelayBeforeSend < 0 || value.DelayAfterSend < 0) {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if value != nil && g.rs485 != nil && g.rs485.Enabled {
+		return errors.New(localize(g.p, MsgRtsToggleConflict, g.Port))
+	}
+	if g.s.isOpen() && (value != nil) != (g.rtsToggle != nil) {
+		if err := g.applyRtsToggle(value != nil); err != nil {
+			return err
+		}
+	}
+	if value != nil {
+		v := *value
+		value = &v
+	}
+	g.rtsToggle = value
+	return nil
+}
+
+// applyRtsToggle sets the RTS toggle mode to the driver. The caller holds the lock.
+// RTS is lowered when the mode is enabled.
+func (g *GXSerial) applyRtsToggle(on bool) error {
+	if err := g.checkWritable(); err != nil {
+		return err
+	}
+	return g.s.setRtsToggle(on)
+}
+
+// beginTransmit raises RTS and switches the transceiver direction before the data is written
+// when the application toggles them. The returned function restores them after the data is sent.
+func (g *GXSerial) beginTransmit() (func(), error) {
+	rts, err := g.beginRtsToggle()
+	if err != nil {
+		return nil, err
+	}
+	direction, err := g.beginDirection()
+	if err != nil {
+		rts()
+		return nil, err
+	}
+	return func() {
+		direction()
+		rts()
+	}, nil
+}
+
+// beginRtsToggle raises RTS before the data is written when the application toggles RTS.
+// The returned function lowers RTS after the data is sent.
+func (g *GXSerial) beginRtsToggle() (func(), error) {
+	if rtsToggleDriver {
+		return func() {}, nil
+	}
+	g.mu.RLock()
+	t := g.rtsToggle
+	g.mu.RUnlock()
+	if t == nil {
+		return func() {}, nil
+	}
+	if err := g.s.setRtsEnable(true); err != nil {
+		return nil, err
+	}
+	time.Sleep(t.DelayBeforeSend)
+	return func() {
+		if err := g.s.waitTxEmpty(g.charTime()); err != nil {
+			g.log(slog.LevelWarn, "wait for empty transmitter failed", "err", err)
+		}
+		time.Sleep(t.DelayAfterSend)
+		g.s.logError("lower RTS failed", g.s.setRtsEnable(false))
+	}, nil
+}
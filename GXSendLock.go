@@ -0,0 +1,131 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// sendLock serializes the senders in the order they arrive.
+type sendLock struct {
+	mu   sync.Mutex
+	held bool
+	// Senders that wait for their turn. The lock is handed over by closing the channel.
+	queue []chan struct{}
+}
+
+// acquire waits until it's the turn of the caller. Zero timeout waits forever.
+// False is returned if the timeout elapses.
+func (l *sendLock) acquire(timeout time.Duration) bool {
+	l.mu.Lock()
+	if !l.held {
+		l.held = true
+		l.mu.Unlock()
+		return true
+	}
+	ch := make(chan struct{})
+	l.queue = append(l.queue, ch)
+	l.mu.Unlock()
+	if timeout <= 0 {
+		<-ch
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ch:
+		return true
+	case <-timer.C:
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if pos := slices.Index(l.queue, ch); pos != -1 {
+		l.queue = slices.Delete(l.queue, pos, pos+1)
+		return false
+	}
+	// The lock was handed over when the timeout elapsed.
+	return true
+}
+
+// release hands the lock over to the next sender.
+func (l *sendLock) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.queue) == 0 {
+		l.held = false
+		return
+	}
+	ch := l.queue[0]
+	l.queue = l.queue[1:]
+	close(ch)
+}
+
+// SendQueueTimeout returns the maximum time a send waits until the previous sends are completed.
+// Zero if the send waits until it's its turn.
+func (g *GXSerial) SendQueueTimeout() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.sendQueueTimeout
+}
+
+// SetSendQueueTimeout sets the maximum time a send waits until the previous sends are completed.
+// Sends from several goroutines are written one at a time in the order they are made,
+// so the bytes of different frames are not interleaved. If the timeout elapses, the data is not
+// sent and an error that wraps os.ErrDeadlineExceeded is returned. Zero waits until it's the turn of the send.
+func (g *GXSerial) SetSendQueueTimeout(value time.Duration) error {
+	if value < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	g.sendQueueTimeout = value
+	g.mu.Unlock()
+	return nil
+}
+
+// lockSend waits until the previous sends are completed.
+func (g *GXSerial) lockSend() error {
+	timeout := g.SendQueueTimeout()
+	if !g.sendLock.acquire(timeout) {
+		err := fmt.Errorf("%s: %w", localize(g.p, MsgSendQueueTimeout, g.Port, timeout), os.ErrDeadlineExceeded)
+		g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: "send", Err: err})
+		return err
+	}
+	return nil
+}
@@ -40,9 +40,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
+	"log/slog"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Gurux/gxcommon-go"
@@ -58,13 +60,137 @@ type GXSerial struct {
 	stopBits gxcommon.StopBits
 	parity   gxcommon.Parity
 	eop      any
+	// Flow control of the serial port.
+	handshake Handshake
+	// Splits the asynchronously received data to frames. Nil if the EOP is not set.
+	parser *Parser
+	// Checksum of the frames. Nil if not used.
+	checksum Checksum
+	// Terminator that is appended to the sent strings.
+	lineTermination LineTermination
+	// Validates the received frames. Nil if not used.
+	validator FrameValidator
+	// Acknowledgement policy of SendAcknowledged. Nil if not used.
+	ackPolicy *AckPolicy
+	// Drops the frames that are addressed to other stations. Nil if not used.
+	addressFilter *AddressFilter
+	// Amount of frames that the address filter has dropped.
+	discardedFrames atomic.Uint64
+	// Strips the idle and fill bytes before the data is framed.
+	noiseFilter *NoiseFilter
+	// Amount of fill bytes that the noise filter has stripped.
+	discardedNoise atomic.Uint64
+	// Has no other than fill bytes been received after the port was opened.
+	noiseIdle bool
+	// Is the echo of the sent data removed from the received data.
+	echoSuppression bool
+	// Sent data whose echo has not been received yet.
+	echo echoBuffer
+	// Is the 9th bit of the received bytes reported.
+	nineBitReceive bool
+	// Replaces the received bytes with a parity error if parityErrorCharOn is set.
+	parityErrorChar   byte
+	parityErrorCharOn bool
+	// Incomplete parity error mark of the previous read.
+	parityPending []byte
+	// Is the null byte of a break that is reported before it's read removed from the next read.
+	breakNull bool
+	// Native RS-485 mode of the driver. Nil if the driver configuration is not changed.
+	rs485 *RS485
+	// Is the low latency mode of the driver used.
+	lowLatency bool
+	// Is the internal loopback mode of the driver used.
+	loopback bool
+	// Does the driver discard the received null bytes.
+	discardNull bool
+	// Does the driver abort the pending reads and writes on a line error.
+	abortOnError bool
+	// State of the RTS line that is set when the port is opened. Nil uses the driver default.
+	rtsEnable *bool
+	// Drives RTS during the transmission. Nil if not used.
+	rtsToggle *RtsToggle
+	// Switches the RS-485 transceiver direction with a GPIO during the transmission. Nil if not used.
+	direction *DirectionControl
+	// State of the DTR line that is set when the port is opened. Nil uses the driver default.
+	dtrEnable *bool
+	// Arrival time of the data that the reader handles.
+	rxTime time.Time
+	// Is the port opened without write access.
+	readOnly bool
+	// Does the reader continue after transient read errors.
+	keepOpen bool
+	// Is the port being closed. The reader stops when it's set.
+	closing atomic.Bool
+	// Restart policy of the reader. Nil if the reader is not restarted.
+	readerRestart atomic.Pointer[RestartPolicy]
+	// How removal and re-attach of the device are handled.
+	hotplug HotplugMode
+	// Called when the device is removed or attached.
+	onDevice DeviceEventHandler
+	// Called when the transmitter is empty after the data is sent.
+	onTxEmpty TxEmptyEventHandler
+	// Amount of bytes that are sent after the previous TxEmpty event.
+	txSent atomic.Int64
+	// Is the empty transmitter waited.
+	txWaiting atomic.Bool
+	// Called when a ring is detected.
+	onRing RingEventHandler
+	// Answers the call after answerRings rings. Nil if auto-answer is not used.
+	answer      AnswerHandler
+	answerRings int
+	// Amount of detected rings.
+	rings atomic.Int64
+	// Is the ring indicator polled.
+	ringWatching atomic.Bool
+	// Called when a modem status line changes state.
+	onPinChanged PinChangedEventHandler
+	// Called when a break condition is received. Nil if breaks are not detected.
+	onBreak BreakEventHandler
+	// Stop channel of the session whose modem status lines are watched. Nil if the lines are not watched.
+	pinStop chan struct{}
+	// Receive the changes of the modem status lines that WaitForLineChange waits.
+	pinWaiters map[chan PinChangedEventArgs]ModemLineMask
+	// Called when no data has been received for idlePeriod.
+	onIdle     IdleEventHandler
+	idlePeriod time.Duration
+	// Stops the idle timer. Nil if it's not started.
+	idleStop chan struct{}
+	// Time when the data was received last time in Unix nanoseconds.
+	lastReceived atomic.Int64
+	// Times of the last received and sent data.
+	lastRx, lastTx activityTime
+	// Recovers the link that doesn't reply. Nil if not used.
+	watchdog *Watchdog
+	// Stops the watchdog. Nil if it's not started.
+	watchdogStop chan struct{}
+	// Time when data was sent first time after the last received data in Unix nanoseconds. Zero if all sent data is answered.
+	unanswered atomic.Int64
+	// Counts the throughput over the sliding window.
+	meter throughputMeter
+	// Stops the device notifications. Nil if they are not started.
+	watchStop func()
+	// Serial number of the opened device. Empty if it's unknown.
+	deviceSerial string
+	// Is the media closed with Close. The device watcher doesn't open it again. Guarded by lifecycleMu.
+	closedByUser bool
+	// Writes the sent and received data to the mirror sink. Nil if not used.
+	mirror func(line []byte) error
+	// Serializes the mirror writes.
+	mirrorMu sync.Mutex
 	// The trace level specifies which types of trace messages are emitted.
 	traceLevel gxcommon.TraceLevel
 	// OnReceived: Media component notifies asynchronously received data through this method.
 	mu sync.RWMutex
 	wg sync.WaitGroup
 
-	stop        chan struct{}
+	// Closed when the port is closed. Event handlers are not called after that.
+	stop chan struct{}
+	// Guards the stop channel against the registration of the event handlers.
+	handlerMu sync.Mutex
+	// Event handlers that are in progress.
+	handlers sync.WaitGroup
+	// Serializes Open and Close so that Close can wait without holding the lock.
+	lifecycleMu sync.Mutex
 	synchronous bool
 
 	bytesSent     uint64
@@ -76,17 +202,67 @@ type GXSerial struct {
 	//Called when the new data is received.
 	onReceive gxcommon.ReceivedEventHandler
 
+	//Called with the arrival time when the new data is received.
+	onTimestamped TimestampedReceivedEventHandler
+
+	//Called with the received frames before they are delivered.
+	onReceiveFilter ReceiveFilterHandler
+
 	//Called when the Media is sending or receiving data.
 	onTrace gxcommon.TraceEventHandler
 
 	//Called when the Media is sending or receiving data.
 	onErr gxcommon.ErrorEventHandler
 
+	//Called with structured trace data when the Media is sending or receiving data.
+	onTraceData TraceDataEventHandler
+
+	//Called before sent or received data is traced.
+	redactor TraceRedactor
+
+	//Encodes the sent strings and renders the traced data. Nil if the default conversion is used.
+	textCodec TextCodec
+
+	//Does SetSettings reject unknown elements.
+	strictSettings bool
+
 	//Sync settings.
 	receivedSize int
 	received     synchronousMediaBase
+	// Asynchronously received data that no handler has received.
+	retained retainBuffer
 
 	s port
+	// Control line used by ResetTarget.
+	resetLine ControlLine
+	// Maximum amount of sent bytes per second. Zero if not limited.
+	txRate int
+	// Maximum time to wait for the serial port to become available.
+	connectTimeout time.Duration
+	// Maximum time to write the data. Zero if not limited.
+	writeTimeout time.Duration
+	// Are open diagnostics traced.
+	openDiagnostics bool
+	// Latest errors.
+	errorHistory *ring[ErrorRecord]
+	// Flight recorder. Nil if disabled.
+	recorder atomic.Pointer[flightRecorder]
+	// Queued requests. The first request is the active one.
+	requestMu sync.Mutex
+	// Logger of the internal conditions.
+	logger atomic.Pointer[slog.Logger]
+	// Serializes the sends of several goroutines.
+	sendLock sendLock
+	// Maximum time a send waits until the previous sends are completed.
+	sendQueueTimeout time.Duration
+	requests         []*request
+	// Request that is waiting for the reply.
+	request atomic.Pointer[request]
+	// Subscribers of the complete frames.
+	frameMu   sync.Mutex
+	frameSubs map[*frameSubscription]struct{}
+	// Amount of frames that are dropped because the subscriber is too slow.
+	droppedFrames atomic.Uint64
 	// Printer for localized messages.
 	p *message.Printer
 }
@@ -98,11 +274,16 @@ func NewGXSerial(port string,
 	parity gxcommon.Parity,
 	stopBits gxcommon.StopBits) *GXSerial {
 	g := &GXSerial{Port: port, baudRate: baudRate, dataBits: dataBits, stopBits: stopBits, parity: parity, stop: make(chan struct{})}
-	g.Localize(language.AmericanEnglish)
+	g.Localize(DefaultLanguage())
 	g.received = *newGXSynchronousMediaBase()
+	g.errorHistory = newRing[ErrorRecord](defaultErrorHistorySize)
+	g.retained.limit.Store(defaultRetainLimit)
 	return g
 }
 
+// defaultErrorHistorySize is the default amount of kept errors.
+const defaultErrorHistorySize = 16
+
 // GetPortNames retrurns list of available serial ports.
 func GetPortNames() ([]string, error) {
 	return getPortNames()
@@ -142,7 +323,11 @@ func (g *GXSerial) StopBits() gxcommon.StopBits {
 }
 
 // SetStopBits sets the used stop bits.
+// *UnsupportedSettingError is returned if 1.5 stop bits are not supported on this platform.
 func (g *GXSerial) SetStopBits(value gxcommon.StopBits) error {
+	if value == gxcommon.StopBitsOnePointFive && !g.Capabilities().OnePointFiveStopBits {
+		return g.unsupported("StopBits", value)
+	}
 	g.stopBits = value
 	if g.s.isOpen() {
 		return g.s.setStopBits(value)
@@ -156,7 +341,11 @@ func (g *GXSerial) Parity() gxcommon.Parity {
 }
 
 // SetParity sets the used parity.
+// *UnsupportedSettingError is returned if mark and space parity are not supported on this platform.
 func (g *GXSerial) SetParity(value gxcommon.Parity) error {
+	if (value == gxcommon.ParityMark || value == gxcommon.ParitySpace) && !g.Capabilities().MarkSpaceParity {
+		return g.unsupported("Parity", value)
+	}
 	g.parity = value
 	if g.s.isOpen() {
 		return g.s.setParity(value)
@@ -180,9 +369,139 @@ func (g *GXSerial) GetBytesToWrite() (int, error) {
 	return 0, nil
 }
 
+// Capabilities returns what the current platform and serial port support.
+// Some capabilities, e.g. native RS-485, can be detected only when the port is open.
+func (g *GXSerial) Capabilities() Capabilities {
+	return g.s.capabilities()
+}
+
+// RecentErrors returns the latest errors from the oldest to the latest.
+// Errors are kept even if OnError handler is not registered.
+func (g *GXSerial) RecentErrors() []ErrorRecord {
+	return g.errorHistory.Items()
+}
+
+// SetErrorHistorySize sets the amount of kept errors. Default is 16.
+func (g *GXSerial) SetErrorHistorySize(value int) error {
+	if value < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.errorHistory.Resize(value)
+	return nil
+}
+
+// ClearRecentErrors removes the kept errors.
+func (g *GXSerial) ClearRecentErrors() {
+	g.errorHistory.Clear()
+}
+
+// EnableFlightRecorder starts keeping the latest size events in memory.
+// Sent and received payloads are truncated to maxPayload bytes.
+// The flight recorder is used to diagnose intermittent failures without verbose tracing.
+func (g *GXSerial) EnableFlightRecorder(size int, maxPayload int) error {
+	if size <= 0 || maxPayload < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.recorder.Store(&flightRecorder{events: newRing[FlightEvent](size), maxPayload: maxPayload})
+	return nil
+}
+
+// DisableFlightRecorder stops the flight recorder and removes the kept events.
+func (g *GXSerial) DisableFlightRecorder() {
+	g.recorder.Store(nil)
+}
+
+// FlightEvents returns the events kept by the flight recorder from the oldest to the latest.
+func (g *GXSerial) FlightEvents() []FlightEvent {
+	if r := g.recorder.Load(); r != nil {
+		return r.events.Items()
+	}
+	return nil
+}
+
+// DumpFlightRecorder writes the events kept by the flight recorder to w, one event per line.
+func (g *GXSerial) DumpFlightRecorder(w io.Writer) error {
+	if r := g.recorder.Load(); r != nil {
+		return r.dump(w)
+	}
+	return nil
+}
+
+// record adds an event to the flight recorder if it's enabled.
+func (g *GXSerial) record(e FlightEvent) {
+	if r := g.recorder.Load(); r != nil {
+		r.add(e)
+	}
+}
+
+// DumpPortState returns the effective platform settings, control line states and driver queue levels.
+// It's used to debug differences between platforms. The state is also traced as info after Open.
+func (g *GXSerial) DumpPortState() (*PortState, error) {
+	if !g.s.isOpen() {
+		return nil, errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	return g.s.dumpState()
+}
+
+// ResetLine returns the control line that ResetTarget pulses.
+func (g *GXSerial) ResetLine() ControlLine {
+	return g.resetLine
+}
+
+// SetResetLine sets the control line that ResetTarget pulses. DTR is used by default.
+func (g *GXSerial) SetResetLine(value ControlLine) {
+	g.resetLine = value
+}
+
+// ResetTarget resets the attached board by dropping the reset line for the given pulse time and raising it again.
+// This is the way Arduino/AVR-style boards are reset before a transfer.
+func (g *GXSerial) ResetTarget(pulse time.Duration) error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if err := g.setControlLine(g.resetLine, false); err != nil {
+		return err
+	}
+	time.Sleep(pulse)
+	return g.setControlLine(g.resetLine, true)
+}
+
+// RunLineSequence executes the given control line sequence.
+// Use it with SequenceEsp32Classic, SequenceEsp32UsbJtag, SequenceStm32 or
+// a custom sequence to put the attached board into the bootloader mode.
+func (g *GXSerial) RunLineSequence(seq LineSequence) error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	for _, it := range seq {
+		if it.Wait != 0 {
+			time.Sleep(it.Wait)
+			continue
+		}
+		if err := g.setControlLine(it.Line, it.State); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setControlLine sets the state of the given control line.
+func (g *GXSerial) setControlLine(line ControlLine, on bool) error {
+	if err := g.checkWritable(); err != nil {
+		return err
+	}
+	switch line {
+	case ControlLineDtr:
+		return g.s.setDtrEnable(on)
+	case ControlLineRts:
+		return g.s.setRtsEnable(on)
+	}
+	return gxcommon.ErrInvalidArgument
+}
+
 // String implements IGXMedia
 func (g *GXSerial) String() string {
-	return fmt.Sprintf("%s %s %d %s %s", g.Port, g.baudRate, g.dataBits, g.stopBits, g.parity)
+	return fmt.Sprintf("%s %s %d %s %s", g.Port, baudRateString(g.baudRate), g.dataBits, g.stopBits, g.parity)
 }
 
 // GetName implements IGXMedia
@@ -204,8 +523,43 @@ func (g *GXSerial) Copy(target gxcommon.IGXMedia) error {
 		dst.dataBits = g.dataBits
 		dst.stopBits = g.stopBits
 		dst.parity = g.parity
+		dst.handshake = g.handshake
 		dst.traceLevel = g.traceLevel
 		dst.eop = g.eop
+		dst.parser, _ = newFrameParser(g.eop, nil)
+		dst.checksum = g.checksum
+		dst.lineTermination = g.lineTermination
+		dst.validator = g.validator
+		dst.ackPolicy = g.ackPolicy
+		dst.addressFilter = g.addressFilter
+		dst.noiseFilter = g.noiseFilter
+		dst.echoSuppression = g.echoSuppression
+		dst.nineBitReceive = g.nineBitReceive
+		dst.parityErrorChar = g.parityErrorChar
+		dst.parityErrorCharOn = g.parityErrorCharOn
+		dst.rs485 = g.rs485
+		dst.lowLatency = g.lowLatency
+		dst.loopback = g.loopback
+		dst.discardNull = g.discardNull
+		dst.abortOnError = g.abortOnError
+		dst.rtsEnable = g.rtsEnable
+		dst.rtsToggle = g.rtsToggle
+		dst.direction = g.direction
+		dst.dtrEnable = g.dtrEnable
+		dst.readOnly = g.readOnly
+		dst.keepOpen = g.keepOpen
+		dst.readerRestart.Store(g.readerRestart.Load())
+		dst.hotplug = g.hotplug
+		dst.resetLine = g.resetLine
+		dst.txRate = g.txRate
+		dst.received.SetLimit(g.received.Limit())
+		dst.retained.limit.Store(g.retained.limit.Load())
+		dst.connectTimeout = g.connectTimeout
+		dst.writeTimeout = g.writeTimeout
+		dst.sendQueueTimeout = g.sendQueueTimeout
+		dst.openDiagnostics = g.openDiagnostics
+		dst.textCodec = g.textCodec
+		dst.strictSettings = g.strictSettings
 	default:
 		return fmt.Errorf("copy: target is %T; want *GXSerial", target)
 	}
@@ -226,7 +580,10 @@ func xmlEscape(s string) string {
 }
 
 // GetSettings implements IGXMedia
+// Settings that can't be saved, e.g. predicate EOPs and custom checksums, are left out.
 func (g *GXSerial) GetSettings() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	var b strings.Builder
 	if g.Port != "" {
 		fmt.Fprintf(&b, "<Port>%s</Port>\n", xmlEscape(g.Port))
@@ -243,15 +600,34 @@ func (g *GXSerial) GetSettings() string {
 	if g.parity != 0 {
 		fmt.Fprintf(&b, "<Parity>%d</Parity>\n", g.parity)
 	}
+	b.WriteString(eopSetting(g.eop))
+	if g.traceLevel != gxcommon.TraceLevelOff {
+		fmt.Fprintf(&b, "<Trace>%s</Trace>\n", g.traceLevel)
+	}
+	if g.connectTimeout != 0 {
+		fmt.Fprintf(&b, "<ConnectTimeout>%d</ConnectTimeout>\n", g.connectTimeout.Milliseconds())
+	}
+	if g.writeTimeout != 0 {
+		fmt.Fprintf(&b, "<WriteTimeout>%d</WriteTimeout>\n", g.writeTimeout.Milliseconds())
+	}
+	if g.sendQueueTimeout != 0 {
+		fmt.Fprintf(&b, "<SendQueueTimeout>%d</SendQueueTimeout>\n", g.sendQueueTimeout.Milliseconds())
+	}
+	g.appendOptionSettings(&b)
 	return b.String()
 }
 
 // SetSettings implements IGXMedia
+// Unknown elements are ignored unless strict settings are enabled.
 func (g *GXSerial) SetSettings(value string) error {
 	if strings.TrimSpace(value) == "" {
 		return nil
 	}
 	dec := xml.NewDecoder(strings.NewReader("<root>" + value + "</root>"))
+	//Skip the root element.
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
 	for {
 		tok, err := dec.Token()
 		if err == io.EOF {
@@ -264,50 +640,12 @@ func (g *GXSerial) SetSettings(value string) error {
 		if !ok {
 			continue
 		}
-
-		switch se.Name.Local {
-		case "Port":
-			var v string
-			if err := dec.DecodeElement(&v, &se); err != nil {
-				return err
-			}
-			g.Port = v
-		case "Bps":
-			var v string
-			if err := dec.DecodeElement(&v, &se); err != nil {
-				return err
-			}
-			g.baudRate, err = gxcommon.BaudRateParse(v)
-			if err != nil {
-				return err
-			}
-		case "ByteSize":
-			var v string
-			if err := dec.DecodeElement(&v, &se); err != nil {
-				return err
-			}
-			g.dataBits, err = strconv.Atoi(v)
-			if err != nil {
-				return fmt.Errorf("invalid ByteSize value: %v", err)
-			}
-		case "StopBits":
-			var v string
-			if err := dec.DecodeElement(&v, &se); err != nil {
-				return err
-			}
-			g.stopBits, err = gxcommon.StopBitsParse(v)
-			if err != nil {
-				return err
-			}
-		case "Parity":
-			var v string
-			if err := dec.DecodeElement(&v, &se); err != nil {
-				return err
-			}
-			g.parity, err = gxcommon.ParityParse(v)
-			if err != nil {
-				return err
-			}
+		var e settingsElement
+		if err := dec.DecodeElement(&e, &se); err != nil {
+			return err
+		}
+		if err := g.setSetting(se.Name.Local, &e); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -353,16 +691,75 @@ func (g *GXSerial) ResetByteCounters() {
 }
 
 // Validate implements IGXMedia
+// Validate also checks that the baud rate, data bits, parity and stop bits
+// can be used on this platform. *UnsupportedSettingError is returned if not.
 func (g *GXSerial) Validate() error {
 	if g.Port == "" {
-		return errors.New(g.p.Sprintf("msg.no_serial_port_selected"))
+		return errors.New(localize(g.p, MsgNoSerialPortSelected))
+	}
+	caps := g.Capabilities()
+	if !caps.SupportsBaudRate(g.baudRate) {
+		return g.unsupported("BaudRate", g.baudRate)
+	}
+	if g.dataBits < 5 || g.dataBits > 8 {
+		return g.unsupported("DataBits", g.dataBits)
+	}
+	switch g.parity {
+	case gxcommon.ParityNone, gxcommon.ParityOdd, gxcommon.ParityEven:
+	case gxcommon.ParityMark, gxcommon.ParitySpace:
+		if !caps.MarkSpaceParity {
+			return g.unsupported("Parity", g.parity)
+		}
+	default:
+		return g.unsupported("Parity", g.parity)
+	}
+	switch g.stopBits {
+	case gxcommon.StopBitsOne, gxcommon.StopBitsTwo:
+	case gxcommon.StopBitsOnePointFive:
+		if !caps.OnePointFiveStopBits {
+			return g.unsupported("StopBits", g.stopBits)
+		}
+	default:
+		return g.unsupported("StopBits", g.stopBits)
 	}
 	return nil
 }
 
+func (g *GXSerial) unsupported(setting string, value any) error {
+	return &UnsupportedSettingError{Setting: setting, Value: value,
+		msg: localize(g.p, MsgUnsupportedSetting, setting, value)}
+}
+
 // SetEop implements IGXMedia
+// Besides the byte, string and byte slice markers, the EOP can be a Matcher
+// or a func([]byte) (end int, ok bool) predicate that returns the length of the first complete frame.
+// In asynchronous mode received data is buffered until the EOP ends the frame.
 func (g *GXSerial) SetEop(eop any) {
+	g.mu.Lock()
+	p, err := newFrameParser(eop, g.parser)
 	g.eop = eop
+	g.parser = p
+	g.mu.Unlock()
+	if err != nil {
+		g.errorf(true, "eop", err)
+	}
+}
+
+// Checksum returns the checksum of the frames. Nil if the checksum is not used.
+func (g *GXSerial) Checksum() Checksum {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.checksum
+}
+
+// SetChecksum sets the checksum of the frames.
+// The checksum is appended to the data of Send. When the EOP is set, the checksum at the end of each
+// received frame, before the bytes of the EOP, is verified and removed. Frames with an invalid checksum are dropped and
+// *ChecksumError is notified through the error event. Nil disables the checksum.
+func (g *GXSerial) SetChecksum(value Checksum) {
+	g.mu.Lock()
+	g.checksum = value
+	g.mu.Unlock()
 }
 
 // GetEop implements IGXMedia
@@ -382,10 +779,14 @@ func (g *GXSerial) SetTrace(traceLevel gxcommon.TraceLevel) error {
 }
 
 // SetOnReceived implements IGXMedia
+// The data that is retained because it had no handler is passed to the new handler
+// before SetOnReceived returns. See SetRetainLimit.
 func (g *GXSerial) SetOnReceived(value gxcommon.ReceivedEventHandler) {
-	g.mu.Lock()
-	g.onReceive = value
-	g.mu.Unlock()
+	for _, it := range g.setOnReceived(value) {
+		g.callHandler(true, "Received", func() {
+			value(g, *gxcommon.NewReceiveEventArgs(it, g.Port))
+		})
+	}
 }
 
 // SetOnError implements IGXMedia
@@ -409,117 +810,730 @@ func (g *GXSerial) SetOnTrace(value gxcommon.TraceEventHandler) {
 	g.mu.Unlock()
 }
 
+// SetOnTraceData sets the handler that receives the direction, timestamp, byte count and
+// raw payload of sent and received data separately from the formatted trace text.
+func (g *GXSerial) SetOnTraceData(value TraceDataEventHandler) {
+	g.mu.Lock()
+	g.onTraceData = value
+	g.mu.Unlock()
+}
+
+// SetTraceRedactor sets the hook that can mask sensitive bytes, e.g. keys and passwords, before
+// sent and received data is traced. Nil removes the hook.
+func (g *GXSerial) SetTraceRedactor(value TraceRedactor) {
+	g.mu.Lock()
+	g.redactor = value
+	g.mu.Unlock()
+}
+
 // Open implements IGXMedia
 func (g *GXSerial) Open() error {
+	g.lifecycleMu.Lock()
+	defer g.lifecycleMu.Unlock()
+	g.closedByUser = false
+	return g.open()
+}
+
+// open opens the serial port. The caller holds lifecycleMu.
+func (g *GXSerial) open() error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	if g.s.isOpen() {
 		return nil
 	}
-	select {
-	case <-g.stop:
-		// Recreate stop channel when reopening after Close.
-		g.stop = make(chan struct{})
-	default:
-	}
+	// Recreate stop channel when reopening after Close.
+	g.unfenceHandlers()
 	g.statef(false, gxcommon.MediaStateOpening)
-	g.trace(false, gxcommon.TraceTypesInfo, g.p.Sprintf("msg.connecting_to", g.Port))
+	g.trace(false, gxcommon.TraceTypesInfo, localize(g.p, MsgConnectingTo, g.Port))
 	err := openPort(g)
+	if err != nil && g.connectTimeout > 0 {
+		deadline := time.Now().Add(g.connectTimeout)
+		for err != nil && time.Now().Before(deadline) {
+			// The settings and the handlers can be used while the next attempt is waited.
+			g.mu.Unlock()
+			time.Sleep(min(connectRetryInterval, time.Until(deadline)))
+			g.mu.Lock()
+			err = openPort(g)
+		}
+	}
+	if err == nil && g.nineBitReceive {
+		if err = g.s.setNineBitReceive(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.parityErrorCharOn {
+		if err = g.applyParityErrorChar(g.parityErrorChar, true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.onBreak != nil {
+		if err = g.applyBreakDetection(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.rs485 != nil {
+		if err = g.applyRS485(g.rs485); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.lowLatency {
+		if err = g.applyLowLatency(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.loopback {
+		if err = g.applyLoopback(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.discardNull {
+		if err = g.applyDiscardNull(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.abortOnError {
+		if err = g.applyAbortOnError(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.rtsEnable != nil {
+		if err = g.setControlLine(ControlLineRts, *g.rtsEnable); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.dtrEnable != nil {
+		if err = g.setControlLine(ControlLineDtr, *g.dtrEnable); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.rtsToggle != nil {
+		if err = g.applyRtsToggle(true); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
+	if err == nil && g.direction != nil {
+		if err = g.direction.Set(false); err != nil {
+			g.s.logError("close failed", g.s.close())
+		}
+	}
 	if err != nil {
-		g.trace(false, gxcommon.TraceTypesError, g.p.Sprintf("msg.connect_failed", g.Port, err))
-		g.errorf(false, err)
+		g.trace(false, gxcommon.TraceTypesError, localize(g.p, MsgConnectFailed, g.Port, err))
+		g.errorf(false, "open", err)
 		return err
 	}
+	g.noiseIdle = true
+	g.echo.reset()
+	g.parityPending = nil
+	g.breakNull = false
+	g.closing.Store(false)
 	g.wg.Add(1)
 	go g.reader()
-	g.trace(false, gxcommon.TraceTypesInfo, g.p.Sprintf("msg.connected_to", g.Port))
+	g.startWatch()
+	g.rings.Store(0)
+	g.startRingWatch()
+	g.startPinWatch()
+	g.lastReceived.Store(time.Now().UnixNano())
+	g.startIdleWatch()
+	g.startWatchdog()
+	g.meter.reset(0)
+	g.trace(false, gxcommon.TraceTypesInfo, localize(g.p, MsgConnectedTo, g.Port))
+	if g.onTrace != nil && !(int(g.traceLevel) < int(gxcommon.TraceTypesInfo)) {
+		if state, err := g.s.dumpState(); err == nil {
+			g.trace(false, gxcommon.TraceTypesInfo, state.String())
+		}
+	}
 	g.statef(false, gxcommon.MediaStateOpen)
 	return nil
 }
 
+// connectRetryInterval is the interval between the open attempts when the connect timeout is used.
+const connectRetryInterval = 100 * time.Millisecond
+
+// ConnectTimeout returns the maximum time Open waits for the serial port to become available.
+func (g *GXSerial) ConnectTimeout() time.Duration {
+	return g.connectTimeout
+}
+
+// SetConnectTimeout sets the maximum time Open waits for the serial port to become available.
+// Open retries until the port is opened or the timeout elapses.
+// This is useful with USB adapters that appear after the device is reset. Zero opens the port only once.
+func (g *GXSerial) SetConnectTimeout(value time.Duration) error {
+	if value < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.connectTimeout = value
+	return nil
+}
+
+// WriteTimeout returns the maximum time to write the data. Zero if the write time is not limited.
+func (g *GXSerial) WriteTimeout() time.Duration {
+	return g.writeTimeout
+}
+
+// SetWriteTimeout sets the maximum time to write the data.
+// If the data is not written in time, Send returns an error that wraps os.ErrDeadlineExceeded.
+// The same time limits the wait of SendStream for the output queue to empty.
+// Zero disables the timeout.
+func (g *GXSerial) SetWriteTimeout(value time.Duration) error {
+	if value < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.writeTimeout = value
+	return nil
+}
+
+// OpenDiagnostics returns true if open diagnostics are enabled.
+func (g *GXSerial) OpenDiagnostics() bool {
+	return g.openDiagnostics
+}
+
+// SetOpenDiagnostics enables or disables open diagnostics.
+// When enabled, every configuration step of Open is traced with the requested and applied settings,
+// flush results and control line states. If a step fails, Open returns *OpenError naming the failed
+// system call and the error number.
+func (g *GXSerial) SetOpenDiagnostics(value bool) {
+	g.openDiagnostics = value
+}
+
+// openStep traces a successful open step when open diagnostics are enabled.
+func (g *GXSerial) openStep(op string, format string, a ...any) {
+	if g.openDiagnostics {
+		g.trace(false, gxcommon.TraceTypesInfo, op+": "+fmt.Sprintf(format, a...))
+	}
+}
+
+// openFailed returns the error of a failed open step.
+// When open diagnostics are enabled the failure is traced and *OpenError is returned. Otherwise err is returned.
+func (g *GXSerial) openFailed(op string, cause error, err error) error {
+	if !g.openDiagnostics {
+		return err
+	}
+	ret := &OpenError{Op: op, Err: cause}
+	g.trace(false, gxcommon.TraceTypesError, ret.Error())
+	return ret
+}
+
 // Send implements IGXMedia
+// If data is an io.Reader, it's read and sent in chunks until EOF, so large payloads
+// don't need to be in memory. Checksum is not appended to the data of a reader.
 func (g *GXSerial) Send(data any, receiver string) error {
-	tmp, err := gxcommon.ToBytes(data, binary.BigEndian)
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	defer g.sendLock.release()
+	if r, ok := data.(io.Reader); ok {
+		err := g.sendReader(r)
+		if err != nil {
+			g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: "send", Err: err})
+		}
+		return err
+	}
+	var tmp []byte
+	var err error
+	s, isString := data.(string)
+	if isString && g.TextCodec() != nil {
+		tmp, err = g.TextCodec().Encode(s)
+	} else {
+		tmp, err = gxcommon.ToBytes(data, binary.BigEndian)
+	}
 	if err != nil {
 		return err
 	}
-	g.bytesSent += uint64(len(tmp))
-	//Trace data.
-	str, err := gxcommon.ToString(data)
+	if t := g.LineTermination(); isString && t != LineTerminationNone {
+		tmp = append(tmp, t...)
+		data = tmp
+	}
+	if c := g.Checksum(); c != nil {
+		tmp = append(tmp, c.Sum(tmp)...)
+		data = tmp
+	}
+	return g.sendFrame(tmp, data)
+}
+
+// SendFramed sends the payload as a complete frame of the configured framing.
+// The checksum is appended to the payload, the payload is escaped if the EOP requires it
+// and the EOP is appended, so the frame is built the same way as the received frames are stripped.
+// The payload is converted to bytes as in Send. The EOP that finds the end of the frame
+// with a function can't build frames and an error is returned.
+func (g *GXSerial) SendFramed(payload any) error {
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	defer g.sendLock.release()
+	var tmp []byte
+	var err error
+	if s, ok := payload.(string); ok && g.TextCodec() != nil {
+		tmp, err = g.TextCodec().Encode(s)
+	} else {
+		tmp, err = gxcommon.ToBytes(payload, binary.BigEndian)
+	}
 	if err != nil {
 		return err
 	}
-	g.tracef(true, gxcommon.TraceTypesSent, "TX: %s", str)
-	_, ret := g.s.write(tmp)
+	g.mu.RLock()
+	eop := g.eop
+	c := g.checksum
+	g.mu.RUnlock()
+	if tmp, err = g.encodeFrame(eop, c, tmp); err != nil {
+		return err
+	}
+	return g.sendFrame(tmp, tmp)
+}
+
+// sendFrame writes the frame to the serial port. data is the sent value that is traced.
+func (g *GXSerial) sendFrame(tmp []byte, data any) error {
+	g.bytesSent += uint64(len(tmp))
+	//Trace data.
+	g.traceData(gxcommon.TraceTypesSent, tmp, data)
+	g.record(FlightEvent{Type: FlightEventSent, Data: tmp})
+	_, ret := g.write(tmp)
+	if ret != nil {
+		g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: "send", Err: ret})
+	} else {
+		g.meter.add(0, 0, 0, 1)
+	}
 	return ret
 }
 
-// Receive implements IGXMedia
-func (g *GXSerial) Receive(args *gxcommon.ReceiveParameters) (bool, error) {
-	if args.EOP == nil && args.Count == 0 && !args.AllData {
-		return false, errors.New(g.p.Sprintf("msg.count_or_eop"))
+// TxRate returns the maximum amount of sent bytes per second. Zero if the rate is not limited.
+func (g *GXSerial) TxRate() int {
+	return g.txRate
+}
+
+// SetTxRate sets the maximum amount of sent bytes per second.
+// Use it with devices that have small buffers and no flow control. Zero disables the limit.
+func (g *GXSerial) SetTxRate(bytesPerSecond int) error {
+	if bytesPerSecond < 0 {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.txRate = bytesPerSecond
+	return nil
+}
+
+// ReadOnly returns true if the serial port is opened without write access.
+func (g *GXSerial) ReadOnly() bool {
+	return g.readOnly
+}
+
+// SetReadOnly sets if the serial port is opened without write access.
+// In read-only mode the media is a passive tap that can monitor a link between two other devices.
+// Sending data and changing the control lines fail, and the control lines are not changed when
+// the port is opened or closed. The value is used when the port is opened next time.
+func (g *GXSerial) SetReadOnly(value bool) {
+	g.readOnly = value
+}
+
+// checkWritable returns an error if the serial port is opened in read-only mode.
+func (g *GXSerial) checkWritable() error {
+	if g.readOnly {
+		return errors.New(localize(g.p, MsgReadOnly, g.Port))
+	}
+	return nil
+}
+
+// write writes data to the serial port honoring the TX rate limit and the write timeout.
+func (g *GXSerial) write(data []byte) (int, error) {
+	if err := g.checkWritable(); err != nil {
+		return 0, err
 	}
-	terminator, err := gxcommon.ToBytes(args.EOP, binary.BigEndian)
+	if g.writeTimeout > 0 {
+		if err := g.s.setWriteDeadline(time.Now().Add(g.writeTimeout)); err != nil {
+			return 0, err
+		}
+		defer g.s.setWriteDeadline(time.Time{})
+	}
+	done, err := g.beginTransmit()
 	if err != nil {
-		return false, err
+		return 0, err
+	}
+	defer done()
+	defer g.expectEcho(data)()
+	rate := g.txRate
+	if rate <= 0 {
+		n, err := g.s.write(data)
+		g.txWritten(n)
+		return n, err
+	}
+	//Data is written in 10 ms slices.
+	size := rate / 100
+	if size < 1 {
+		size = 1
+	}
+	start := time.Now()
+	total := 0
+	for total < len(data) {
+		end := total + size
+		if end > len(data) {
+			end = len(data)
+		}
+		n, err := g.s.write(data[total:end])
+		total += n
+		g.txWritten(n)
+		if err != nil {
+			return total, err
+		}
+		due := start.Add(time.Duration(int64(total) * int64(time.Second) / int64(rate)))
+		if d := time.Until(due); d > 0 {
+			time.Sleep(d)
+		}
+	}
+	return total, nil
+}
+
+// SendStream sends the data read from r to the serial port in chunks of chunkSize bytes.
+// The chunks are paced so that the next chunk is written only after the driver output queue is empty.
+// progress is called after each chunk with the amount of sent bytes and the total size. Total is -1 when the size is unknown.
+// Sending is cancelled if the media is closed. If the write timeout is set, each chunk must be sent
+// within it or an error that wraps os.ErrDeadlineExceeded is returned.
+func (g *GXSerial) SendStream(r io.Reader, chunkSize int, progress func(sent, total int64)) error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	defer g.sendLock.release()
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	total := streamSize(r)
+	buf := make([]byte, chunkSize)
+	var sent int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if !g.s.isOpen() {
+				return errors.New(localize(g.p, MsgSendCancelled, g.Port))
+			}
+			if err := g.sendChunk(buf[:n]); err != nil {
+				return err
+			}
+			sent += int64(n)
+			if progress != nil {
+				progress(sent, total)
+			}
+			if err := g.waitOutputEmpty(); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sendReader sends the data read from r in chunks until EOF.
+func (g *GXSerial) sendReader(r io.Reader) error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	buf := make([]byte, defaultChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := g.sendChunk(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// defaultChunkSize is the chunk size used when streaming data.
+const defaultChunkSize = 256
+
+// streamSize returns the size of the stream or -1 if it's unknown.
+func streamSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case io.Seeker:
+		cur, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err = v.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - cur
+	}
+	return -1
+}
+
+// sendChunk writes a part of a bigger transfer to the serial port.
+func (g *GXSerial) sendChunk(data []byte) error {
+	g.bytesSent += uint64(len(data))
+	g.traceData(gxcommon.TraceTypesSent, data, nil)
+	g.record(FlightEvent{Type: FlightEventSent, Data: data})
+	_, err := g.write(data)
+	return err
+}
+
+// waitOutputEmpty waits until the driver output queue is empty.
+// If the write timeout is set and the queue isn't empty in time, e.g. because the flow control holds
+// the transmission, an error that wraps os.ErrDeadlineExceeded is returned.
+func (g *GXSerial) waitOutputEmpty() error {
+	var deadline time.Time
+	if g.writeTimeout > 0 {
+		deadline = time.Now().Add(g.writeTimeout)
+	}
+	for {
+		if !g.s.isOpen() || g.closing.Load() {
+			return errors.New(localize(g.p, MsgSendCancelled, g.Port))
+		}
+		n, err := g.s.getBytesToWrite()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("%s: %w", localize(g.p, MsgOutputDrainTimeout, g.Port, g.writeTimeout), os.ErrDeadlineExceeded)
+		}
+		time.Sleep(time.Millisecond)
 	}
+}
 
+// Receive implements IGXMedia
+func (g *GXSerial) Receive(args *gxcommon.ReceiveParameters) (bool, error) {
 	var waitTime time.Duration
 	if args.WaitTime <= 0 {
 		waitTime = 0
 	} else {
 		waitTime = time.Duration(args.WaitTime) * time.Millisecond
 	}
-	index := g.received.Search(terminator, args.Count, waitTime)
+	return g.ReceiveWait(args, waitTime)
+}
+
+// ReceiveWait works like Receive, but the wait time is given as time.Duration.
+// args.WaitTime is ignored.
+func (g *GXSerial) ReceiveWait(args *gxcommon.ReceiveParameters, waitTime time.Duration) (bool, error) {
+	if args.EOP == nil && args.Count == 0 && !args.AllData {
+		return false, errors.New(localize(g.p, MsgCountOrEop))
+	}
+	if waitTime < 0 {
+		waitTime = 0
+	}
+	patterns, _ := args.EOP.(*Patterns)
+	if patterns != nil {
+		patterns.matched.Store(-1)
+	}
+	var index int
+	if m, ok := predicate(args.EOP); ok {
+		index = g.received.SearchFunc(m, args.Count, waitTime)
+	} else {
+		terminator, err := gxcommon.ToBytes(args.EOP, binary.BigEndian)
+		if err != nil {
+			return false, err
+		}
+		index = g.received.Search(terminator, args.Count, waitTime)
+	}
 	if index == -1 {
 		return false, nil
 	}
+	if index == searchOverflow {
+		return false, g.bufferOverflow()
+	}
 
 	if args.AllData {
 		//Read all data.
 		index = -1
+	} else if args.EOP == nil {
+		//Without the EOP the reply is Count bytes.
+		index = args.Count
+	}
+	reply := g.received.Get(index)
+	if patterns != nil {
+		patterns.matched.Store(int32(patterns.Index(reply)))
+	}
+	if args.EOP != nil {
+		var err error
+		if reply, err = g.completeFrame(args.EOP, reply); err != nil {
+			return false, err
+		}
 	}
-	args.Reply, err = gxcommon.BytesToAny2(g.received.Get(index), args.ReplyType, binary.ByteOrder(binary.BigEndian))
+	var err error
+	args.Reply, err = gxcommon.BytesToAny2(reply, args.ReplyType, binary.ByteOrder(binary.BigEndian))
 	if err != nil {
 		return false, err
 	}
+	g.meter.add(0, 0, 1, 0)
 	return true, nil
 }
 
+// ReceiveAll gathers the frames that are received within the time window,
+// e.g. the replies of several slaves to a broadcast.
+// Frames are ended as in Receive and the items of the returned slice are the replies of each frame.
+// The receive ends when maxFrames frames are received or the window elapses. Zero maxFrames is unlimited.
+// Frames received before an error are returned with the error.
+func (g *GXSerial) ReceiveAll(args *gxcommon.ReceiveParameters, maxFrames int, window time.Duration) ([]any, error) {
+	if maxFrames < 0 || window < 0 {
+		return nil, gxcommon.ErrInvalidArgument
+	}
+	var ret []any
+	deadline := time.Now().Add(window)
+	for maxFrames == 0 || len(ret) < maxFrames {
+		// Already received frames are returned even if the window has elapsed.
+		ok, err := g.ReceiveWait(args, max(time.Until(deadline), 0))
+		if err != nil {
+			return ret, err
+		}
+		if !ok {
+			break
+		}
+		ret = append(ret, args.Reply)
+	}
+	return ret, nil
+}
+
+// ReceiveTo copies received data to w until the given condition is met.
+// Data is written to w as it arrives and it's not accumulated to the internal buffer.
+// ReceiveTo returns false if no data is received during the wait time before the EOP or count is reached.
+// If neither EOP nor Count is set, the receive ends when the wait time elapses and true is returned.
+func (g *GXSerial) ReceiveTo(w io.Writer, until Condition) (bool, error) {
+	if until.EOP == nil && until.Count == 0 && until.WaitTime <= 0 {
+		return false, errors.New(localize(g.p, MsgCountOrEop))
+	}
+	var terminator []byte
+	if until.EOP != nil {
+		var err error
+		terminator, err = gxcommon.ToBytes(until.EOP, binary.BigEndian)
+		if err != nil {
+			return false, err
+		}
+	}
+	if !g.IsSynchronous() {
+		defer g.GetSynchronous()()
+	}
+	//Last bytes of the previous chunk that can be part of the EOP.
+	var tail []byte
+	written := 0
+	for {
+		switch g.received.Search(nil, 1, until.WaitTime) {
+		case -1:
+			return len(terminator) == 0 && until.Count == 0, nil
+		case searchOverflow:
+			return false, g.bufferOverflow()
+		}
+		data := g.received.Peek()
+		count := len(data)
+		done := false
+		if until.Count != 0 && written+count >= until.Count {
+			count = until.Count - written
+			done = true
+		}
+		if len(terminator) != 0 {
+			tmp := append(tail, data[:count]...)
+			if pos := bytes.Index(tmp, terminator); pos != -1 {
+				count = pos + len(terminator) - len(tail)
+				done = true
+			} else if len(tmp) >= len(terminator) {
+				tail = append([]byte(nil), tmp[len(tmp)-len(terminator)+1:]...)
+			} else {
+				tail = tmp
+			}
+		}
+		if _, err := w.Write(g.received.Get(count)); err != nil {
+			return false, err
+		}
+		written += count
+		if done {
+			return true, nil
+		}
+	}
+}
+
 func (g *GXSerial) handleData(data []byte) {
-	str, err := gxcommon.ToString(data)
-	if err != nil {
-		g.tracef(true, gxcommon.TraceTypesError, "RX failed: %v", err)
-		g.errorf(true, err)
-	} else {
-		g.tracef(true, gxcommon.TraceTypesReceived, "RX: %s", str)
+	data = g.checkBreak(data)
+	if data = g.unmarkErrors(data); len(data) == 0 {
+		return
+	}
+	g.record(FlightEvent{Type: FlightEventReceived, Data: data})
+	g.traceData(gxcommon.TraceTypesReceived, data, nil)
+	if data = g.suppressEcho(data); len(data) == 0 {
+		return
+	}
+	if data = g.filterNoise(data); len(data) == 0 {
+		return
 	}
-	if g.synchronous {
+	if r := g.request.Load(); r != nil {
+		if data = r.add(data); len(data) == 0 {
+			return
+		}
+	}
+	if g.synchronous && !g.framedSynchronous() {
 		g.appendData(data)
-	} else {
-		g.receivef(true, data)
+		return
 	}
+	g.deliver(data, g.synchronous)
+}
+
+// transientErrorDelay is the delay before the read is retried after a transient error.
+const transientErrorDelay = 10 * time.Millisecond
+
+// KeepOpen returns true if the reader continues after transient read errors.
+func (g *GXSerial) KeepOpen() bool {
+	return g.keepOpen
+}
+
+// SetKeepOpen sets if the reader continues after transient read errors.
+// When enabled, errors such as interrupted system calls, would-block results and transient
+// overlapped failures are traced and reading continues. Other errors stop the reader as before.
+func (g *GXSerial) SetKeepOpen(value bool) {
+	g.keepOpen = value
 }
 
 func (g *GXSerial) reader() {
 	defer g.wg.Done()
+	//Amount of consecutive reader restarts.
+	failures := 0
 	for {
 		ret, err := g.s.read()
-		if !g.IsOpen() {
+		if g.closing.Load() || !g.IsOpen() {
 			return
 		}
+		if err != nil && (g.keepOpen && isTransientError(err) || isLineErrorAbort(err) && g.AbortOnError()) {
+			g.trace(true, gxcommon.TraceTypesError, localize(g.p, MsgTransientReadError, g.Port, err))
+			if err := g.s.clearError(); err != nil {
+				g.log(slog.LevelWarn, "clear error failed", "err", err)
+			}
+			time.Sleep(transientErrorDelay)
+			continue
+		}
 		if err != nil {
 			select {
 			case <-g.stop:
 				return
 			default:
-				g.trace(false, gxcommon.TraceTypesError, g.p.Sprintf("msg.connection_failed", err))
-				g.errorf(false, err)
+				g.trace(true, gxcommon.TraceTypesError, localize(g.p, MsgConnectionFailed, err))
+				g.errorf(true, "read", err)
+			}
+			if g.restartReader(&failures) {
+				continue
 			}
 			return
 		}
+		failures = 0
 		if len(ret) != 0 {
+			g.rxTime = g.arrivalTime()
+			g.lastReceived.Store(g.rxTime.UnixNano())
+			g.lastRx.store(g.rxTime)
+			g.unanswered.Store(0)
 			g.bytesReceived += uint64(len(ret))
+			g.meter.add(len(ret), 0, 0, 0)
 			g.handleData(ret)
 		}
 		select {
@@ -540,11 +1554,15 @@ func (g *GXSerial) receivef(lock bool, data []byte) {
 		cb = g.onReceive
 	}
 	if cb != nil {
-		cb(g, *gxcommon.NewReceiveEventArgs(data, g.Port))
+		g.callHandler(lock, "Received", func() {
+			cb(g, *gxcommon.NewReceiveEventArgs(data, g.Port))
+		})
 	}
 }
 
-func (g *GXSerial) errorf(lock bool, err error) {
+func (g *GXSerial) errorf(lock bool, op string, err error) {
+	g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: op, Err: err})
+	g.record(FlightEvent{Type: FlightEventError, Err: err})
 	var cb gxcommon.ErrorEventHandler
 	if lock {
 		g.mu.RLock()
@@ -554,7 +1572,11 @@ func (g *GXSerial) errorf(lock bool, err error) {
 		cb = g.onErr
 	}
 	if cb != nil {
-		cb(g, err)
+		g.callHandler(lock, "Error", func() {
+			cb(g, err)
+		})
+	} else {
+		g.log(slog.LevelWarn, "unhandled error", "op", op, "err", err)
 	}
 }
 
@@ -573,7 +1595,57 @@ func (g *GXSerial) tracef(lock bool, traceType gxcommon.TraceTypes, fmtStr strin
 	if cb != nil && trace {
 		p := gxcommon.NewTraceEventArgs(traceType, fmt.Sprintf(fmtStr, a...), "")
 		var m gxcommon.IGXMedia = g
-		cb(m, *p)
+		g.callHandler(lock, "Trace", func() {
+			cb(m, *p)
+		})
+	}
+}
+
+// traceData emits the trace of sent or received data.
+// value is the sent value that is formatted to the trace. Nil formats the data.
+// The data is formatted only when the trace level passes it and a trace handler is set.
+func (g *GXSerial) traceData(traceType gxcommon.TraceTypes, data []byte, value any) {
+	g.mu.RLock()
+	trace := !(int(g.traceLevel) < int(traceType))
+	cb := g.onTrace
+	dataCb := g.onTraceData
+	mirror := g.mirror
+	redactor := g.redactor
+	codec := g.textCodec
+	g.mu.RUnlock()
+	trace = trace && (cb != nil || dataCb != nil)
+	if !trace && mirror == nil {
+		return
+	}
+	now := time.Now()
+	direction, prefix := TraceDirectionReceived, "RX: "
+	if traceType == gxcommon.TraceTypesSent {
+		direction, prefix = TraceDirectionSent, "TX: "
+	}
+	if redactor != nil {
+		data = redactor(direction, append([]byte(nil), data...))
+		// The redacted data is traced instead of the value.
+		value = nil
+	}
+	g.mirrorData(now, prefix, data)
+	if !trace {
+		return
+	}
+	var str string
+	if codec != nil {
+		str = codec.Decode(data)
+	} else if value != nil {
+		str, _ = gxcommon.ToString(value)
+	} else {
+		str, _ = gxcommon.ToString(data)
+	}
+	text := prefix + str
+	g.trace(true, traceType, text)
+	if dataCb != nil {
+		g.callHandler(true, "TraceData", func() {
+			dataCb(g, TraceDataEventArgs{Type: traceType, Direction: direction, Time: now,
+				Count: len(data), Data: data, Text: text})
+		})
 	}
 }
 
@@ -592,11 +1664,14 @@ func (g *GXSerial) trace(lock bool, traceType gxcommon.TraceTypes, message strin
 	if cb != nil && trace {
 		p := gxcommon.NewTraceEventArgs(traceType, message, "")
 		var m gxcommon.IGXMedia = g
-		cb(m, *p)
+		g.callHandler(lock, "Trace", func() {
+			cb(m, *p)
+		})
 	}
 }
 
 func (g *GXSerial) statef(lock bool, state gxcommon.MediaState) {
+	g.record(FlightEvent{Type: FlightEventState, State: state})
 	var cb gxcommon.MediaStateHandler
 	if lock {
 		g.mu.RLock()
@@ -606,7 +1681,10 @@ func (g *GXSerial) statef(lock bool, state gxcommon.MediaState) {
 		cb = g.onState
 	}
 	if cb != nil {
-		cb(g, *gxcommon.NewMediaStateEventArgs(state))
+		// The state is notified also when the port is closed.
+		g.invokeHandler(lock, "MediaState", func() {
+			cb(g, *gxcommon.NewMediaStateEventArgs(state))
+		})
 	}
 }
 
@@ -621,41 +1699,61 @@ func (g *GXSerial) appendData(data []byte) {
 }
 
 // Close implements IGXMedia
+// When Close returns, the reader has stopped and the Received, Trace, Error and other event
+// handlers are not called anymore until the port is opened again. Close waits until the
+// handlers in progress return, so it must not be called from an event handler of the media.
 func (g *GXSerial) Close() error {
+	g.lifecycleMu.Lock()
+	g.closedByUser = true
+	g.lifecycleMu.Unlock()
+	g.stopWatch()
+	return g.closePort()
+}
+
+// closePort closes the serial port. Device notifications are not stopped.
+func (g *GXSerial) closePort() error {
 	var err error
+	g.lifecycleMu.Lock()
+	defer g.lifecycleMu.Unlock()
 	g.mu.Lock()
-	defer g.mu.Unlock()
 	select {
 	case <-g.stop:
 		// already closed
+		g.mu.Unlock()
+		return err
 	default:
-		if g.s.isOpen() {
-			g.trace(false, gxcommon.TraceTypesInfo, g.p.Sprintf("msg.closing_connection", g.Port))
-			g.statef(false, gxcommon.MediaStateClosing)
-		}
-		_ = g.s.close()
-		g.trace(false, gxcommon.TraceTypesInfo, g.p.Sprintf("msg.connection_closed", g.Port))
-		g.statef(false, gxcommon.MediaStateClosed)
 	}
+	if g.s.isOpen() {
+		g.trace(false, gxcommon.TraceTypesInfo, localize(g.p, MsgClosingConnection, g.Port))
+		g.statef(false, gxcommon.MediaStateClosing)
+	}
+	g.stopIdleWatch()
+	g.stopWatchdog()
+	// The reader is stopped before the port is closed.
+	g.closing.Store(true)
+	g.s.interrupt()
+	g.mu.Unlock()
+	// The reader can be waiting for a frame consumer.
+	g.endFrameSubscriptions()
+	// The lock is not held so that the reader can read the settings.
 	g.wg.Wait()
-	return err
-}
 
-//nolint:errcheck
-func init() {
-	// --- English (default) ---
-	message.SetString(language.AmericanEnglish, "msg.closing_connection", "Closing serial port '%s' connection")
-	message.SetString(language.AmericanEnglish, "msg.connection_closed", "Serial port connection '%s' closed")
-	message.SetString(language.AmericanEnglish, "msg.connection_failed", "Serial port connection failed: %v")
-	message.SetString(language.AmericanEnglish, "msg.count_or_eop", "Either Count or EOP must be set")
-	message.SetString(language.AmericanEnglish, "msg.connected_to", "Connected to serial port '%s'")
-	message.SetString(language.AmericanEnglish, "msg.connect_failed", "Connect to serial port '%s' failed: %v")
-	message.SetString(language.AmericanEnglish, "msg.connecting_to", "%s connecting to %s: timeout %d ms")
-	message.SetString(language.AmericanEnglish, "msg.no_serial_port_selected", "No serial port selected. Please select a serial port.")
+	g.mu.Lock()
+	if err := g.s.close(); err != nil {
+		g.log(slog.LevelError, "close failed", "err", err)
+	}
+	g.cancelRequests(errors.New(localize(g.p, MsgPortNotOpen, g.Port)))
+	g.trace(false, gxcommon.TraceTypesInfo, localize(g.p, MsgConnectionClosed, g.Port))
+	g.statef(false, gxcommon.MediaStateClosed)
+	g.fenceHandlers()
+	g.mu.Unlock()
+	// The lock is not held so that the handlers can read the settings.
+	g.handlers.Wait()
+	return err
 }
 
 // Localize messages for the specified language.
 // No errors is returned if language is not supported.
 func (g *GXSerial) Localize(language language.Tag) {
-	g.p = message.NewPrinter(language)
+	g.p = newPrinter(language)
 }
@@ -0,0 +1,481 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// settingsElement is an element of the settings XML.
+type settingsElement struct {
+	Value string     `xml:",chardata"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// attr returns the value of the attribute. Empty if the attribute is not set.
+func (e *settingsElement) attr(name string) string {
+	for _, it := range e.Attrs {
+		if it.Name.Local == name {
+			return it.Value
+		}
+	}
+	return ""
+}
+
+// eopSetting returns the settings element of the EOP.
+// Empty is returned if the EOP is not set or it can't be saved, e.g. a predicate.
+func eopSetting(eop any) string {
+	if eop == nil {
+		return ""
+	}
+	if _, ok := predicate(eop); ok {
+		return ""
+	}
+	switch v := eop.(type) {
+	case byte:
+		return fmt.Sprintf("<Eop Type=\"Byte\">%02X</Eop>\n", v)
+	case string:
+		return fmt.Sprintf("<Eop Type=\"String\">%s</Eop>\n", xmlEscape(v))
+	}
+	data, err := gxcommon.ToBytes(eop, binary.BigEndian)
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("<Eop Type=\"Bytes\">%X</Eop>\n", data)
+}
+
+// parseEop parses the EOP of the settings element.
+func (g *GXSerial) parseEop(typ string, value string) (any, error) {
+	switch typ {
+	case "Byte":
+		v, err := strconv.ParseUint(value, 16, 8)
+		if err != nil {
+			return nil, g.invalidSetting("Eop", value)
+		}
+		return byte(v), nil
+	case "String":
+		return value, nil
+	case "Bytes":
+		v, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, g.invalidSetting("Eop", value)
+		}
+		return v, nil
+	}
+	return nil, g.invalidSetting("Eop Type", typ)
+}
+
+// parseMilliseconds parses the timeout of the settings element.
+func (g *GXSerial) parseMilliseconds(name string, value string) (time.Duration, error) {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || v < 0 {
+		return 0, g.invalidSetting(name, value)
+	}
+	return time.Duration(v) * time.Millisecond, nil
+}
+
+// boolToInt returns 1 for true and 0 for false.
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// parseBoolAttr parses the boolean attribute. A missing attribute is false.
+func (g *GXSerial) parseBoolAttr(name string, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	ret, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, g.invalidSetting(name, value)
+	}
+	return ret, nil
+}
+
+// invalidSetting returns the error of an invalid value of the settings element.
+func (g *GXSerial) invalidSetting(name string, value string) error {
+	return errors.New(localize(g.p, MsgInvalidSetting, name, value))
+}
+
+// StrictSettings returns true if SetSettings rejects unknown elements.
+func (g *GXSerial) StrictSettings() bool {
+	return g.strictSettings
+}
+
+// SetStrictSettings sets whether SetSettings rejects unknown elements.
+// By default unknown elements are ignored so that settings of newer versions can be read.
+func (g *GXSerial) SetStrictSettings(value bool) {
+	g.strictSettings = value
+}
+
+// appendOptionSettings appends the optional media settings to the settings XML.
+func (g *GXSerial) appendOptionSettings(b *strings.Builder) {
+	if g.handshake != HandshakeNone {
+		fmt.Fprintf(b, "<Handshake>%s</Handshake>\n", g.handshake)
+	}
+	if g.readOnly {
+		b.WriteString("<ReadOnly>1</ReadOnly>\n")
+	}
+	if g.keepOpen {
+		b.WriteString("<KeepOpen>1</KeepOpen>\n")
+	}
+	if g.nineBitReceive {
+		b.WriteString("<NineBitReceive>1</NineBitReceive>\n")
+	}
+	if g.lineTermination != LineTerminationNone {
+		fmt.Fprintf(b, "<LineTermination>%X</LineTermination>\n", []byte(g.lineTermination))
+	}
+	if g.parityErrorCharOn {
+		fmt.Fprintf(b, "<ParityErrorChar>%X</ParityErrorChar>\n", []byte{g.parityErrorChar})
+	}
+	if g.openDiagnostics {
+		b.WriteString("<OpenDiagnostics>1</OpenDiagnostics>\n")
+	}
+	if g.txRate != 0 {
+		fmt.Fprintf(b, "<TxRate>%d</TxRate>\n", g.txRate)
+	}
+	if limit := g.received.Limit(); limit != 0 {
+		fmt.Fprintf(b, "<ReceiveBufferLimit>%d</ReceiveBufferLimit>\n", limit)
+	}
+	if limit := g.retained.limit.Load(); limit != defaultRetainLimit {
+		fmt.Fprintf(b, "<RetainLimit>%d</RetainLimit>\n", limit)
+	}
+	if g.resetLine != ControlLineDtr {
+		fmt.Fprintf(b, "<ResetLine>%s</ResetLine>\n", g.resetLine)
+	}
+	if g.hotplug != HotplugNone {
+		fmt.Fprintf(b, "<Hotplug>%s</Hotplug>\n", g.hotplug)
+	}
+	if p := g.rs485; p != nil {
+		fmt.Fprintf(b, "<RS485 RtsOnSend=\"%d\" RtsAfterSend=\"%d\" DelayBeforeSend=\"%d\" DelayAfterSend=\"%d\" RxDuringTx=\"%d\">%d</RS485>\n",
+			boolToInt(p.RtsOnSend), boolToInt(p.RtsAfterSend), p.DelayBeforeSend.Milliseconds(),
+			p.DelayAfterSend.Milliseconds(), boolToInt(p.RxDuringTx), boolToInt(p.Enabled))
+	}
+	if g.lowLatency {
+		b.WriteString("<LowLatency>1</LowLatency>\n")
+	}
+	if g.loopback {
+		b.WriteString("<Loopback>1</Loopback>\n")
+	}
+	if g.echoSuppression {
+		b.WriteString("<EchoSuppression>1</EchoSuppression>\n")
+	}
+	if g.discardNull {
+		b.WriteString("<DiscardNull>1</DiscardNull>\n")
+	}
+	if g.abortOnError {
+		b.WriteString("<AbortOnError>1</AbortOnError>\n")
+	}
+	if g.rtsEnable != nil {
+		fmt.Fprintf(b, "<RtsEnable>%d</RtsEnable>\n", boolToInt(*g.rtsEnable))
+	}
+	if g.dtrEnable != nil {
+		fmt.Fprintf(b, "<DtrEnable>%d</DtrEnable>\n", boolToInt(*g.dtrEnable))
+	}
+	if p := g.rtsToggle; p != nil {
+		fmt.Fprintf(b, "<RtsToggle DelayBeforeSend=\"%d\" DelayAfterSend=\"%d\" />\n",
+			p.DelayBeforeSend.Milliseconds(), p.DelayAfterSend.Milliseconds())
+	}
+	if p := g.readerRestart.Load(); p != nil {
+		fmt.Fprintf(b, "<ReaderRestart Attempts=\"%d\" Backoff=\"%d\" MaxBackoff=\"%d\" />\n",
+			p.Attempts, p.Backoff.Milliseconds(), p.MaxBackoff.Milliseconds())
+	}
+	if p := g.ackPolicy; p != nil {
+		fmt.Fprintf(b, "<AckPolicy Ack=\"%X\" Nak=\"%X\" Timeout=\"%d\" Retries=\"%d\" />\n",
+			p.Ack, p.Nak, p.Timeout.Milliseconds(), p.Retries)
+	}
+	if f := g.addressFilter; f != nil && f.Extract == nil {
+		broadcast := make([]string, 0, len(f.Broadcast))
+		for _, it := range f.Broadcast {
+			broadcast = append(broadcast, fmt.Sprintf("%X", it))
+		}
+		fmt.Fprintf(b, "<AddressFilter Address=\"%X\" Offset=\"%d\" Broadcast=\"%s\" />\n",
+			f.Address, f.Offset, strings.Join(broadcast, ","))
+	}
+	if f := g.noiseFilter; f != nil {
+		fmt.Fprintf(b, "<NoiseFilter>%X</NoiseFilter>\n", f.Bytes)
+	}
+	if c, ok := g.checksum.(checksum); ok {
+		fmt.Fprintf(b, "<Checksum>%s</Checksum>\n", c.name)
+	}
+	if c, ok := g.textCodec.(textCodec); ok {
+		fmt.Fprintf(b, "<TextCodec>%s</TextCodec>\n", c.name)
+	}
+}
+
+// setSetting sets the value of the settings element.
+func (g *GXSerial) setSetting(name string, e *settingsElement) error {
+	var err error
+	v := e.Value
+	switch name {
+	case "Port":
+		g.Port = v
+	case "Bps":
+		g.baudRate, err = parseBaudRate(v)
+	case "ByteSize":
+		if g.dataBits, err = strconv.Atoi(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+	case "StopBits":
+		if n, e := strconv.Atoi(v); e == nil {
+			// Older settings saved the stop bits as a number.
+			g.stopBits = gxcommon.StopBits(n)
+		} else {
+			g.stopBits, err = gxcommon.StopBitsParse(v)
+		}
+	case "Parity":
+		if n, e := strconv.Atoi(v); e == nil {
+			// Older settings saved the parity as a number.
+			g.parity = gxcommon.Parity(n)
+		} else {
+			g.parity, err = gxcommon.ParityParse(v)
+		}
+	case "Eop":
+		typ := e.attr("Type")
+		if typ == "" {
+			typ = "Bytes"
+		}
+		var eop any
+		if eop, err = g.parseEop(typ, v); err == nil {
+			g.SetEop(eop)
+		}
+	case "Trace":
+		g.traceLevel, err = gxcommon.TraceLevelParse(v)
+	case "ConnectTimeout":
+		g.connectTimeout, err = g.parseMilliseconds(name, v)
+	case "WriteTimeout":
+		g.writeTimeout, err = g.parseMilliseconds(name, v)
+	case "SendQueueTimeout":
+		g.sendQueueTimeout, err = g.parseMilliseconds(name, v)
+	case "ReadOnly", "KeepOpen", "NineBitReceive", "LowLatency", "Loopback", "RtsEnable", "DtrEnable", "EchoSuppression",
+		"DiscardNull", "AbortOnError", "OpenDiagnostics":
+		var on bool
+		if on, err = strconv.ParseBool(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		switch name {
+		case "ReadOnly":
+			g.SetReadOnly(on)
+		case "KeepOpen":
+			g.SetKeepOpen(on)
+		case "NineBitReceive":
+			err = g.SetNineBitReceive(on)
+		case "LowLatency":
+			err = g.SetLowLatency(on)
+		case "Loopback":
+			err = g.EnableLoopback(on)
+		case "RtsEnable":
+			err = g.SetRtsEnable(on)
+		case "DtrEnable":
+			err = g.SetDtrEnable(on)
+		case "EchoSuppression":
+			g.SetEchoSuppression(on)
+		case "DiscardNull":
+			err = g.SetDiscardNull(on)
+		case "AbortOnError":
+			err = g.SetAbortOnError(on)
+		default:
+			g.SetOpenDiagnostics(on)
+		}
+	case "TxRate":
+		var n int
+		if n, err = strconv.Atoi(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		err = g.SetTxRate(n)
+	case "ReceiveBufferLimit":
+		var n int
+		if n, err = strconv.Atoi(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		err = g.SetReceiveBufferLimit(n)
+	case "LineTermination":
+		var tmp []byte
+		if tmp, err = hex.DecodeString(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		g.SetLineTermination(LineTermination(tmp))
+	case "ParityErrorChar":
+		var tmp []byte
+		if tmp, err = hex.DecodeString(v); err != nil || len(tmp) != 1 {
+			return g.invalidSetting(name, v)
+		}
+		err = g.SetParityErrorChar(tmp[0], true)
+	case "RetainLimit":
+		var n int
+		if n, err = strconv.Atoi(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		err = g.SetRetainLimit(n)
+	case "ResetLine":
+		switch strings.ToUpper(v) {
+		case "DTR":
+			g.SetResetLine(ControlLineDtr)
+		case "RTS":
+			g.SetResetLine(ControlLineRts)
+		default:
+			return g.invalidSetting(name, v)
+		}
+	case "Handshake":
+		err = g.invalidSetting(name, v)
+		for _, it := range []Handshake{HandshakeNone, HandshakeXOnXOff, HandshakeRequestToSend, HandshakeRequestToSendXOnXOff} {
+			if strings.EqualFold(it.String(), v) {
+				err = g.SetHandshake(it)
+			}
+		}
+	case "Hotplug":
+		err = g.invalidSetting(name, v)
+		for _, it := range []HotplugMode{HotplugNone, HotplugDetect, HotplugReconnect} {
+			if strings.EqualFold(it.String(), v) {
+				err = g.SetHotplug(it)
+			}
+		}
+	case "RS485":
+		p := &RS485{}
+		if p.Enabled, err = strconv.ParseBool(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		if p.RtsOnSend, err = g.parseBoolAttr("RtsOnSend", e.attr("RtsOnSend")); err != nil {
+			return err
+		}
+		if p.RtsAfterSend, err = g.parseBoolAttr("RtsAfterSend", e.attr("RtsAfterSend")); err != nil {
+			return err
+		}
+		if p.DelayBeforeSend, err = g.parseMilliseconds("DelayBeforeSend", e.attr("DelayBeforeSend")); err != nil {
+			return err
+		}
+		if p.DelayAfterSend, err = g.parseMilliseconds("DelayAfterSend", e.attr("DelayAfterSend")); err != nil {
+			return err
+		}
+		if p.RxDuringTx, err = g.parseBoolAttr("RxDuringTx", e.attr("RxDuringTx")); err != nil {
+			return err
+		}
+		err = g.SetRS485(p)
+	case "RtsToggle":
+		p := &RtsToggle{}
+		if p.DelayBeforeSend, err = g.parseMilliseconds("DelayBeforeSend", e.attr("DelayBeforeSend")); err != nil {
+			return err
+		}
+		if p.DelayAfterSend, err = g.parseMilliseconds("DelayAfterSend", e.attr("DelayAfterSend")); err != nil {
+			return err
+		}
+		err = g.SetRtsToggle(p)
+	case "ReaderRestart":
+		p := &RestartPolicy{}
+		if p.Attempts, err = strconv.Atoi(e.attr("Attempts")); err != nil {
+			return g.invalidSetting("Attempts", e.attr("Attempts"))
+		}
+		if p.Backoff, err = g.parseMilliseconds("Backoff", e.attr("Backoff")); err != nil {
+			return err
+		}
+		if p.MaxBackoff, err = g.parseMilliseconds("MaxBackoff", e.attr("MaxBackoff")); err != nil {
+			return err
+		}
+		err = g.SetReaderRestart(p)
+	case "AckPolicy":
+		p := &AckPolicy{}
+		if p.Ack, err = hex.DecodeString(e.attr("Ack")); err != nil {
+			return g.invalidSetting("Ack", e.attr("Ack"))
+		}
+		if p.Nak, err = hex.DecodeString(e.attr("Nak")); err != nil {
+			return g.invalidSetting("Nak", e.attr("Nak"))
+		}
+		if len(p.Nak) == 0 {
+			p.Nak = nil
+		}
+		if p.Timeout, err = g.parseMilliseconds("Timeout", e.attr("Timeout")); err != nil {
+			return err
+		}
+		if p.Retries, err = strconv.Atoi(e.attr("Retries")); err != nil {
+			return g.invalidSetting("Retries", e.attr("Retries"))
+		}
+		err = g.SetAckPolicy(p)
+	case "AddressFilter":
+		f := &AddressFilter{}
+		if f.Address, err = hex.DecodeString(e.attr("Address")); err != nil {
+			return g.invalidSetting("Address", e.attr("Address"))
+		}
+		if f.Offset, err = strconv.Atoi(e.attr("Offset")); err != nil {
+			return g.invalidSetting("Offset", e.attr("Offset"))
+		}
+		if broadcast := e.attr("Broadcast"); broadcast != "" {
+			for _, it := range strings.Split(broadcast, ",") {
+				address, err := hex.DecodeString(it)
+				if err != nil {
+					return g.invalidSetting("Broadcast", broadcast)
+				}
+				f.Broadcast = append(f.Broadcast, address)
+			}
+		}
+		g.SetAddressFilter(f)
+	case "NoiseFilter":
+		f := &NoiseFilter{}
+		if f.Bytes, err = hex.DecodeString(v); err != nil {
+			return g.invalidSetting(name, v)
+		}
+		g.SetNoiseFilter(f)
+	case "Checksum":
+		err = g.invalidSetting(name, v)
+		for _, it := range []Checksum{ChecksumCrc16Ccitt, ChecksumCrc16Modbus, ChecksumCrc32, ChecksumLrc, ChecksumBcc} {
+			if it.(checksum).name == v {
+				g.SetChecksum(it)
+				err = nil
+			}
+		}
+	case "TextCodec":
+		err = g.invalidSetting(name, v)
+		for _, it := range []TextCodec{TextCodecUTF8, TextCodecASCII, TextCodecLatin1, TextCodecHex} {
+			if it.(textCodec).name == v {
+				g.SetTextCodec(it)
+				err = nil
+			}
+		}
+	default:
+		if g.strictSettings {
+			return errors.New(localize(g.p, MsgUnknownSetting, name))
+		}
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+// enterHandler registers an in-flight event handler.
+// It returns false if the port is closed and the handler must not be called.
+func (g *GXSerial) enterHandler() bool {
+	g.handlerMu.Lock()
+	defer g.handlerMu.Unlock()
+	select {
+	case <-g.stop:
+		return false
+	default:
+	}
+	g.handlers.Add(1)
+	return true
+}
+
+// fenceHandlers closes the stop channel so that no new event handlers are called.
+// The caller holds the lock.
+func (g *GXSerial) fenceHandlers() {
+	g.handlerMu.Lock()
+	close(g.stop)
+	g.handlerMu.Unlock()
+}
+
+// unfenceHandlers recreates the stop channel when the port is opened after Close.
+// The caller holds the lock.
+func (g *GXSerial) unfenceHandlers() {
+	g.handlerMu.Lock()
+	select {
+	case <-g.stop:
+		g.stop = make(chan struct{})
+	default:
+	}
+	g.handlerMu.Unlock()
+}
@@ -0,0 +1,208 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// SimulatorHandler returns the reply for the received request.
+// Nil reply means that nothing is sent.
+type SimulatorHandler func(request []byte) ([]byte, error)
+
+// SimulatorStep is a single request and reply pair of the simulator script.
+type SimulatorStep struct {
+	// Request is the expected request. Nil matches any request.
+	Request []byte
+	// Reply is sent when the request is received.
+	Reply []byte
+	// Latency is the delay before the reply is sent.
+	// If zero, the simulator latency is used.
+	Latency time.Duration
+}
+
+// Simulator answers frames received from the attached media according to
+// a script or a handler function. It's used in integration tests to emulate
+// a device, e.g. a meter sign-on dialog, deterministically.
+//
+// The simulator is attached to the device side of the link, e.g. a GXSerial
+// opened to the other end of a virtual null-modem port pair.
+type Simulator struct {
+	media   gxcommon.IGXMedia
+	mu      sync.Mutex
+	script  []SimulatorStep
+	index   int
+	handler SimulatorHandler
+	latency time.Duration
+	parser  *Parser
+	err     error
+}
+
+// NewSimulator creates a simulator for the given media.
+func NewSimulator(media gxcommon.IGXMedia) *Simulator {
+	return &Simulator{media: media}
+}
+
+// SetScript sets the request and reply pairs that are handled in order.
+func (s *Simulator) SetScript(steps []SimulatorStep) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = steps
+	s.index = 0
+}
+
+// SetHandler sets the handler that is used when there is no script.
+func (s *Simulator) SetHandler(value SimulatorHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = value
+}
+
+// SetLatency sets the default delay before a reply is sent.
+func (s *Simulator) SetLatency(value time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = value
+}
+
+// SetEop sets the end of packet that separates the received requests.
+// The EOP can be any EOP that Parser accepts.
+// If EOP is not set, each received chunk is handled as a request.
+func (s *Simulator) SetEop(eop any) error {
+	p, err := NewParser(eop)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parser = p
+	return nil
+}
+
+// Start starts answering requests received from the media.
+func (s *Simulator) Start() {
+	s.media.SetOnReceived(s.onReceived)
+}
+
+// Stop stops answering requests.
+func (s *Simulator) Stop() {
+	s.media.SetOnReceived(nil)
+}
+
+// Done returns true when all the script steps are handled.
+func (s *Simulator) Done() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index >= len(s.script)
+}
+
+// Err returns the first error that occurred while answering, e.g. an unexpected request.
+func (s *Simulator) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Simulator) onReceived(m gxcommon.IGXMedia, e gxcommon.ReceiveEventArgs) {
+	data, err := gxcommon.ToBytes(e.Data(), binary.BigEndian)
+	if err != nil {
+		s.setError(err)
+		return
+	}
+	for _, frame := range s.frames(data) {
+		reply, latency, err := s.reply(frame)
+		if err != nil {
+			s.setError(err)
+			continue
+		}
+		if reply == nil {
+			continue
+		}
+		time.Sleep(latency)
+		if err := m.Send(reply, ""); err != nil {
+			s.setError(err)
+		}
+	}
+}
+
+// frames splits the received data to the requests.
+func (s *Simulator) frames(data []byte) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.parser == nil {
+		return [][]byte{data}
+	}
+	return s.parser.Feed(data)
+}
+
+// reply returns the reply for the request.
+func (s *Simulator) reply(request []byte) ([]byte, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index < len(s.script) {
+		step := s.script[s.index]
+		s.index++
+		if step.Request != nil && !bytes.Equal(step.Request, request) {
+			return nil, 0, fmt.Errorf("simulator step %d: unexpected request % X, want % X", s.index, request, step.Request)
+		}
+		latency := step.Latency
+		if latency == 0 {
+			latency = s.latency
+		}
+		return step.Reply, latency, nil
+	}
+	handler, latency := s.handler, s.latency
+	if handler == nil {
+		return nil, 0, fmt.Errorf("simulator: unexpected request % X", request)
+	}
+	//Handler is called without the lock so it can use the simulator.
+	s.mu.Unlock()
+	reply, err := handler(request)
+	s.mu.Lock()
+	return reply, latency, err
+}
+
+func (s *Simulator) setError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,130 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+var (
+	// smlEscape is the escape sequence of the SML transport protocol.
+	smlEscape = []byte{0x1B, 0x1B, 0x1B, 0x1B}
+	// smlStart starts the SML file.
+	smlStart = []byte{0x1B, 0x1B, 0x1B, 0x1B, 0x01, 0x01, 0x01, 0x01}
+)
+
+// smlEnd is the first byte after the escape sequence that ends the SML file.
+const smlEnd = 0x1A
+
+// SmlFrame is the EOP of the SML (Smart Message Language) transport protocol version 1.
+// The frame starts with the start sequence and ends with the end sequence that tells
+// the amount of padding bytes and the CRC-16 of the frame. Escaped escape sequences
+// inside the SML file don't end the frame. The CRC is verified and a mismatch is notified
+// with *ChecksumError. Bytes before the start sequence are removed.
+// Use it as the EOP of SetEop or Receive.
+//
+//	media.SetEop(gxserial.SmlFrame{Unwrap: true})
+type SmlFrame struct {
+	// Unwrap removes the start and end sequences, the escaping and the padding and delivers the SML file.
+	Unwrap bool
+}
+
+// find returns the start and the end of the first frame in data.
+func (SmlFrame) find(data []byte) (int, int, bool) {
+	start := bytes.Index(data, smlStart)
+	if start == -1 {
+		return 0, 0, false
+	}
+	// The escape sequences are aligned to four bytes.
+	for pos := start + len(smlStart); pos+8 <= len(data); pos += 4 {
+		if !bytes.Equal(data[pos:pos+4], smlEscape) {
+			continue
+		}
+		if bytes.Equal(data[pos+4:pos+8], smlEscape) {
+			// Escaped escape sequence.
+			pos += 4
+			continue
+		}
+		if data[pos+4] == smlEnd {
+			return start, pos + 8, true
+		}
+	}
+	return 0, 0, false
+}
+
+// End returns the length of the first frame in data.
+func (s SmlFrame) End(data []byte) (int, bool) {
+	_, end, ok := s.find(data)
+	return end, ok
+}
+
+// check verifies the CRC of the frame.
+func (s SmlFrame) check(frame []byte) ([]byte, []byte, bool) {
+	start, end, ok := s.find(frame)
+	if !ok {
+		return nil, nil, true
+	}
+	frame = frame[start:end]
+	expected := binary.LittleEndian.AppendUint16(nil, Crc16X25(frame[:len(frame)-2]))
+	actual := frame[len(frame)-2:]
+	return expected, actual, bytes.Equal(expected, actual)
+}
+
+// Decode removes the bytes before the start sequence and, if Unwrap is set, returns the SML file.
+func (s SmlFrame) Decode(frame []byte) []byte {
+	start, end, ok := s.find(frame)
+	if !ok {
+		return frame
+	}
+	frame = frame[start:end]
+	if !s.Unwrap {
+		return frame
+	}
+	padding := int(frame[len(frame)-3])
+	body := frame[len(smlStart) : len(frame)-8]
+	ret := make([]byte, 0, len(body))
+	for pos := 0; pos < len(body); pos += 4 {
+		chunk := body[pos:min(pos+4, len(body))]
+		ret = append(ret, chunk...)
+		if bytes.Equal(chunk, smlEscape) {
+			// Skip the escaped copy.
+			pos += 4
+		}
+	}
+	if padding <= len(ret) {
+		ret = ret[:len(ret)-padding]
+	}
+	return ret
+}
@@ -39,10 +39,17 @@ import (
 	"time"
 )
 
+// searchOverflow is returned by the searches when the buffer has overflowed.
+const searchOverflow = -2
+
 type synchronousMediaBase struct {
 	mu   sync.Mutex
 	buf  []byte
 	wait chan struct{}
+	// Maximum size of the buffer. Zero if the size is not limited.
+	limit int
+	// Has the buffer overflowed after the last search.
+	overflow bool
 }
 
 func newGXSynchronousMediaBase() *synchronousMediaBase {
@@ -54,6 +61,14 @@ func (b *synchronousMediaBase) Append(p []byte) {
 		return
 	}
 	b.mu.Lock()
+	if b.limit > 0 && len(b.buf)+len(p) > b.limit {
+		//The buffered data is discarded because the end of the frame is not found.
+		b.buf = b.buf[:0]
+		b.overflow = true
+		if len(p) > b.limit {
+			p = nil
+		}
+	}
 	b.buf = append(b.buf, p...)
 	old := b.wait
 	b.wait = make(chan struct{})
@@ -61,23 +76,112 @@ func (b *synchronousMediaBase) Append(p []byte) {
 	close(old)
 }
 
+// Overflow discards the buffered data and the pending or the next search returns searchOverflow.
+func (b *synchronousMediaBase) Overflow() {
+	b.mu.Lock()
+	b.buf = b.buf[:0]
+	b.overflow = true
+	old := b.wait
+	b.wait = make(chan struct{})
+	b.mu.Unlock()
+	close(old)
+}
+
+// SetLimit sets the maximum size of the buffer. Zero doesn't limit the size.
+func (b *synchronousMediaBase) SetLimit(limit int) {
+	b.mu.Lock()
+	b.limit = limit
+	b.mu.Unlock()
+}
+
+// Limit returns the maximum size of the buffer. Zero if the size is not limited.
+func (b *synchronousMediaBase) Limit() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit
+}
+
+// overflowed returns true once after the buffer has overflowed. The caller holds the lock.
+func (b *synchronousMediaBase) overflowed() bool {
+	ret := b.overflow
+	b.overflow = false
+	return ret
+}
+
+// Len returns the amount of the buffered bytes.
+func (b *synchronousMediaBase) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buf)
+}
+
 func (b *synchronousMediaBase) Get(count int) []byte {
 	var ret []byte
 	b.mu.Lock()
 	if count == -1 || count == len(b.buf) {
 		//Copy all data.
-		ret = b.buf[:]
+		ret = append([]byte(nil), b.buf...)
 		//Clear buffer
 		b.buf = b.buf[:0]
 	} else {
-		ret = b.buf[:count]
 		//Copy elements to new slice and remove them from buffer.
+		ret = append([]byte(nil), b.buf[:count]...)
 		b.buf = b.buf[count:]
 	}
 	b.mu.Unlock()
 	return ret
 }
 
+// Peek returns a copy of the buffered data without removing it.
+func (b *synchronousMediaBase) Peek() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf...)
+}
+
+// SearchFunc waits until match finds the end of the frame from the buffered data.
+// It returns the length of the frame or -1 if the frame is not received before maxWait elapses.
+// searchOverflow is returned if the buffer overflows.
+func (b *synchronousMediaBase) SearchFunc(match func([]byte) (int, bool), minLen int, maxWait time.Duration) int {
+	deadline := time.Now().Add(maxWait)
+	for {
+		b.mu.Lock()
+		if b.overflowed() {
+			b.mu.Unlock()
+			return searchOverflow
+		}
+		if len(b.buf) >= minLen {
+			if end, ok := match(b.buf); ok && end > 0 {
+				end = min(end, len(b.buf))
+				b.mu.Unlock()
+				return end
+			}
+		}
+		ch := b.wait
+		b.mu.Unlock()
+
+		if maxWait <= 0 {
+			return -1
+		}
+		rem := time.Until(deadline)
+		if rem <= 0 {
+			return -1
+		}
+		timer := time.NewTimer(rem)
+		select {
+		case <-ch:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+			return -1
+		}
+	}
+}
+
+// Search waits until the pattern is found from the buffered data.
+// It returns the end of the pattern or -1 if it's not found before maxWait elapses.
+// searchOverflow is returned if the buffer overflows.
 func (b *synchronousMediaBase) Search(pattern []byte, minLen int, maxWait time.Duration) int {
 	if minLen < 0 {
 		minLen = 0
@@ -94,6 +198,10 @@ func (b *synchronousMediaBase) Search(pattern []byte, minLen int, maxWait time.D
 	if len(pattern) == 0 {
 		for {
 			b.mu.Lock()
+			if b.overflowed() {
+				b.mu.Unlock()
+				return searchOverflow
+			}
 			if len(b.buf) >= minLen {
 				b.mu.Unlock()
 				return 0
@@ -131,6 +239,10 @@ func (b *synchronousMediaBase) Search(pattern []byte, minLen int, maxWait time.D
 
 	for {
 		b.mu.Lock()
+		if b.overflowed() {
+			b.mu.Unlock()
+			return searchOverflow
+		}
 		start := lastStart
 		if start < 0 {
 			start = 0
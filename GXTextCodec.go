@@ -0,0 +1,169 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextCodec converts the strings that are sent and renders the payloads of the traces.
+type TextCodec interface {
+	// Encode returns the bytes of the sent string.
+	// An error is returned if the string can't be encoded.
+	Encode(s string) ([]byte, error)
+	// Decode returns the text of the traced data.
+	Decode(data []byte) string
+}
+
+// EncodingError is returned when a sent string contains a character that the text codec can't encode.
+type EncodingError struct {
+	// Codec is the name of the text codec.
+	Codec string
+	// Rune is the character that can't be encoded.
+	Rune rune
+	// Offset is the byte offset of the character in the string.
+	Offset int
+	msg    string
+}
+
+// Error implements error.
+func (e *EncodingError) Error() string {
+	return e.msg
+}
+
+// textCodec is a built-in text codec.
+type textCodec struct {
+	name   string
+	encode func(s string) ([]byte, error)
+	decode func(data []byte) string
+}
+
+// Encode implements TextCodec.
+func (c textCodec) Encode(s string) ([]byte, error) {
+	return c.encode(s)
+}
+
+// Decode implements TextCodec.
+func (c textCodec) Decode(data []byte) string {
+	return c.decode(data)
+}
+
+// String returns the name of the text codec.
+func (c textCodec) String() string {
+	return c.name
+}
+
+// Built-in text codecs.
+var (
+	// TextCodecUTF8 sends the strings as UTF-8. Invalid UTF-8 is traced with the replacement character.
+	TextCodecUTF8 TextCodec = textCodec{"UTF-8", func(s string) ([]byte, error) {
+		return []byte(s), nil
+	}, func(data []byte) string {
+		return strings.ToValidUTF8(string(data), "�")
+	}}
+	// TextCodecASCII sends 7-bit ASCII only. Control characters and other bytes are traced escaped, e.g. \r or \x1B.
+	TextCodecASCII TextCodec = textCodec{"ASCII", func(s string) ([]byte, error) {
+		return encodeRunes("ASCII", s, 0x7F)
+	}, escapeASCII}
+	// TextCodecLatin1 sends the strings as ISO 8859-1.
+	TextCodecLatin1 TextCodec = textCodec{"Latin-1", func(s string) ([]byte, error) {
+		return encodeRunes("Latin-1", s, 0xFF)
+	}, func(data []byte) string {
+		ret := make([]rune, len(data))
+		for pos, it := range data {
+			ret[pos] = rune(it)
+		}
+		return string(ret)
+	}}
+	// TextCodecHex sends the strings as hex, e.g. "01 0A FF" is sent as three bytes. Traces are rendered as hex.
+	TextCodecHex TextCodec = textCodec{"Hex", func(s string) ([]byte, error) {
+		return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	}, func(data []byte) string {
+		return fmt.Sprintf("% X", data)
+	}}
+)
+
+// encodeRunes returns the bytes of the string if all characters are at most max.
+func encodeRunes(codec string, s string, max rune) ([]byte, error) {
+	ret := make([]byte, 0, len(s))
+	for pos, it := range s {
+		if it > max || it == utf8.RuneError {
+			return nil, &EncodingError{Codec: codec, Rune: it, Offset: pos,
+				msg: localize(newPrinter(DefaultLanguage()), MsgUnencodableText, it, pos, codec)}
+		}
+		ret = append(ret, byte(it))
+	}
+	return ret, nil
+}
+
+// escapeASCII returns printable ASCII as it is and escapes other bytes.
+func escapeASCII(data []byte) string {
+	var sb strings.Builder
+	for _, it := range data {
+		switch {
+		case it == '\r':
+			sb.WriteString(`\r`)
+		case it == '\n':
+			sb.WriteString(`\n`)
+		case it == '\t':
+			sb.WriteString(`\t`)
+		case it == '\\':
+			sb.WriteString(`\\`)
+		case it < 0x20 || it > 0x7E:
+			fmt.Fprintf(&sb, `\x%02X`, it)
+		default:
+			sb.WriteByte(it)
+		}
+	}
+	return sb.String()
+}
+
+// TextCodec returns the text codec. Nil if the default conversion is used.
+func (g *GXSerial) TextCodec() TextCodec {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.textCodec
+}
+
+// SetTextCodec sets the codec that encodes the sent strings and renders the payloads of the traces.
+// Send returns *EncodingError if the string can't be encoded, e.g. a non-ASCII character with TextCodecASCII.
+// Nil uses the default conversion: strings are sent as UTF-8 and data is traced as hex.
+func (g *GXSerial) SetTextCodec(value TextCodec) {
+	g.mu.Lock()
+	g.textCodec = value
+	g.mu.Unlock()
+}
@@ -0,0 +1,172 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+const (
+	// defaultThroughputWindow is the default sliding window of the throughput meter.
+	defaultThroughputWindow = 10 * time.Second
+	// throughputSlots is the amount of slots in the sliding window.
+	throughputSlots = 20
+)
+
+// Throughput is the link utilization over the sliding window.
+type Throughput struct {
+	// Window is the time the rates are counted from.
+	// It's shorter than the configured window right after the port is opened.
+	Window time.Duration
+	// RxBytesPerSecond is the amount of received bytes per second.
+	RxBytesPerSecond float64
+	// TxBytesPerSecond is the amount of sent bytes per second.
+	TxBytesPerSecond float64
+	// RxFramesPerSecond is the amount of received frames per second.
+	RxFramesPerSecond float64
+	// TxFramesPerSecond is the amount of sent frames per second.
+	TxFramesPerSecond float64
+}
+
+// throughputSlot holds the counters of one slot of the window.
+type throughputSlot struct {
+	// Index of the slot from the Unix epoch.
+	index    int64
+	rxBytes  uint64
+	txBytes  uint64
+	rxFrames uint64
+	txFrames uint64
+}
+
+// throughputMeter counts the traffic in slots of the sliding window.
+type throughputMeter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	started time.Time
+	slots   [throughputSlots]throughputSlot
+}
+
+// slotLength returns the time of one slot.
+func (t *throughputMeter) slotLength() time.Duration {
+	if t.window == 0 {
+		return defaultThroughputWindow / throughputSlots
+	}
+	return t.window / throughputSlots
+}
+
+// add adds the counters to the current slot.
+func (t *throughputMeter) add(rxBytes, txBytes, rxFrames, txFrames int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	index := time.Now().UnixNano() / int64(t.slotLength())
+	s := &t.slots[index%throughputSlots]
+	if s.index != index {
+		*s = throughputSlot{index: index}
+	}
+	s.rxBytes += uint64(rxBytes)
+	s.txBytes += uint64(txBytes)
+	s.rxFrames += uint64(rxFrames)
+	s.txFrames += uint64(txFrames)
+}
+
+// reset clears the counters and changes the window. Zero window keeps the current window.
+func (t *throughputMeter) reset(window time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if window != 0 {
+		t.window = window
+	}
+	t.started = time.Now()
+	t.slots = [throughputSlots]throughputSlot{}
+}
+
+// rates returns the rates over the window.
+func (t *throughputMeter) rates() Throughput {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	length := t.slotLength()
+	window := length * throughputSlots
+	if !t.started.IsZero() {
+		window = min(window, now.Sub(t.started))
+	}
+	ret := Throughput{Window: window}
+	if window <= 0 {
+		return ret
+	}
+	index := now.UnixNano() / int64(length)
+	var rxBytes, txBytes, rxFrames, txFrames uint64
+	for _, s := range t.slots {
+		if s.index > index-throughputSlots && s.index <= index {
+			rxBytes += s.rxBytes
+			txBytes += s.txBytes
+			rxFrames += s.rxFrames
+			txFrames += s.txFrames
+		}
+	}
+	seconds := window.Seconds()
+	ret.RxBytesPerSecond = float64(rxBytes) / seconds
+	ret.TxBytesPerSecond = float64(txBytes) / seconds
+	ret.RxFramesPerSecond = float64(rxFrames) / seconds
+	ret.TxFramesPerSecond = float64(txFrames) / seconds
+	return ret
+}
+
+// Throughput returns the sent and received bytes and frames per second over the sliding window.
+// Received frames are the frames that are completed by the EOP and delivered to the application.
+// Sent frames are the Send, SendVector and SendNineBit calls.
+// The counters are reset when the port is opened.
+func (g *GXSerial) Throughput() Throughput {
+	return g.meter.rates()
+}
+
+// ThroughputWindow returns the sliding window of Throughput.
+func (g *GXSerial) ThroughputWindow() time.Duration {
+	g.meter.mu.Lock()
+	defer g.meter.mu.Unlock()
+	return g.meter.slotLength() * throughputSlots
+}
+
+// SetThroughputWindow sets the sliding window of Throughput. The default window is 10 seconds.
+// The counters are reset.
+func (g *GXSerial) SetThroughputWindow(value time.Duration) error {
+	if value < throughputSlots {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.meter.reset(value)
+	return nil
+}
@@ -0,0 +1,114 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"time"
+)
+
+// TimestampedReceiveEventArgs is the received data with the time when it arrived.
+type TimestampedReceiveEventArgs struct {
+	// Data is the received frame.
+	Data []byte
+	// Port is the name of the serial port where the data is received.
+	Port string
+	// Time is the time when the last byte of the frame arrived.
+	// It's taken as soon as the driver reports the data and doesn't include the time
+	// that is spent in framing, tracing and the other event handlers.
+	Time time.Time
+}
+
+// TimestampedReceivedEventHandler is called when the data is received.
+type TimestampedReceivedEventHandler func(m *GXSerial, e TimestampedReceiveEventArgs)
+
+// SetOnTimestampedReceived sets the handler that is called with the arrival time of the received data.
+// It's called in the asynchronous mode after the received event.
+func (g *GXSerial) SetOnTimestampedReceived(value TimestampedReceivedEventHandler) {
+	g.mu.Lock()
+	g.onTimestamped = value
+	g.mu.Unlock()
+}
+
+// LowLatency returns true if the low latency mode of the driver is used.
+func (g *GXSerial) LowLatency() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.lowLatency
+}
+
+// SetLowLatency sets if the low latency mode of the driver is used.
+// In the low latency mode the driver passes the received data to the reader without
+// buffering it, which makes the arrival times more accurate. See Capabilities.LowLatency.
+// If the port is open, the mode is changed immediately.
+// *UnsupportedSettingError is returned if the driver doesn't support the low latency mode.
+func (g *GXSerial) SetLowLatency(value bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.s.isOpen() && value != g.lowLatency {
+		if err := g.applyLowLatency(value); err != nil {
+			return err
+		}
+	}
+	g.lowLatency = value
+	return nil
+}
+
+// applyLowLatency sets the low latency mode to the driver. The caller holds the lock.
+func (g *GXSerial) applyLowLatency(value bool) error {
+	if !g.s.capabilities().LowLatency {
+		return g.unsupported("LowLatency", value)
+	}
+	return g.s.setLowLatency(value)
+}
+
+// arrivalTime returns the time when the data of the last read arrived.
+func (g *GXSerial) arrivalTime() time.Time {
+	if t := g.s.readTime; !t.IsZero() {
+		return t
+	}
+	return time.Now()
+}
+
+// timestampedf notifies the received frame with its arrival time.
+func (g *GXSerial) timestampedf(data []byte) {
+	g.mu.RLock()
+	cb := g.onTimestamped
+	g.mu.RUnlock()
+	if cb != nil {
+		e := TimestampedReceiveEventArgs{Data: data, Port: g.Port, Time: g.rxTime}
+		g.callHandler(true, "TimestampedReceived", func() {
+			cb(g, e)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// TraceDirection is the direction of the traced data.
+type TraceDirection int
+
+const (
+	// TraceDirectionNone is used for trace events without data.
+	TraceDirectionNone TraceDirection = iota
+	// TraceDirectionSent is used for sent data.
+	TraceDirectionSent
+	// TraceDirectionReceived is used for received data.
+	TraceDirectionReceived
+)
+
+// String returns the name of the direction.
+func (d TraceDirection) String() string {
+	switch d {
+	case TraceDirectionSent:
+		return "TX"
+	case TraceDirectionReceived:
+		return "RX"
+	}
+	return "None"
+}
+
+// TraceDataEventArgs contains the structured data of a trace event.
+type TraceDataEventArgs struct {
+	// Type is the trace type.
+	Type gxcommon.TraceTypes
+	// Direction is the direction of the data.
+	Direction TraceDirection
+	// Time is the time when the data was sent or received.
+	Time time.Time
+	// Count is the amount of bytes.
+	Count int
+	// Data is the raw payload.
+	Data []byte
+	// Text is the formatted trace message.
+	Text string
+}
+
+// TraceDataEventHandler is called when data is sent or received and the trace level allows it.
+type TraceDataEventHandler func(m gxcommon.IGXMedia, e TraceDataEventArgs)
+
+// TraceRedactor is called before sent or received data is traced.
+// It returns the data that is traced, e.g. with keys or passwords masked.
+// The given data is a copy and it can be modified.
+type TraceRedactor func(direction TraceDirection, data []byte) []byte
+
+// MaskRange returns a redactor that replaces count bytes starting from offset with mask
+// in the data of the given direction. The part of the range that is outside the data is ignored,
+// so a negative offset masks from the start of the data and a negative count masks nothing.
+func MaskRange(direction TraceDirection, offset int, count int, mask byte) TraceRedactor {
+	return func(d TraceDirection, data []byte) []byte {
+		if d != direction || offset >= len(data) || count <= 0 {
+			return data
+		}
+		end := min(offset+count, len(data))
+		for i := max(offset, 0); i < end; i++ {
+			data[i] = mask
+		}
+		return data
+	}
+}
@@ -0,0 +1,408 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "golang.org/x/text/language"
+
+// translations are the built-in messages for the languages other than English.
+// Each language must contain all message keys.
+var translations = map[language.Tag]map[MessageKey]string{
+	language.French: {
+		MsgClosingConnection:    "Fermeture de la connexion du port série '%s'",
+		MsgConnectionClosed:     "Connexion du port série '%s' fermée",
+		MsgConnectionFailed:     "Échec de la connexion du port série : %v",
+		MsgCountOrEop:           "Count ou EOP doit être défini",
+		MsgConnectedTo:          "Connecté au port série '%s'",
+		MsgConnectFailed:        "Échec de la connexion au port série '%s' : %v",
+		MsgConnectingTo:         "Connexion au port série '%s'",
+		MsgNoSerialPortSelected: "Aucun port série sélectionné. Veuillez sélectionner un port série.",
+		MsgPortNotOpen:          "Le port série '%s' n'est pas ouvert.",
+		MsgSendCancelled:        "L'envoi vers le port série '%s' a été annulé.",
+		MsgUnsupportedSetting:   "%s %v n'est pas pris en charge sur cette plateforme.",
+		MsgMediaNotOpen:         "Le média '%s' n'est pas ouvert.",
+		MsgNoReplayRecords:      "Le média de relecture ne contient aucun enregistrement.",
+		MsgReplayEnded:          "Tous les enregistrements de la relecture '%s' ont déjà été rejoués.",
+		MsgReplayMismatch:       "Les données envoyées % X ne correspondent pas aux données enregistrées % X.",
+		MsgRequestTimeout:       "Aucune réponse reçue du port série '%s' en %v.",
+		MsgChecksumMismatch:     "Somme de contrôle invalide. Attendu % X, reçu % X.",
+		MsgInvalidFrame:         "Trame invalide % X : %v",
+		MsgNotAcknowledged:      "Le port série '%s' n'a pas acquitté les données après %d tentatives",
+		MsgReadOnly:             "Le port série '%s' est ouvert en lecture seule.",
+		MsgReaderStopped:        "La lecture du port série '%s' s'est arrêtée après %d redémarrages.",
+		MsgProfileNotFound:      "Profil '%s' introuvable dans '%s'.",
+		MsgHandlerPanic:         "Le gestionnaire d'événement %s a paniqué : %v",
+		MsgUnencodableText:      "Le caractère %q à la position %d ne peut pas être encodé en %s.",
+		MsgRingDetected:         "Sonnerie %[2]d détectée sur le port série '%[1]s'.",
+		MsgAnswerFailed:         "La réponse à l'appel sur le port série '%s' a échoué.",
+		MsgWatchdogExpired:      "Aucune donnée n'a été reçue du port série '%s' en %v après l'envoi de données. Action : %v.",
+		MsgBufferOverflow:       "Le tampon de réception du port série '%s' a dépassé %d octets avant la fin de la trame.",
+		MsgAggregatorClosed:     "L'agrégateur est fermé.",
+		MsgSendQueueTimeout:     "Le port série '%s' ne s'est pas libéré pour l'envoi en %v",
+		MsgFramingNotSupported:  "l'EOP %T ne peut pas construire les trames envoyées",
+		MsgLineWaitTimeout:      "La ligne %s du port série '%s' n'est pas devenue %t en %v",
+		MsgSelfTestFailed:       "L'autotest du port série '%s' a échoué à l'octet %d sur %d.",
+		MsgLineChangeTimeout:    "Les lignes %s du port série '%s' n'ont pas changé en %v",
+		MsgBreakSent:            "Break de %v envoyé sur le port série '%s'.",
+		MsgBreakReceived:        "Break reçu sur le port série '%s'.",
+		MsgRtsToggleConflict:    "Le basculement RTS et le mode RS-485 natif ne peuvent pas être utilisés en même temps sur le port série '%s'.",
+		MsgEchoMismatch:         "L'écho des données envoyées ne correspond pas aux données reçues sur le port série '%s'.",
+		MsgNak:                  "Acquittement négatif (NAK) reçu.",
+		MsgFrameDropped:         "Le port série '%s' a abandonné une trame reçue car le consommateur est trop lent",
+		MsgOutputDrainTimeout:   "Le port série '%s' n'a pas envoyé les données en attente en %v",
+		MsgInvalidLineStep:      "Étape de séquence de lignes invalide : %q",
+		MsgInvalidReplayLog:     "Données invalides à la ligne %d du journal de relecture",
+		MsgCatalogNotWritable:   "Les messages ne peuvent pas être définis dans le catalogue %T.",
+		MsgTransientReadError:   "Erreur de lecture transitoire sur le port série '%s' : %v",
+		MsgUnknownSetting:       "Paramètre inconnu %s.",
+		MsgInvalidSetting:       "Valeur %s invalide : %s",
+		MsgInvalidIecMessage:    "Message d'identification IEC 61107 invalide : %q",
+		MsgInvalidIecBaudRate:   "Identifiant de débit IEC 61107 invalide : %c",
+		MsgNoDle:                "Le partenaire n'a pas répondu à STX par DLE.",
+		MsgNoPortPair:           "Aucune paire de ports virtuels trouvée.",
+		MsgPortPairNotFound:     "Paire de ports virtuels %s introuvable.",
+		MsgInvalidModeString:    "Chaîne de port série %q invalide. device:baud est attendu.",
+		MsgLayoutTarget:         "La cible de la disposition doit être un pointeur vers une structure.",
+		MsgInvalidLayoutTag:     "Balise de disposition %q invalide du champ %s",
+		MsgLayoutField:          "Le décodage du champ %s a échoué",
+		MsgUnsupportedLayout:    "Type de champ de disposition %s non pris en charge",
+	},
+	language.Italian: {
+		MsgClosingConnection:    "Chiusura della connessione della porta seriale '%s'",
+		MsgConnectionClosed:     "Connessione della porta seriale '%s' chiusa",
+		MsgConnectionFailed:     "Connessione della porta seriale non riuscita: %v",
+		MsgCountOrEop:           "È necessario impostare Count o EOP",
+		MsgConnectedTo:          "Connesso alla porta seriale '%s'",
+		MsgConnectFailed:        "Connessione alla porta seriale '%s' non riuscita: %v",
+		MsgConnectingTo:         "Connessione alla porta seriale '%s' in corso",
+		MsgNoSerialPortSelected: "Nessuna porta seriale selezionata. Selezionare una porta seriale.",
+		MsgPortNotOpen:          "La porta seriale '%s' non è aperta.",
+		MsgSendCancelled:        "L'invio alla porta seriale '%s' è stato annullato.",
+		MsgUnsupportedSetting:   "%s %v non è supportato su questa piattaforma.",
+		MsgMediaNotOpen:         "Il media '%s' non è aperto.",
+		MsgNoReplayRecords:      "Il media di riproduzione non contiene record.",
+		MsgReplayEnded:          "Tutti i record della riproduzione '%s' sono già stati riprodotti.",
+		MsgReplayMismatch:       "I dati inviati % X non corrispondono ai dati registrati % X.",
+		MsgRequestTimeout:       "Nessuna risposta ricevuta dalla porta seriale '%s' entro %v.",
+		MsgChecksumMismatch:     "Checksum non valido. Atteso % X, ricevuto % X.",
+		MsgInvalidFrame:         "Frame non valido % X: %v",
+		MsgNotAcknowledged:      "La porta seriale '%s' non ha confermato i dati dopo %d tentativi",
+		MsgReadOnly:             "La porta seriale '%s' è aperta in sola lettura.",
+		MsgReaderStopped:        "La lettura dalla porta seriale '%s' si è interrotta dopo %d riavvii.",
+		MsgProfileNotFound:      "Profilo '%s' non trovato in '%s'.",
+		MsgHandlerPanic:         "Il gestore dell'evento %s è andato in panic: %v",
+		MsgUnencodableText:      "Il carattere %q alla posizione %d non può essere codificato con %s.",
+		MsgRingDetected:         "Squillo %[2]d rilevato sulla porta seriale '%[1]s'.",
+		MsgAnswerFailed:         "La risposta alla chiamata sulla porta seriale '%s' non è riuscita.",
+		MsgWatchdogExpired:      "Nessun dato ricevuto dalla porta seriale '%s' entro %v dall'invio dei dati. Azione: %v.",
+		MsgBufferOverflow:       "Il buffer di ricezione della porta seriale '%s' ha superato %d byte prima della fine del frame.",
+		MsgAggregatorClosed:     "L'aggregatore è chiuso.",
+		MsgSendQueueTimeout:     "La porta seriale '%s' non si è liberata per l'invio entro %v",
+		MsgFramingNotSupported:  "l'EOP %T non può costruire i frame inviati",
+		MsgLineWaitTimeout:      "La linea %s della porta seriale '%s' non è diventata %t entro %v",
+		MsgSelfTestFailed:       "L'autotest della porta seriale '%s' non è riuscito al byte %d di %d.",
+		MsgLineChangeTimeout:    "Le linee %s della porta seriale '%s' non sono cambiate in %v",
+		MsgBreakSent:            "Break di %v inviato sulla porta seriale '%s'.",
+		MsgBreakReceived:        "Break ricevuto sulla porta seriale '%s'.",
+		MsgRtsToggleConflict:    "La commutazione RTS e la modalità RS-485 nativa non possono essere usate contemporaneamente sulla porta seriale '%s'.",
+		MsgEchoMismatch:         "L'eco dei dati inviati non corrisponde ai dati ricevuti sulla porta seriale '%s'.",
+		MsgNak:                  "Ricevuto un riconoscimento negativo (NAK).",
+		MsgFrameDropped:         "La porta seriale '%s' ha scartato un frame ricevuto perché il consumatore è troppo lento",
+		MsgOutputDrainTimeout:   "La porta seriale '%s' non ha inviato i dati in coda in %v",
+		MsgInvalidLineStep:      "Passo della sequenza di linee non valido: %q",
+		MsgInvalidReplayLog:     "Dati non validi alla riga %d del log di riproduzione",
+		MsgCatalogNotWritable:   "Non è possibile impostare messaggi nel catalogo %T.",
+		MsgTransientReadError:   "Errore di lettura temporaneo sulla porta seriale '%s': %v",
+		MsgUnknownSetting:       "Impostazione sconosciuta %s.",
+		MsgInvalidSetting:       "Valore %s non valido: %s",
+		MsgInvalidIecMessage:    "Messaggio di identificazione IEC 61107 non valido: %q",
+		MsgInvalidIecBaudRate:   "Identificatore di velocità IEC 61107 non valido: %c",
+		MsgNoDle:                "Il partner non ha risposto a STX con DLE.",
+		MsgNoPortPair:           "Nessuna coppia di porte virtuali trovata.",
+		MsgPortPairNotFound:     "Coppia di porte virtuali %s non trovata.",
+		MsgInvalidModeString:    "Stringa della porta seriale %q non valida. È previsto device:baud.",
+		MsgLayoutTarget:         "La destinazione del layout deve essere un puntatore a una struct.",
+		MsgInvalidLayoutTag:     "Tag di layout %q non valido del campo %s",
+		MsgLayoutField:          "Decodifica del campo %s non riuscita",
+		MsgUnsupportedLayout:    "Tipo di campo del layout %s non supportato",
+	},
+	language.Portuguese: {
+		MsgClosingConnection:    "Fechando a conexão da porta serial '%s'",
+		MsgConnectionClosed:     "Conexão da porta serial '%s' fechada",
+		MsgConnectionFailed:     "Falha na conexão da porta serial: %v",
+		MsgCountOrEop:           "Count ou EOP deve ser definido",
+		MsgConnectedTo:          "Conectado à porta serial '%s'",
+		MsgConnectFailed:        "Falha ao conectar à porta serial '%s': %v",
+		MsgConnectingTo:         "Conectando à porta serial '%s'",
+		MsgNoSerialPortSelected: "Nenhuma porta serial selecionada. Selecione uma porta serial.",
+		MsgPortNotOpen:          "A porta serial '%s' não está aberta.",
+		MsgSendCancelled:        "O envio para a porta serial '%s' foi cancelado.",
+		MsgUnsupportedSetting:   "%s %v não é suportado nesta plataforma.",
+		MsgMediaNotOpen:         "A mídia '%s' não está aberta.",
+		MsgNoReplayRecords:      "A mídia de reprodução não tem registros.",
+		MsgReplayEnded:          "Todos os registros da reprodução '%s' já foram reproduzidos.",
+		MsgReplayMismatch:       "Os dados enviados % X não correspondem aos dados gravados % X.",
+		MsgRequestTimeout:       "Nenhuma resposta recebida da porta serial '%s' em %v.",
+		MsgChecksumMismatch:     "Checksum inválido. Esperado % X, recebido % X.",
+		MsgInvalidFrame:         "Quadro inválido % X: %v",
+		MsgNotAcknowledged:      "A porta serial '%s' não confirmou os dados após %d tentativas",
+		MsgReadOnly:             "A porta serial '%s' está aberta somente para leitura.",
+		MsgReaderStopped:        "A leitura da porta serial '%s' parou após %d reinícios.",
+		MsgProfileNotFound:      "Perfil '%s' não encontrado em '%s'.",
+		MsgHandlerPanic:         "O manipulador do evento %s entrou em pânico: %v",
+		MsgUnencodableText:      "O caractere %q na posição %d não pode ser codificado com %s.",
+		MsgRingDetected:         "Toque %[2]d detectado na porta serial '%[1]s'.",
+		MsgAnswerFailed:         "O atendimento da chamada na porta serial '%s' falhou.",
+		MsgWatchdogExpired:      "Não foram recebidos dados da porta serial '%s' em %v após o envio de dados. Ação: %v.",
+		MsgBufferOverflow:       "O buffer de recepção da porta serial '%s' excedeu %d bytes antes do fim do quadro.",
+		MsgAggregatorClosed:     "O agregador está fechado.",
+		MsgSendQueueTimeout:     "A porta serial '%s' não ficou livre para envio em %v",
+		MsgFramingNotSupported:  "o EOP %T não pode construir os quadros enviados",
+		MsgLineWaitTimeout:      "A linha %s da porta serial '%s' não se tornou %t em %v",
+		MsgSelfTestFailed:       "O autoteste da porta serial '%s' falhou no byte %d de %d.",
+		MsgLineChangeTimeout:    "As linhas %s da porta serial '%s' não mudaram em %v",
+		MsgBreakSent:            "Break de %v enviado na porta serial '%s'.",
+		MsgBreakReceived:        "Break recebido na porta serial '%s'.",
+		MsgRtsToggleConflict:    "A alternância de RTS e o modo RS-485 nativo não podem ser usados ao mesmo tempo na porta serial '%s'.",
+		MsgEchoMismatch:         "O eco dos dados enviados não corresponde aos dados recebidos na porta serial '%s'.",
+		MsgNak:                  "Reconhecimento negativo (NAK) recebido.",
+		MsgFrameDropped:         "A porta serial '%s' descartou um quadro recebido porque o consumidor é muito lento",
+		MsgOutputDrainTimeout:   "A porta serial '%s' não enviou os dados da fila em %v",
+		MsgInvalidLineStep:      "Passo de sequência de linhas inválido: %q",
+		MsgInvalidReplayLog:     "Dados inválidos na linha %d do log de reprodução",
+		MsgCatalogNotWritable:   "Não é possível definir mensagens no catálogo %T.",
+		MsgTransientReadError:   "Erro de leitura temporário na porta serial '%s': %v",
+		MsgUnknownSetting:       "Configuração desconhecida %s.",
+		MsgInvalidSetting:       "Valor de %s inválido: %s",
+		MsgInvalidIecMessage:    "Mensagem de identificação IEC 61107 inválida: %q",
+		MsgInvalidIecBaudRate:   "Identificador de taxa de transmissão IEC 61107 inválido: %c",
+		MsgNoDle:                "O parceiro não respondeu ao STX com DLE.",
+		MsgNoPortPair:           "Nenhum par de portas virtuais encontrado.",
+		MsgPortPairNotFound:     "Par de portas virtuais %s não encontrado.",
+		MsgInvalidModeString:    "String de porta serial %q inválida. device:baud é esperado.",
+		MsgLayoutTarget:         "O destino do layout deve ser um ponteiro para uma struct.",
+		MsgInvalidLayoutTag:     "Tag de layout %q inválida do campo %s",
+		MsgLayoutField:          "Falha na decodificação do campo %s",
+		MsgUnsupportedLayout:    "Tipo de campo de layout %s não suportado",
+	},
+	language.Russian: {
+		MsgClosingConnection:    "Закрытие соединения с последовательным портом '%s'",
+		MsgConnectionClosed:     "Соединение с последовательным портом '%s' закрыто",
+		MsgConnectionFailed:     "Ошибка соединения с последовательным портом: %v",
+		MsgCountOrEop:           "Необходимо задать Count или EOP",
+		MsgConnectedTo:          "Подключено к последовательному порту '%s'",
+		MsgConnectFailed:        "Не удалось подключиться к последовательному порту '%s': %v",
+		MsgConnectingTo:         "Подключение к последовательному порту '%s'",
+		MsgNoSerialPortSelected: "Последовательный порт не выбран. Выберите последовательный порт.",
+		MsgPortNotOpen:          "Последовательный порт '%s' не открыт.",
+		MsgSendCancelled:        "Отправка в последовательный порт '%s' отменена.",
+		MsgUnsupportedSetting:   "%s %v не поддерживается на этой платформе.",
+		MsgMediaNotOpen:         "Среда передачи '%s' не открыта.",
+		MsgNoReplayRecords:      "Среда воспроизведения не содержит записей.",
+		MsgReplayEnded:          "Все записи воспроизведения '%s' уже воспроизведены.",
+		MsgReplayMismatch:       "Отправленные данные % X не совпадают с записанными данными % X.",
+		MsgRequestTimeout:       "Ответ от последовательного порта '%s' не получен за %v.",
+		MsgChecksumMismatch:     "Неверная контрольная сумма. Ожидалось % X, получено % X.",
+		MsgInvalidFrame:         "Недопустимый кадр % X: %v",
+		MsgNotAcknowledged:      "Последовательный порт '%s' не подтвердил данные после %d попыток",
+		MsgReadOnly:             "Последовательный порт '%s' открыт только для чтения.",
+		MsgReaderStopped:        "Чтение из последовательного порта '%s' остановлено после %d перезапусков.",
+		MsgProfileNotFound:      "Профиль '%s' не найден в '%s'.",
+		MsgHandlerPanic:         "Обработчик события %s вызвал панику: %v",
+		MsgUnencodableText:      "Символ %q в позиции %d не может быть закодирован в %s.",
+		MsgRingDetected:         "Звонок %[2]d обнаружен на последовательном порту '%[1]s'.",
+		MsgAnswerFailed:         "Не удалось ответить на вызов на последовательном порту '%s'.",
+		MsgWatchdogExpired:      "Данные не получены с последовательного порта '%s' в течение %v после отправки. Действие: %v.",
+		MsgBufferOverflow:       "Буфер приёма последовательного порта '%s' превысил %d байт до окончания кадра.",
+		MsgAggregatorClosed:     "Агрегатор закрыт.",
+		MsgSendQueueTimeout:     "Последовательный порт '%s' не освободился для отправки за %v",
+		MsgFramingNotSupported:  "EOP %T не может формировать отправляемые кадры",
+		MsgLineWaitTimeout:      "Линия %s последовательного порта '%s' не перешла в состояние %t за %v",
+		MsgSelfTestFailed:       "Самотестирование последовательного порта '%s' не пройдено на байте %d из %d.",
+		MsgLineChangeTimeout:    "Линии %s последовательного порта '%s' не изменились за %v",
+		MsgBreakSent:            "Сигнал break длительностью %v отправлен в последовательный порт '%s'.",
+		MsgBreakReceived:        "Получен сигнал break в последовательном порту '%s'.",
+		MsgRtsToggleConflict:    "Переключение RTS и встроенный режим RS-485 нельзя использовать одновременно в последовательном порту '%s'.",
+		MsgEchoMismatch:         "Эхо отправленных данных не совпадает с принятыми данными в последовательном порту '%s'.",
+		MsgNak:                  "Получено отрицательное подтверждение (NAK).",
+		MsgFrameDropped:         "Последовательный порт '%s' отбросил принятый кадр, потому что получатель слишком медленный",
+		MsgOutputDrainTimeout:   "Последовательный порт '%s' не отправил данные из очереди за %v",
+		MsgInvalidLineStep:      "Недопустимый шаг последовательности линий: %q",
+		MsgInvalidReplayLog:     "Недопустимые данные в строке %d журнала воспроизведения",
+		MsgCatalogNotWritable:   "Невозможно задать сообщения в каталоге %T.",
+		MsgTransientReadError:   "Временная ошибка чтения последовательного порта '%s': %v",
+		MsgUnknownSetting:       "Неизвестный параметр %s.",
+		MsgInvalidSetting:       "Недопустимое значение %s: %s",
+		MsgInvalidIecMessage:    "Недопустимое идентификационное сообщение IEC 61107: %q",
+		MsgInvalidIecBaudRate:   "Недопустимый идентификатор скорости IEC 61107: %c",
+		MsgNoDle:                "Партнёр не ответил на STX символом DLE.",
+		MsgNoPortPair:           "Пара виртуальных портов не найдена.",
+		MsgPortPairNotFound:     "Пара виртуальных портов %s не найдена.",
+		MsgInvalidModeString:    "Недопустимая строка последовательного порта %q. Ожидается device:baud.",
+		MsgLayoutTarget:         "Целью разметки должен быть указатель на структуру.",
+		MsgInvalidLayoutTag:     "Недопустимый тег разметки %q поля %s",
+		MsgLayoutField:          "Ошибка декодирования поля %s",
+		MsgUnsupportedLayout:    "Неподдерживаемый тип поля разметки %s",
+	},
+	language.SimplifiedChinese: {
+		MsgClosingConnection:    "正在关闭串口 '%s' 的连接",
+		MsgConnectionClosed:     "串口 '%s' 的连接已关闭",
+		MsgConnectionFailed:     "串口连接失败：%v",
+		MsgCountOrEop:           "必须设置 Count 或 EOP",
+		MsgConnectedTo:          "已连接到串口 '%s'",
+		MsgConnectFailed:        "连接串口 '%s' 失败：%v",
+		MsgConnectingTo:         "正在连接串口 '%s'",
+		MsgNoSerialPortSelected: "未选择串口。请选择一个串口。",
+		MsgPortNotOpen:          "串口 '%s' 未打开。",
+		MsgSendCancelled:        "向串口 '%s' 的发送已取消。",
+		MsgUnsupportedSetting:   "此平台不支持 %s %v。",
+		MsgMediaNotOpen:         "媒体 '%s' 未打开。",
+		MsgNoReplayRecords:      "回放媒体没有记录。",
+		MsgReplayEnded:          "回放 '%s' 的所有记录均已回放。",
+		MsgReplayMismatch:       "发送的数据 % X 与记录的数据 % X 不匹配。",
+		MsgRequestTimeout:       "在 %[2]v 内未收到串口 '%[1]s' 的应答。",
+		MsgChecksumMismatch:     "校验和无效。期望 % X，收到 % X。",
+		MsgInvalidFrame:         "无效的帧 % X：%v",
+		MsgNotAcknowledged:      "串口 '%s' 在 %d 次尝试后仍未确认数据",
+		MsgReadOnly:             "串口 '%s' 以只读模式打开。",
+		MsgReaderStopped:        "串口 '%s' 的读取在 %d 次重启后停止。",
+		MsgProfileNotFound:      "在 '%[2]s' 中找不到配置文件 '%[1]s'。",
+		MsgHandlerPanic:         "%s 事件处理程序发生 panic：%v",
+		MsgUnencodableText:      "位于偏移量 %[2]d 的字符 %[1]q 无法用 %[3]s 编码。",
+		MsgRingDetected:         "在串口 '%[1]s' 上检测到第 %[2]d 次振铃。",
+		MsgAnswerFailed:         "在串口 '%s' 上应答呼叫失败。",
+		MsgWatchdogExpired:      "发送数据后 %[2]v 内未从串口 '%[1]s' 接收到数据。操作：%[3]v。",
+		MsgBufferOverflow:       "串口 '%s' 的接收缓冲区在帧结束前超过了 %d 字节。",
+		MsgAggregatorClosed:     "聚合器已关闭。",
+		MsgSendQueueTimeout:     "串口 '%s' 在 %v 内未空闲以进行发送",
+		MsgFramingNotSupported:  "EOP %T 无法构建发送的帧",
+		MsgLineWaitTimeout:      "串口 '%[2]s' 的 %[1]s 线在 %[4]v 内未变为 %[3]t",
+		MsgSelfTestFailed:       "串口 '%s' 的自检在第 %d 个字节（共 %d 个）处失败。",
+		MsgLineChangeTimeout:    "串口 '%[2]s' 的 %[1]s 线在 %[3]v 内没有变化",
+		MsgBreakSent:            "已在串口 '%[2]s' 上发送 %[1]v 的 break 信号。",
+		MsgBreakReceived:        "串口 '%s' 收到 break 信号。",
+		MsgRtsToggleConflict:    "串口 '%s' 不能同时使用 RTS 切换和原生 RS-485 模式。",
+		MsgEchoMismatch:         "串口 '%s' 上发送数据的回显与接收数据不匹配。",
+		MsgNak:                  "收到否定应答 (NAK)。",
+		MsgFrameDropped:         "串口 '%s' 丢弃了接收到的帧，因为使用者太慢",
+		MsgOutputDrainTimeout:   "串口 '%s' 未在 %v 内发送排队的数据",
+		MsgInvalidLineStep:      "无效的控制线序列步骤：%q",
+		MsgInvalidReplayLog:     "回放日志第 %d 行的数据无效",
+		MsgCatalogNotWritable:   "无法在目录 %T 中设置消息。",
+		MsgTransientReadError:   "串口 '%s' 出现暂时性读取错误：%v",
+		MsgUnknownSetting:       "未知的设置 %s。",
+		MsgInvalidSetting:       "无效的 %s 值：%s",
+		MsgInvalidIecMessage:    "无效的 IEC 61107 标识报文：%q",
+		MsgInvalidIecBaudRate:   "无效的 IEC 61107 波特率标识符：%c",
+		MsgNoDle:                "对方未以 DLE 应答 STX。",
+		MsgNoPortPair:           "未找到虚拟端口对。",
+		MsgPortPairNotFound:     "未找到虚拟端口对 %s。",
+		MsgInvalidModeString:    "无效的串口字符串 %q。应为 device:baud。",
+		MsgLayoutTarget:         "布局目标必须是指向结构体的指针。",
+		MsgInvalidLayoutTag:     "字段 %[2]s 的布局标签 %[1]q 无效",
+		MsgLayoutField:          "字段 %s 解码失败",
+		MsgUnsupportedLayout:    "不支持的布局字段类型 %s",
+	},
+	language.Japanese: {
+		MsgClosingConnection:    "シリアルポート '%s' の接続を閉じています",
+		MsgConnectionClosed:     "シリアルポート '%s' の接続を閉じました",
+		MsgConnectionFailed:     "シリアルポートの接続に失敗しました: %v",
+		MsgCountOrEop:           "Count または EOP を設定する必要があります",
+		MsgConnectedTo:          "シリアルポート '%s' に接続しました",
+		MsgConnectFailed:        "シリアルポート '%s' への接続に失敗しました: %v",
+		MsgConnectingTo:         "シリアルポート '%s' に接続しています",
+		MsgNoSerialPortSelected: "シリアルポートが選択されていません。シリアルポートを選択してください。",
+		MsgPortNotOpen:          "シリアルポート '%s' は開かれていません。",
+		MsgSendCancelled:        "シリアルポート '%s' への送信はキャンセルされました。",
+		MsgUnsupportedSetting:   "%s %v はこのプラットフォームではサポートされていません。",
+		MsgMediaNotOpen:         "メディア '%s' は開かれていません。",
+		MsgNoReplayRecords:      "再生メディアにレコードがありません。",
+		MsgReplayEnded:          "再生 '%s' のすべてのレコードは既に再生されています。",
+		MsgReplayMismatch:       "送信データ % X が記録データ % X と一致しません。",
+		MsgRequestTimeout:       "%[2]v 以内にシリアルポート '%[1]s' から応答がありませんでした。",
+		MsgChecksumMismatch:     "チェックサムが無効です。期待値 % X、受信値 % X。",
+		MsgInvalidFrame:         "無効なフレーム % X: %v",
+		MsgNotAcknowledged:      "シリアルポート '%s' は %d 回の試行後もデータを確認応答しませんでした",
+		MsgReadOnly:             "シリアルポート '%s' は読み取り専用モードで開かれています。",
+		MsgReaderStopped:        "シリアルポート '%s' からの読み取りは %d 回の再起動後に停止しました。",
+		MsgProfileNotFound:      "'%[2]s' にプロファイル '%[1]s' が見つかりません。",
+		MsgHandlerPanic:         "%s イベントハンドラーでパニックが発生しました: %v",
+		MsgUnencodableText:      "オフセット %[2]d の文字 %[1]q は %[3]s でエンコードできません。",
+		MsgRingDetected:         "シリアルポート '%[1]s' で %[2]d 回目の着信を検出しました。",
+		MsgAnswerFailed:         "シリアルポート '%s' での着信応答に失敗しました。",
+		MsgWatchdogExpired:      "データ送信後 %[2]v 以内にシリアルポート '%[1]s' からデータを受信しませんでした。アクション: %[3]v。",
+		MsgBufferOverflow:       "シリアルポート '%s' の受信バッファがフレーム終了前に %d バイトを超えました。",
+		MsgAggregatorClosed:     "アグリゲーターは閉じられています。",
+		MsgSendQueueTimeout:     "シリアルポート '%s' は %v 以内に送信可能になりませんでした",
+		MsgFramingNotSupported:  "EOP %T は送信フレームを構築できません",
+		MsgLineWaitTimeout:      "シリアルポート '%[2]s' の %[1]s 線が %[4]v 以内に %[3]t になりませんでした",
+		MsgSelfTestFailed:       "シリアルポート '%s' のセルフテストが %d / %d バイト目で失敗しました。",
+		MsgLineChangeTimeout:    "シリアルポート '%[2]s' の %[1]s 線は %[3]v 以内に変化しませんでした",
+		MsgBreakSent:            "シリアルポート '%[2]s' に %[1]v の break を送信しました。",
+		MsgBreakReceived:        "シリアルポート '%s' で break を受信しました。",
+		MsgRtsToggleConflict:    "シリアルポート '%s' では RTS トグルとネイティブ RS-485 モードを同時に使用できません。",
+		MsgEchoMismatch:         "シリアルポート '%s' で送信データのエコーが受信データと一致しません。",
+		MsgNak:                  "否定応答 (NAK) を受信しました。",
+		MsgFrameDropped:         "受信側が遅すぎるため、シリアルポート '%s' は受信したフレームを破棄しました",
+		MsgOutputDrainTimeout:   "シリアルポート '%s' は %v 以内にキューのデータを送信しませんでした",
+		MsgInvalidLineStep:      "無効な制御線シーケンスのステップ: %q",
+		MsgInvalidReplayLog:     "再生ログの %d 行目のデータが無効です",
+		MsgCatalogNotWritable:   "カタログ %T にはメッセージを設定できません。",
+		MsgTransientReadError:   "シリアルポート '%s' で一時的な読み取りエラー: %v",
+		MsgUnknownSetting:       "不明な設定 %s。",
+		MsgInvalidSetting:       "無効な %s の値: %s",
+		MsgInvalidIecMessage:    "無効な IEC 61107 識別メッセージ: %q",
+		MsgInvalidIecBaudRate:   "無効な IEC 61107 ボーレート識別子: %c",
+		MsgNoDle:                "相手が STX に DLE で応答しませんでした。",
+		MsgNoPortPair:           "仮想ポートのペアが見つかりません。",
+		MsgPortPairNotFound:     "仮想ポートのペア %s が見つかりません。",
+		MsgInvalidModeString:    "無効なシリアルポート文字列 %q。device:baud が必要です。",
+		MsgLayoutTarget:         "レイアウトの対象は構造体へのポインターである必要があります。",
+		MsgInvalidLayoutTag:     "フィールド %[2]s のレイアウト タグ %[1]q が無効です",
+		MsgLayoutField:          "フィールド %s のデコードに失敗しました",
+		MsgUnsupportedLayout:    "サポートされていないレイアウト フィールド型 %s",
+	},
+}
+
+// Languages returns the languages that have built-in messages.
+func Languages() []language.Tag {
+	return []language.Tag{
+		language.AmericanEnglish,
+		language.French,
+		language.Italian,
+		language.Portuguese,
+		language.Russian,
+		language.SimplifiedChinese,
+		language.Japanese,
+	}
+}
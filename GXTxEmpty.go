@@ -0,0 +1,117 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// TxEmptyEventArgs describes the completed transmission.
+type TxEmptyEventArgs struct {
+	// Time is the time when the transmitter was noticed to be empty.
+	Time time.Time
+	// Sent is the amount of bytes that were sent after the previous event.
+	Sent int
+}
+
+// TxEmptyEventHandler is called when the last sent byte is shifted out of the transmitter.
+type TxEmptyEventHandler func(m gxcommon.IGXMedia, e TxEmptyEventArgs)
+
+// SetOnTxEmpty sets the handler that is called when the transmitter is empty after the data is sent.
+// Use it to switch the RS-485 direction or to power down the line drivers.
+// Windows uses the EV_TXEMPTY event. Linux polls the line status register of the UART and
+// other platforms poll the output queue and wait one character time after it's empty.
+func (g *GXSerial) SetOnTxEmpty(value TxEmptyEventHandler) {
+	g.mu.Lock()
+	g.onTxEmpty = value
+	g.mu.Unlock()
+}
+
+// charTime returns the time to send one character with start, parity and two stop bits.
+func (g *GXSerial) charTime() time.Duration {
+	bits := 1 + g.dataBits + 1 + 2
+	if baud := int(g.baudRate); baud > 0 {
+		return time.Duration(bits) * time.Second / time.Duration(baud)
+	}
+	return 0
+}
+
+// txWritten is called after n bytes are written. It counts the throughput, arms the watchdog and
+// starts waiting for the empty transmitter. Only one waiter is running.
+// Bytes that are written while it waits are notified in the same event.
+func (g *GXSerial) txWritten(n int) {
+	if n <= 0 {
+		return
+	}
+	now := time.Now()
+	g.lastTx.store(now)
+	g.unanswered.CompareAndSwap(0, now.UnixNano())
+	g.meter.add(0, n, 0, 0)
+	g.mu.RLock()
+	cb := g.onTxEmpty
+	g.mu.RUnlock()
+	if cb == nil {
+		return
+	}
+	g.txSent.Add(int64(n))
+	if g.txWaiting.CompareAndSwap(false, true) {
+		go g.waitTxEmpty()
+	}
+}
+
+// waitTxEmpty waits until the transmitter is empty and notifies it.
+// The waiter ends when the port is closed.
+func (g *GXSerial) waitTxEmpty() {
+	for {
+		err := g.s.waitTxEmpty(g.charTime())
+		sent := int(g.txSent.Swap(0))
+		if err == nil && sent != 0 {
+			g.mu.RLock()
+			cb := g.onTxEmpty
+			g.mu.RUnlock()
+			if cb != nil {
+				g.callHandler(true, "TxEmpty", func() {
+					cb(g, TxEmptyEventArgs{Time: time.Now(), Sent: sent})
+				})
+			}
+		}
+		g.txWaiting.Store(false)
+		//Data was written after the transmitter was noticed to be empty.
+		if err != nil || g.txSent.Load() == 0 || !g.txWaiting.CompareAndSwap(false, true) {
+			return
+		}
+	}
+}
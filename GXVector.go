@@ -0,0 +1,110 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// SendVector sends the parts of a frame, e.g. header, payload and CRC, in a single write operation.
+// The parts are written without gaps between them, which is required by devices that treat
+// silence inside a frame as the end of the frame. The checksum is appended after the last part.
+func (g *GXSerial) SendVector(parts [][]byte) error {
+	if !g.s.isOpen() {
+		return errors.New(localize(g.p, MsgPortNotOpen, g.Port))
+	}
+	if err := g.checkWritable(); err != nil {
+		return err
+	}
+	if err := g.lockSend(); err != nil {
+		return err
+	}
+	defer g.sendLock.release()
+	data := bytes.Join(parts, nil)
+	if c := g.Checksum(); c != nil {
+		parts = append(parts[:len(parts):len(parts)], c.Sum(data))
+		data = bytes.Join(parts, nil)
+	}
+	g.bytesSent += uint64(len(data))
+	g.traceData(gxcommon.TraceTypesSent, data, nil)
+	g.record(FlightEvent{Type: FlightEventSent, Data: data})
+	var err error
+	if g.txRate > 0 {
+		//Rate limited data is written in slices.
+		_, err = g.write(data)
+	} else {
+		_, err = g.writev(parts)
+	}
+	if err != nil {
+		g.errorHistory.Add(ErrorRecord{Time: time.Now(), Op: "send", Err: err})
+	} else {
+		g.meter.add(0, 0, 0, 1)
+	}
+	return err
+}
+
+// writev writes the buffers with one system call honoring the write timeout.
+func (g *GXSerial) writev(bufs [][]byte) (int, error) {
+	if g.writeTimeout > 0 {
+		if err := g.s.setWriteDeadline(time.Now().Add(g.writeTimeout)); err != nil {
+			return 0, err
+		}
+		defer g.s.setWriteDeadline(time.Time{})
+	}
+	done, err := g.beginTransmit()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	defer g.expectEcho(bufs...)()
+	n, err := g.s.writev(bufs)
+	g.txWritten(n)
+	return n, err
+}
+
+// consumeBuffers removes n written bytes from the beginning of the buffers.
+func consumeBuffers(bufs [][]byte, n int) [][]byte {
+	for len(bufs) != 0 && n >= len(bufs[0]) {
+		n -= len(bufs[0])
+		bufs = bufs[1:]
+	}
+	if len(bufs) != 0 {
+		bufs[0] = bufs[0][n:]
+	}
+	return bufs
+}
@@ -0,0 +1,189 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+)
+
+// defaultWatchdogPulse is the time DTR is dropped by WatchdogToggleDtr when the pulse is not given.
+const defaultWatchdogPulse = 100 * time.Millisecond
+
+// WatchdogAction defines what the watchdog does when no data is received after data is sent.
+type WatchdogAction int
+
+const (
+	// WatchdogError notifies the timeout through the error event.
+	WatchdogError WatchdogAction = iota
+	// WatchdogFlush discards the data in the driver queues and in the receive buffer.
+	WatchdogFlush
+	// WatchdogToggleDtr drops DTR for the pulse time and raises it again.
+	WatchdogToggleDtr
+	// WatchdogReconnect closes the port and opens it again. The port is not opened again if Close is called meanwhile.
+	WatchdogReconnect
+)
+
+// String returns the name of the watchdog action.
+func (a WatchdogAction) String() string {
+	switch a {
+	case WatchdogError:
+		return "Error"
+	case WatchdogFlush:
+		return "Flush"
+	case WatchdogToggleDtr:
+		return "ToggleDtr"
+	case WatchdogReconnect:
+		return "Reconnect"
+	}
+	return fmt.Sprintf("WatchdogAction(%d)", int(a))
+}
+
+// Watchdog recovers a link that doesn't reply.
+// The window starts when data is sent after the last received data.
+// If nothing is received before the window elapses, the action is performed.
+// The watchdog is armed again by the next sent data.
+type Watchdog struct {
+	// Window is the maximum time to wait for received data after data is sent.
+	Window time.Duration
+	// Action is performed when nothing is received in the window.
+	Action WatchdogAction
+	// Pulse is the time DTR is dropped by WatchdogToggleDtr. 100 ms is used if zero.
+	Pulse time.Duration
+}
+
+// Watchdog returns the traffic watchdog. Nil if it's not used.
+func (g *GXSerial) Watchdog() *Watchdog {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.watchdog
+}
+
+// SetWatchdog sets the traffic watchdog. Nil disables the watchdog.
+// The watchdog runs while the port is open.
+func (g *GXSerial) SetWatchdog(value *Watchdog) error {
+	if value != nil && (value.Window <= 0 || value.Pulse < 0 ||
+		value.Action < WatchdogError || value.Action > WatchdogReconnect) {
+		return gxcommon.ErrInvalidArgument
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopWatchdog()
+	g.watchdog = value
+	g.startWatchdog()
+	return nil
+}
+
+// startWatchdog starts the watchdog if it's used. The caller holds the lock.
+func (g *GXSerial) startWatchdog() {
+	if g.watchdog == nil || g.watchdogStop != nil || !g.s.isOpen() {
+		return
+	}
+	g.unanswered.Store(0)
+	g.watchdogStop = make(chan struct{})
+	go g.runWatchdog(g.watchdogStop, *g.watchdog)
+}
+
+// stopWatchdog stops the watchdog. The caller holds the lock.
+func (g *GXSerial) stopWatchdog() {
+	if g.watchdogStop != nil {
+		close(g.watchdogStop)
+		g.watchdogStop = nil
+	}
+}
+
+// runWatchdog checks the unanswered sent data until stop is closed.
+func (g *GXSerial) runWatchdog(stop chan struct{}, w Watchdog) {
+	timer := time.NewTimer(w.Window)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		wait := w.Window
+		if sent := g.unanswered.Load(); sent != 0 {
+			elapsed := time.Since(time.Unix(0, sent))
+			if elapsed < w.Window {
+				wait = w.Window - elapsed
+			} else if g.unanswered.CompareAndSwap(sent, 0) {
+				g.watchdogExpired(w)
+				if w.Action == WatchdogReconnect {
+					// Reconnect starts a new watchdog.
+					return
+				}
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// watchdogExpired performs the watchdog action.
+func (g *GXSerial) watchdogExpired(w Watchdog) {
+	msg := localize(g.p, MsgWatchdogExpired, g.Port, w.Window, w.Action)
+	g.trace(true, gxcommon.TraceTypesError, msg)
+	var err error
+	switch w.Action {
+	case WatchdogError:
+		g.errorf(true, "watchdog", errors.New(msg))
+	case WatchdogFlush:
+		g.received.Get(-1)
+		err = g.s.flush()
+	case WatchdogToggleDtr:
+		pulse := w.Pulse
+		if pulse == 0 {
+			pulse = defaultWatchdogPulse
+		}
+		if err = g.setControlLine(ControlLineDtr, false); err == nil {
+			time.Sleep(pulse)
+			err = g.setControlLine(ControlLineDtr, true)
+		}
+	case WatchdogReconnect:
+		if err = g.closePort(); err == nil {
+			// The port is not reopened if Close was called meanwhile.
+			g.lifecycleMu.Lock()
+			if !g.closedByUser {
+				err = g.open()
+			}
+			g.lifecycleMu.Unlock()
+		}
+	}
+	if err != nil {
+		g.errorf(true, "watchdog", err)
+	}
+}
@@ -0,0 +1,175 @@
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// imstSof starts the HCI frame of IMST iM871A and iM170A sticks.
+	imstSof = 0xA5
+	// amberSof starts the command frame of Amber AMB8465 and AMB8665 sticks.
+	amberSof = 0xFF
+)
+
+// IMST control field flags.
+const (
+	imstTimestamp = 0x20
+	imstRssi      = 0x40
+	imstCrc       = 0x80
+)
+
+// WmbusImst is the EOP of the HCI frames of IMST style wM-Bus USB sticks, e.g. iM871A.
+// The length of the frame is read from the header, and the timestamp, RSSI and CRC-16
+// fields are included as the control field tells. The CRC is verified and a mismatch is
+// notified with *ChecksumError. Bytes before the start of the frame are removed.
+// Use it as the EOP of SetEop or Receive.
+//
+//	media.SetEop(gxserial.WmbusImst{Unwrap: true})
+type WmbusImst struct {
+	// Unwrap removes the HCI header and trailer and delivers only the payload, e.g. the received telegram.
+	Unwrap bool
+}
+
+// find returns the start of the first frame in data and the size of the frame.
+func (WmbusImst) find(data []byte) (int, int, bool) {
+	start := bytes.IndexByte(data, imstSof)
+	if start == -1 || start+4 > len(data) {
+		return 0, 0, false
+	}
+	ctrl := data[start+1]
+	size := 4 + int(data[start+3])
+	if ctrl&imstTimestamp != 0 {
+		size += 4
+	}
+	if ctrl&imstRssi != 0 {
+		size++
+	}
+	if ctrl&imstCrc != 0 {
+		size += 2
+	}
+	if start+size > len(data) {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+// End returns the length of the first frame in data.
+func (w WmbusImst) End(data []byte) (int, bool) {
+	start, size, ok := w.find(data)
+	return start + size, ok
+}
+
+// check verifies the CRC of the frame.
+func (w WmbusImst) check(frame []byte) ([]byte, []byte, bool) {
+	start, size, ok := w.find(frame)
+	if !ok || frame[start+1]&imstCrc == 0 {
+		return nil, nil, true
+	}
+	frame = frame[start : start+size]
+	// The CRC is calculated without the start of the frame.
+	expected := binary.LittleEndian.AppendUint16(nil, Crc16X25(frame[1:size-2]))
+	actual := frame[size-2:]
+	return expected, actual, bytes.Equal(expected, actual)
+}
+
+// Decode removes the bytes before the start of the frame and, if Unwrap is set, the header and trailer.
+func (w WmbusImst) Decode(frame []byte) []byte {
+	start, size, ok := w.find(frame)
+	if !ok {
+		return frame
+	}
+	frame = frame[start : start+size]
+	if w.Unwrap {
+		return frame[4 : 4+int(frame[3])]
+	}
+	return frame
+}
+
+// WmbusAmber is the EOP of the command frames of Amber style wM-Bus USB sticks, e.g. AMB8465.
+// The length of the frame is read from the header. The XOR checksum is verified and a mismatch is
+// notified with *ChecksumError. Bytes before the start of the frame are removed.
+// Use it as the EOP of SetEop or Receive.
+//
+//	media.SetEop(gxserial.WmbusAmber{Unwrap: true})
+type WmbusAmber struct {
+	// Unwrap removes the command header and the checksum and delivers only the payload, e.g. the received telegram.
+	Unwrap bool
+}
+
+// find returns the start of the first frame in data and the size of the frame.
+func (WmbusAmber) find(data []byte) (int, int, bool) {
+	start := bytes.IndexByte(data, amberSof)
+	if start == -1 || start+3 > len(data) {
+		return 0, 0, false
+	}
+	size := 4 + int(data[start+2])
+	if start+size > len(data) {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+// End returns the length of the first frame in data.
+func (w WmbusAmber) End(data []byte) (int, bool) {
+	start, size, ok := w.find(data)
+	return start + size, ok
+}
+
+// check verifies the checksum of the frame.
+func (w WmbusAmber) check(frame []byte) ([]byte, []byte, bool) {
+	start, size, ok := w.find(frame)
+	if !ok {
+		return nil, nil, true
+	}
+	frame = frame[start : start+size]
+	expected := []byte{Bcc(frame[:size-1])}
+	actual := frame[size-1:]
+	return expected, actual, bytes.Equal(expected, actual)
+}
+
+// Decode removes the bytes before the start of the frame and, if Unwrap is set, the header and the checksum.
+func (w WmbusAmber) Decode(frame []byte) []byte {
+	start, size, ok := w.find(frame)
+	if !ok {
+		return frame
+	}
+	frame = frame[start : start+size]
+	if w.Unwrap {
+		return frame[3 : size-1]
+	}
+	return frame
+}
@@ -0,0 +1,228 @@
+package config
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	gxserial "github.com/Gurux/gxserial-go"
+	"go.yaml.in/yaml/v3"
+)
+
+// document is the layout of the configuration documents.
+type document struct {
+	Port           setting `yaml:"port" toml:"port"`
+	BaudRate       setting `yaml:"baudRate" toml:"baudRate"`
+	DataBits       setting `yaml:"dataBits" toml:"dataBits"`
+	Parity         setting `yaml:"parity" toml:"parity"`
+	StopBits       setting `yaml:"stopBits" toml:"stopBits"`
+	Eop            eop     `yaml:"eop" toml:"eop"`
+	Trace          setting `yaml:"trace" toml:"trace"`
+	ConnectTimeout setting `yaml:"connectTimeout" toml:"connectTimeout"`
+	WriteTimeout   setting `yaml:"writeTimeout" toml:"writeTimeout"`
+}
+
+func newDocument(c *gxserial.Config) *document {
+	return &document{
+		Port:           setting{"port", c},
+		BaudRate:       setting{"baudRate", c},
+		DataBits:       setting{"dataBits", c},
+		Parity:         setting{"parity", c},
+		StopBits:       setting{"stopBits", c},
+		Eop:            eop{c},
+		Trace:          setting{"trace", c},
+		ConnectTimeout: setting{"connectTimeout", c},
+		WriteTimeout:   setting{"writeTimeout", c},
+	}
+}
+
+// setting is a scalar value of the configuration document.
+type setting struct {
+	name   string
+	config *gxserial.Config
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *setting) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d column %d: scalar value expected", node.Line, node.Column)
+	}
+	if err := s.config.SetValue(s.name, node.Value); err != nil {
+		return fmt.Errorf("line %d column %d: %w", node.Line, node.Column, err)
+	}
+	return nil
+}
+
+// UnmarshalTOML implements toml.Unmarshaler.
+// The decoder adds the position to the returned error.
+func (s *setting) UnmarshalTOML(value any) error {
+	switch value.(type) {
+	case string, int64, bool, float64:
+		return s.config.SetValue(s.name, fmt.Sprint(value))
+	}
+	return errors.New("scalar value expected")
+}
+
+// eop is the EOP of the configuration document.
+// It's a string, a byte or a list of bytes.
+type eop struct {
+	config *gxserial.Config
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *eop) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!int" {
+			e.config.Eop = node.Value
+			return nil
+		}
+		v, err := parseByte(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d column %d: %w", node.Line, node.Column, err)
+		}
+		e.config.Eop = v
+	case yaml.SequenceNode:
+		data := make([]byte, 0, len(node.Content))
+		for _, it := range node.Content {
+			v, err := parseByte(it.Value)
+			if err != nil {
+				return fmt.Errorf("line %d column %d: %w", it.Line, it.Column, err)
+			}
+			data = append(data, v)
+		}
+		e.config.Eop = data
+	default:
+		return fmt.Errorf("line %d column %d: string, byte or list of bytes expected", node.Line, node.Column)
+	}
+	return nil
+}
+
+// UnmarshalTOML implements toml.Unmarshaler.
+// The decoder adds the position to the returned error.
+func (e *eop) UnmarshalTOML(value any) error {
+	switch v := value.(type) {
+	case string:
+		e.config.Eop = v
+	case int64:
+		b, err := parseByte(strconv.FormatInt(v, 10))
+		if err != nil {
+			return err
+		}
+		e.config.Eop = b
+	case []any:
+		data := make([]byte, 0, len(v))
+		for _, it := range v {
+			b, err := parseByte(fmt.Sprint(it))
+			if err != nil {
+				return err
+			}
+			data = append(data, b)
+		}
+		e.config.Eop = data
+	default:
+		return errors.New("string, byte or list of bytes expected")
+	}
+	return nil
+}
+
+// parseByte parses a byte of the EOP. Hexadecimal values start with 0x.
+func parseByte(text string) (byte, error) {
+	v, err := strconv.ParseUint(text, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid EOP byte %q", text)
+	}
+	return byte(v), nil
+}
+
+// ReadYAML reads the configuration from a YAML document.
+// The settings that are not given use gxserial.DefaultConfig.
+// Unknown settings are rejected. Errors tell the line and the column of the invalid setting.
+func ReadYAML(r io.Reader) (*gxserial.Config, error) {
+	c := gxserial.DefaultConfig()
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(newDocument(c)); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ReadTOML reads the configuration from a TOML document.
+// The settings that are not given use gxserial.DefaultConfig.
+// Unknown settings are rejected. Errors tell the line and the column of the invalid setting.
+func ReadTOML(r io.Reader) (*gxserial.Config, error) {
+	c := gxserial.DefaultConfig()
+	md, err := toml.NewDecoder(r).Decode(newDocument(c))
+	if err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return nil, fmt.Errorf("line %d column %d: %s", pe.Position.Line, pe.Position.Col, pe.Message)
+		}
+		return nil, err
+	}
+	if keys := md.Undecoded(); len(keys) != 0 {
+		return nil, fmt.Errorf("unknown setting %q", keys[0].String())
+	}
+	return c, nil
+}
+
+// LoadFile reads the configuration from a YAML (.yaml, .yml) or TOML (.toml) file.
+func LoadFile(path string) (*gxserial.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c *gxserial.Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		c, err = ReadYAML(bytes.NewReader(data))
+	case ".toml":
+		c, err = ReadTOML(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unknown configuration file type: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
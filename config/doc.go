@@ -0,0 +1,16 @@
+/*
+Package config reads the gxserial configuration from YAML and TOML documents.
+
+The YAML and TOML parsers are kept in this module so that the gxserial module
+does not depend on them. The documents use the setting names of
+gxserial.Config.SetValue, e.g.
+
+	port: /dev/ttyUSB0
+	baudRate: 9600
+	parity: Even
+	eop: [0x0D, 0x0A]
+
+Unknown settings are rejected and the errors tell the line and the column of
+the invalid setting.
+*/
+package config
@@ -0,0 +1,17 @@
+module github.com/Gurux/gxserial-go/config
+
+go 1.25.5
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/Gurux/gxserial-go v1.0.2
+	go.yaml.in/yaml/v3 v3.0.4
+)
+
+require (
+	github.com/Gurux/gxcommon-go v1.0.9 // indirect
+	golang.org/x/sys v0.41.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
+)
+
+replace github.com/Gurux/gxserial-go => ../
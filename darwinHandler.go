@@ -40,6 +40,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 	"unsafe"
 
 	"github.com/Gurux/gxcommon-go"
@@ -51,6 +53,37 @@ type port struct {
 	fd int
 	r  *os.File
 	w  *os.File
+	// Time when the data of the last read arrived.
+	readTime time.Time
+	// Baud rate that is set with IOSSIOSPEED. Zero if a standard baud rate is used.
+	customBaud int
+	// Logger of the media.
+	log portLogger
+}
+
+// iossioSpeed is the IOSSIOSPEED ioctl of IOKit/serial/ioss.h that sets an arbitrary baud rate.
+const iossioSpeed = 0x80085402
+
+// customBaudPlaceholder is the speed of termios when the baud rate is set with IOSSIOSPEED.
+// tcsetattr fails with a non-standard speed.
+const customBaudPlaceholder = unix.B9600
+
+// applyTermios sets the termios and the custom baud rate. tcsetattr resets the custom baud rate,
+// so it's set again after each tcsetattr.
+func (p *port) applyTermios(fd int, t *unix.Termios) error {
+	if p.customBaud != 0 {
+		t.Ispeed = customBaudPlaceholder
+		t.Ospeed = customBaudPlaceholder
+	}
+	if err := unix.IoctlSetTermios(fd, unix.TIOCSETA, t); err != nil {
+		return err
+	}
+	if p.customBaud != 0 {
+		if err := ioctlSetIntPointer(fd, iossioSpeed, p.customBaud); err != nil {
+			return fmt.Errorf("IOSSIOSPEED failed: %w", err)
+		}
+	}
+	return nil
 }
 
 // toUnitBaudrate maps a baud rate to the corresponding constant in the mac package.
@@ -73,6 +106,7 @@ var toUnitBaudrate = map[int]uint32{
 	38400:  unix.B38400,
 	57600:  unix.B57600,
 	115200: unix.B115200,
+	230400: unix.B230400,
 }
 
 // getPortNames returns a list of available serial port device paths on macOS.
@@ -100,26 +134,46 @@ func getPortNames() ([]string, error) {
 }
 
 func openPort(cfg *GXSerial) error {
-	fd, err := unix.Open(cfg.Port, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0666)
+	mode := unix.O_RDWR
+	if cfg.readOnly {
+		mode = unix.O_RDONLY
+	}
+	fd, err := unix.Open(cfg.Port, mode|unix.O_NOCTTY|unix.O_NONBLOCK, 0666)
 	if err != nil {
-		return err
+		return cfg.openFailed("open", err, err)
 	}
+	cfg.openStep("open", "%s fd %d", cfg.Port, fd)
 
 	f := os.NewFile(uintptr(fd), cfg.Port)
-	cfg.s = port{f: f, fd: fd}
+	cfg.s = port{f: f, fd: fd, log: &cfg.logger}
 
 	// (iflag, oflag, cflag, lflag, ispeed, ospeed, cc) = tcgetattr
 	t, err := unix.IoctlGetTermios(fd, unix.TIOCGETA)
 	if err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("tcgetattr", err, err)
 	}
+	cfg.openStep("tcgetattr", "iflag %#x oflag %#x cflag %#x lflag %#x", t.Iflag, t.Oflag, t.Cflag, t.Lflag)
 	t.Cflag |= unix.CLOCAL | unix.CREAD
+	if cfg.readOnly {
+		// Control lines are not dropped when the port is closed.
+		t.Cflag &^= unix.HUPCL
+	}
 	t.Lflag &^= unix.ICANON | unix.ECHO | unix.ECHOE | unix.ECHOK | unix.ECHONL | unix.ISIG | unix.IEXTEN
 	t.Oflag &^= unix.OPOST | unix.ONLCR | unix.OCRNL
 	t.Iflag &^= unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IGNBRK
 	// Baud rate:
-	speed := toUnitBaudrate[int(cfg.baudRate)]
+	// Non-standard baud rates are set with IOSSIOSPEED.
+	if cfg.baudRate <= 0 {
+		cfg.s.close()
+		err = fmt.Errorf("open failed. unsupported baud: %d", cfg.baudRate)
+		return cfg.openFailed("baud rate", err, err)
+	}
+	speed, ok := toUnitBaudrate[int(cfg.baudRate)]
+	cfg.s.customBaud = 0
+	if !ok {
+		cfg.s.customBaud = int(cfg.baudRate)
+	}
 	t.Ispeed = uint64(speed)
 	t.Ospeed = uint64(speed)
 	// Databits:
@@ -135,7 +189,8 @@ func openPort(cfg *GXSerial) error {
 		t.Cflag |= unix.CS8
 	default:
 		cfg.s.close()
-		return errors.New("invalid databits (must be 5..8)")
+		err = errors.New("invalid databits (must be 5..8)")
+		return cfg.openFailed("data bits", err, err)
 	}
 
 	// Stop bits
@@ -144,9 +199,15 @@ func openPort(cfg *GXSerial) error {
 		t.Cflag &^= unix.CSTOPB
 	case 2:
 		t.Cflag |= unix.CSTOPB
+	case gxcommon.StopBitsOnePointFive:
+		// termios has no 1.5 stop bits.
+		cfg.s.close()
+		err = cfg.unsupported("StopBits", cfg.stopBits)
+		return cfg.openFailed("stop bits", err, err)
 	default:
 		cfg.s.close()
-		return errors.New("invalid stopbits (must be 1 or 2)")
+		err = errors.New("invalid stopbits (must be 1 or 2)")
+		return cfg.openFailed("stop bits", err, err)
 	}
 
 	// setup parity
@@ -176,37 +237,52 @@ func openPort(cfg *GXSerial) error {
 	case gxcommon.ParityMark:
 		if !hasCMSPAR {
 			cfg.s.close()
-			return errors.New("mark parity requested but CMSPAR not supported")
+			err = errors.New("mark parity requested but CMSPAR not supported")
+			return cfg.openFailed("parity", err, err)
 		}
 		t.Cflag |= unix.PARENB | CMSPAR | unix.PARODD
 	case gxcommon.ParitySpace:
 		if !hasCMSPAR {
 			cfg.s.close()
-			return errors.New("space parity requested but CMSPAR not supported")
+			err = errors.New("space parity requested but CMSPAR not supported")
+			return cfg.openFailed("parity", err, err)
 		}
 		t.Cflag |= unix.PARENB | CMSPAR
 		t.Cflag &^= unix.PARODD
 	default:
 		cfg.s.close()
-		return errors.New("invalid parity")
+		err = errors.New("invalid parity")
+		return cfg.openFailed("parity", err, err)
 	}
 
-	t.Iflag &^= unix.IXON | unix.IXOFF
-	t.Cflag &^= unix.CRTSCTS
-	if err := unix.IoctlSetTermios(fd, unix.TIOCSETA, t); err != nil {
+	setHandshake(t, cfg.handshake)
+	if err := cfg.s.applyTermios(fd, t); err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("tcsetattr", err, err)
+	}
+	if cfg.openDiagnostics {
+		if applied, err := unix.IoctlGetTermios(fd, unix.TIOCGETA); err == nil {
+			cfg.openStep("tcsetattr", "requested cflag %#x speed %d, applied cflag %#x speed %d",
+				t.Cflag, t.Ospeed, applied.Cflag, applied.Ospeed)
+		}
 	}
 	if err := ioctlSetIntPointer(fd, unix.TIOCFLUSH, unix.TCIOFLUSH); err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("tcflush", err, err)
 	}
+	cfg.openStep("tcflush", "input and output queues flushed")
 	cfg.s.r, cfg.s.w, err = os.Pipe()
 	if err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("pipe", err, err)
+	}
+	cfg.s.logError("set non-blocking failed", unix.SetNonblock(int(cfg.s.r.Fd()), true))
+	if cfg.openDiagnostics {
+		if state, err := cfg.s.dumpState(); err == nil {
+			cfg.openStep("control lines", "RTS %t DTR %t CTS %t DSR %t DCD %t RI %t",
+				state.Rts, state.Dtr, state.Modem.Cts, state.Modem.Dsr, state.Modem.Dcd, state.Modem.Ri)
+		}
 	}
-	_ = unix.SetNonblock(int(cfg.s.r.Fd()), true)
 	return nil
 }
 
@@ -219,16 +295,85 @@ func ioctlSetIntPointer(fd int, req uint, value int) error {
 	return nil
 }
 
+// writev writes the buffers with a single writev call. Partial writes are continued.
+func (p *port) writev(bufs [][]byte) (int, error) {
+	if err := p.ensureOpen(); err != nil {
+		return 0, err
+	}
+	rc, err := p.f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	bufs = append([][]byte(nil), bufs...)
+	total := 0
+	var werr error
+	err = rc.Write(func(fd uintptr) bool {
+		for len(bufs) != 0 {
+			n, e := unix.Writev(int(fd), bufs)
+			if e == unix.EAGAIN {
+				// Wait until the port is writable.
+				return false
+			}
+			if e == unix.EINTR {
+				continue
+			}
+			if e != nil {
+				werr = e
+				return true
+			}
+			total += n
+			bufs = consumeBuffers(bufs, n)
+		}
+		return true
+	})
+	if werr != nil {
+		return total, werr
+	}
+	return total, err
+}
+
+// isTransientError returns true if the read can be retried after the error.
+func isTransientError(err error) bool {
+	return errors.Is(err, unix.EINTR) || errors.Is(err, unix.EAGAIN)
+}
+
+// isLineErrorAbort returns true if the driver aborted the read because of a line error.
+// Termios doesn't abort the reads, see setAbortOnError.
+func isLineErrorAbort(error) bool {
+	return false
+}
+
+// clearError clears the error state of the port. Nothing is needed on this system.
+func (p *port) clearError() error {
+	return nil
+}
+
+// setWriteDeadline sets the deadline for the following writes. Zero time disables the deadline.
+func (p *port) setWriteDeadline(t time.Time) error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	return p.f.SetWriteDeadline(t)
+}
+
+// interrupt wakes up the pending read so that the reader can stop before the port is closed.
+func (p *port) interrupt() {
+	if p != nil && p.w != nil {
+		_, err := p.w.Write([]byte{0})
+		p.logError("interrupt read failed", err)
+	}
+}
+
 func (p *port) close() error {
 	if p == nil {
 		return nil
 	}
 	if p.r != nil {
-		_ = p.r.Close()
+		p.logError("close pipe failed", p.r.Close())
 		p.r = nil
 	}
 	if p.w != nil {
-		_ = p.w.Close()
+		p.logError("close pipe failed", p.w.Close())
 		p.w = nil
 	}
 	if p.f != nil {
@@ -244,6 +389,31 @@ func (p *port) isOpen() bool {
 	return p.f != nil
 }
 
+// supportedBaudRates returns the baud rates of toUnitBaudrate and the high baud rates
+// that are set with IOSSIOSPEED in ascending order.
+func supportedBaudRates() []gxcommon.BaudRate {
+	var ret []gxcommon.BaudRate
+	for k := range toUnitBaudrate {
+		if k != 0 {
+			ret = append(ret, gxcommon.BaudRate(k))
+		}
+	}
+	ret = append(ret, highBaudRates...)
+	slices.Sort(ret)
+	return slices.Compact(ret)
+}
+
+// capabilities returns the capabilities of the platform.
+func (p *port) capabilities() Capabilities {
+	return Capabilities{
+		CustomBaudRate: true,
+		ControlLines:   true,
+		ModemStatus:    true,
+		Break:          true,
+		BaudRates:      supportedBaudRates(),
+	}
+}
+
 func (p *port) ensureOpen() error {
 	if p == nil || p.f == nil {
 		return errors.New("serial port not open")
@@ -266,7 +436,7 @@ func (p *port) setTermios(value *unix.Termios) error {
 	if err := p.ensureOpen(); err != nil {
 		return err
 	}
-	if err := unix.IoctlSetTermios(p.fd, unix.TIOCSETA, value); err != nil {
+	if err := p.applyTermios(p.fd, value); err != nil {
 		return fmt.Errorf("tcsetattr failed: %w", err)
 	}
 	return nil
@@ -277,13 +447,21 @@ func (p *port) setBaudRate(value gxcommon.BaudRate) error {
 	if err != nil {
 		return fmt.Errorf("setBaudRate failed. %w", err)
 	}
-	u := toUnitBaudrate[int(value)]
-	if u == 0 {
+	if value <= 0 {
 		return fmt.Errorf("setBaudRate failed. unsupported baud: %d", value)
 	}
+	old := p.customBaud
+	u, ok := toUnitBaudrate[int(value)]
+	p.customBaud = 0
+	if !ok {
+		p.customBaud = int(value)
+	}
 	t.Ispeed = uint64(u)
 	t.Ospeed = uint64(u)
-	return p.setTermios(t)
+	if err = p.setTermios(t); err != nil {
+		p.customBaud = old
+	}
+	return err
 }
 
 func (p *port) setDataBits(value int) error {
@@ -326,6 +504,98 @@ func (p *port) setParity(value gxcommon.Parity) error {
 	return p.setTermios(t)
 }
 
+// setNineBitReceive returns an error because mark and space parity are not supported.
+func (p *port) setNineBitReceive(bool) error {
+	return errors.New("9-bit receive not supported on this system")
+}
+
+func (p *port) setLoopback(bool) error {
+	return errors.New("loopback mode not supported on this system")
+}
+
+func (p *port) setLowLatency(bool) error {
+	return errors.New("low latency mode not supported on this system")
+}
+
+func (p *port) setRS485(*RS485) error {
+	return errors.New("RS-485 mode not supported on this system")
+}
+
+// setHandshake sets the flow control flags of the termios.
+func setHandshake(t *unix.Termios, value Handshake) {
+	t.Iflag &^= unix.IXON | unix.IXOFF
+	t.Cflag &^= unix.CRTSCTS
+	if value.rtsCts() {
+		t.Cflag |= unix.CRTSCTS
+	}
+	if value.xonXoff() {
+		t.Iflag |= unix.IXON | unix.IXOFF
+		t.Cc[unix.VSTART] = 0x11
+		t.Cc[unix.VSTOP] = 0x13
+	}
+}
+
+// setHandshake sets the flow control of the opened port.
+func (p *port) setHandshake(value Handshake) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return err
+	}
+	setHandshake(t, value)
+	return p.setTermios(t)
+}
+
+// setDiscardNull returns an error because termios can't discard the null bytes.
+func (p *port) setDiscardNull(bool) error {
+	return errors.New("null byte discarding not supported on this system")
+}
+
+// setAbortOnError returns an error because termios can't abort the pending I/O on a line error.
+func (p *port) setAbortOnError(bool) error {
+	return errors.New("abort on error not supported on this system")
+}
+
+// parityMarking is true if the driver marks the received bytes with a parity error
+// and the marks are replaced with the parity error character.
+const parityMarking = true
+
+// setParityErrorChar enables or disables marking of the received bytes with a parity error.
+// The bytes with a parity error are prefixed with 0xFF 0x00 and 0xFF bytes are doubled.
+func (p *port) setParityErrorChar(on bool, _ byte) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return fmt.Errorf("setParityErrorChar failed. %w", err)
+	}
+	if on {
+		t.Iflag &^= unix.IGNPAR | unix.ISTRIP
+		t.Iflag |= unix.INPCK | unix.PARMRK
+	} else {
+		t.Iflag &^= unix.INPCK | unix.PARMRK
+	}
+	return p.setTermios(t)
+}
+
+// setBreakDetection enables or disables marking of the received breaks.
+// A break is received as 0xFF 0x00 0x00 and 0xFF bytes are doubled.
+func (p *port) setBreakDetection(on bool) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return fmt.Errorf("setBreakDetection failed. %w", err)
+	}
+	if on {
+		t.Iflag &^= unix.IGNBRK | unix.BRKINT
+		t.Iflag |= unix.PARMRK
+	} else {
+		t.Iflag &^= unix.PARMRK
+	}
+	return p.setTermios(t)
+}
+
+// takeBreak returns false because the breaks are marked in the received data.
+func (p *port) takeBreak() (bool, error) {
+	return false, nil
+}
+
 func (p *port) getStopBits() (int, error) {
 	t, err := p.getTermios()
 	if err != nil {
@@ -392,6 +662,17 @@ func (p *port) setRtsEnable(on bool) error {
 	return p.setModemBit(unix.TIOCM_RTS, on)
 }
 
+// rtsToggleDriver is false because the driver can't toggle RTS and the application does it.
+const rtsToggleDriver = false
+
+// setRtsToggle lowers RTS when the application starts to toggle it.
+func (p *port) setRtsToggle(on bool) error {
+	if !on {
+		return nil
+	}
+	return p.setRtsEnable(false)
+}
+
 func (p *port) getDtrEnable() (bool, error) {
 	if err := p.ensureOpen(); err != nil {
 		return false, err
@@ -407,6 +688,140 @@ func (p *port) setDtrEnable(on bool) error {
 	return p.setModemBit(unix.TIOCM_DTR, on)
 }
 
+// waitModemChange returns errModemWaitUnsupported because macOS has no TIOCMIWAIT.
+func (p *port) waitModemChange() error {
+	return errModemWaitUnsupported
+}
+
+// setBreak starts or ends the break condition.
+func (p *port) setBreak(on bool) error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	req := uint(unix.TIOCCBRK)
+	if on {
+		req = unix.TIOCSBRK
+	}
+	if err := unix.IoctlSetInt(p.fd, req, 0); err != nil {
+		return fmt.Errorf("setBreak failed: %w", err)
+	}
+	return nil
+}
+
+func (p *port) getModemStatus() (ModemStatus, error) {
+	if err := p.ensureOpen(); err != nil {
+		return ModemStatus{}, err
+	}
+	status, err := unix.IoctlGetInt(p.fd, unix.TIOCMGET)
+	if err != nil {
+		return ModemStatus{}, fmt.Errorf("getModemStatus failed: %w", err)
+	}
+	return ModemStatus{
+		Cts: (status & unix.TIOCM_CTS) != 0,
+		Dsr: (status & unix.TIOCM_DSR) != 0,
+		Dcd: (status & unix.TIOCM_CD) != 0,
+		Ri:  (status & unix.TIOCM_RI) != 0,
+	}, nil
+}
+
+// dumpState returns the effective termios flags, control line states and queue levels.
+func (p *port) dumpState() (*PortState, error) {
+	t, err := p.getTermios()
+	if err != nil {
+		return nil, err
+	}
+	ret := &PortState{}
+	ret.add("c_iflag", "%#x", t.Iflag)
+	ret.add("c_oflag", "%#x", t.Oflag)
+	ret.add("c_cflag", "%#x", t.Cflag)
+	ret.add("c_lflag", "%#x", t.Lflag)
+	ret.add("ispeed", "%d", t.Ispeed)
+	ret.add("ospeed", "%d", t.Ospeed)
+	ret.add("CSIZE", "%#x", t.Cflag&unix.CSIZE)
+	ret.add("CSTOPB", "%t", t.Cflag&unix.CSTOPB != 0)
+	ret.add("PARENB", "%t", t.Cflag&unix.PARENB != 0)
+	ret.add("PARODD", "%t", t.Cflag&unix.PARODD != 0)
+	ret.add("CRTSCTS", "%t", t.Cflag&unix.CRTSCTS != 0)
+	ret.add("IXON", "%t", t.Iflag&unix.IXON != 0)
+	ret.add("IXOFF", "%t", t.Iflag&unix.IXOFF != 0)
+	if ret.Rts, err = p.getRtsEnable(); err != nil {
+		return nil, err
+	}
+	if ret.Dtr, err = p.getDtrEnable(); err != nil {
+		return nil, err
+	}
+	if ret.Modem, err = p.getModemStatus(); err != nil {
+		return nil, err
+	}
+	if ret.BytesToRead, err = p.getBytesToRead(); err != nil {
+		return nil, err
+	}
+	if ret.BytesToWrite, err = p.getBytesToWrite(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// getFlowStatus returns why the transmission is held off.
+// POSIX has no query for the XOFF state. If IXON is set and the output queue doesn't drain during
+// the sample time while CTS doesn't hold the transmission, XOFF is assumed to be received.
+func (p *port) getFlowStatus(sample time.Duration) (FlowStatus, error) {
+	t, err := p.getTermios()
+	if err != nil {
+		return FlowStatus{}, err
+	}
+	pending, err := p.getBytesToWrite()
+	if err != nil {
+		return FlowStatus{}, err
+	}
+	ret := FlowStatus{Pending: pending}
+	if pending == 0 {
+		return ret, nil
+	}
+	if t.Cflag&unix.CRTSCTS != 0 {
+		status, err := p.getModemStatus()
+		if err != nil {
+			return FlowStatus{}, err
+		}
+		ret.CtsHold = !status.Cts
+	}
+	if !ret.CtsHold && t.Iflag&unix.IXON != 0 {
+		time.Sleep(sample)
+		if ret.Pending, err = p.getBytesToWrite(); err != nil {
+			return FlowStatus{}, err
+		}
+		ret.XoffHold = ret.Pending != 0 && ret.Pending >= pending
+	}
+	return ret, nil
+}
+
+// waitTxEmpty waits until the last byte is shifted out of the transmitter.
+// The output queue is polled and one character time is waited after it's empty.
+func (p *port) waitTxEmpty(charTime time.Duration) error {
+	for {
+		n, err := p.getBytesToWrite()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			time.Sleep(charTime)
+			return nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// flush discards the data in the input and output queues.
+func (p *port) flush() error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	if err := ioctlSetIntPointer(p.fd, unix.TIOCFLUSH, unix.TCIOFLUSH); err != nil {
+		return fmt.Errorf("tcflush failed: %w", err)
+	}
+	return nil
+}
+
 func (p *port) setModemBit(bit int, on bool) error {
 	if err := p.ensureOpen(); err != nil {
 		return err
@@ -443,6 +858,8 @@ func (p *port) read() ([]byte, error) {
 	if (pfds[1].Revents & unix.POLLIN) != 0 {
 		return nil, nil
 	}
+	// The data is timestamped as soon as the poll wakes up.
+	arrived := time.Now()
 
 	cnt, _ := p.getBytesToRead()
 	if cnt <= 0 {
@@ -459,8 +876,10 @@ func (p *port) read() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		p.readTime = arrived
 		return append(buf[:n], ret...), nil
 	}
+	p.readTime = arrived
 	return buf[:n], nil
 }
 
@@ -0,0 +1,115 @@
+//go:build darwin && !cgo
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// IOKit service notifications need cgo, see darwinIOKit.go. Without cgo the device is matched only
+// by the name of its device node: changes of /dev are watched with kqueue NOTE_WRITE and
+// the existence of the node of the port is checked after each change. A different device
+// that gets the same node name is taken as the same device.
+
+// watchPollInterval is the interval in which the device watcher checks if it's stopped.
+const watchPollInterval = 250 * time.Millisecond
+
+// deviceSerial returns the serial number of the serial port device. It's not available without cgo.
+// The device is matched by the name of the device node.
+func deviceSerial(string) string {
+	return ""
+}
+
+// watchDevice watches the device node of the serial port. The device isn't identified,
+// only the node name is followed. It returns the function that stops the watching.
+func watchDevice(g *GXSerial) (func(), error) {
+	dir, err := unix.Open("/dev", unix.O_RDONLY|unix.O_EVTONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	kq, err := unix.Kqueue()
+	if err != nil {
+		_ = unix.Close(dir)
+		return nil, err
+	}
+	var ev unix.Kevent_t
+	unix.SetKevent(&ev, dir, unix.EVFILT_VNODE, unix.EV_ADD|unix.EV_CLEAR)
+	ev.Fflags = unix.NOTE_WRITE
+	if _, err = unix.Kevent(kq, []unix.Kevent_t{ev}, nil, nil); err != nil {
+		_ = unix.Close(kq)
+		_ = unix.Close(dir)
+		return nil, err
+	}
+	port := g.Port
+	var stopped atomic.Bool
+	go func() {
+		defer unix.Close(dir)
+		defer unix.Close(kq)
+		// Kevent times out so that the stop is noticed.
+		timeout := unix.NsecToTimespec(int64(watchPollInterval))
+		events := make([]unix.Kevent_t, 1)
+		present := true
+		for !stopped.Load() {
+			n, err := unix.Kevent(kq, nil, events, &timeout)
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			if err != nil {
+				g.errorf(true, "hotplug", err)
+				return
+			}
+			if n == 0 || stopped.Load() {
+				continue
+			}
+			_, err = os.Stat(port)
+			exists := err == nil
+			if present && !exists {
+				g.deviceRemoved()
+			} else if !present && exists {
+				g.deviceAttached(port)
+			}
+			present = exists
+		}
+	}()
+	return func() {
+		stopped.Store(true)
+	}, nil
+}
@@ -0,0 +1,261 @@
+//go:build darwin && cgo
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+
+/*
+#cgo LDFLAGS: -framework IOKit -framework CoreFoundation
+#include <stdint.h>
+#include <stdlib.h>
+#include <CoreFoundation/CoreFoundation.h>
+#include <IOKit/IOKitLib.h>
+#include <IOKit/serial/IOSerialKeys.h>
+
+extern void gxServicesMatched(uintptr_t handle, unsigned int iterator, int attached);
+
+static void gxAttached(void *refcon, io_iterator_t iterator) {
+	gxServicesMatched((uintptr_t)refcon, iterator, 1);
+}
+
+static void gxRemoved(void *refcon, io_iterator_t iterator) {
+	gxServicesMatched((uintptr_t)refcon, iterator, 0);
+}
+
+// gxSerialServices returns the iterator of the serial port services.
+static kern_return_t gxSerialServices(io_iterator_t *services) {
+	return IOServiceGetMatchingServices(MACH_PORT_NULL, IOServiceMatching(kIOSerialBSDServiceValue), services);
+}
+
+// gxWatch adds the notifications of the attached and removed serial port services
+// to the run loop of the current thread.
+static kern_return_t gxWatch(IONotificationPortRef notify, uintptr_t handle, io_iterator_t *attached,
+	io_iterator_t *removed) {
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), IONotificationPortGetRunLoopSource(notify), kCFRunLoopDefaultMode);
+	kern_return_t ret = IOServiceAddMatchingNotification(notify, kIOFirstMatchNotification,
+		IOServiceMatching(kIOSerialBSDServiceValue), gxAttached, (void *)handle, attached);
+	if (ret == KERN_SUCCESS) {
+		ret = IOServiceAddMatchingNotification(notify, kIOTerminatedNotification,
+			IOServiceMatching(kIOSerialBSDServiceValue), gxRemoved, (void *)handle, removed);
+	}
+	return ret;
+}
+
+// gxRun runs the run loop of the current thread for the given time.
+static void gxRun(double seconds) {
+	CFRunLoopRunInMode(kCFRunLoopDefaultMode, seconds, false);
+}
+
+// gxString copies the string property of the service to buf. If parents is set, the parents
+// of the service are searched as well. Zero is returned if the property isn't found.
+static int gxString(io_object_t service, const char *key, int parents, char *buf, int size) {
+	CFStringRef name = CFStringCreateWithCString(kCFAllocatorDefault, key, kCFStringEncodingUTF8);
+	if (name == NULL) {
+		return 0;
+	}
+	CFTypeRef value;
+	if (parents) {
+		value = IORegistryEntrySearchCFProperty(service, kIOServicePlane, name, kCFAllocatorDefault,
+			kIORegistryIterateRecursively | kIORegistryIterateParents);
+	} else {
+		value = IORegistryEntryCreateCFProperty(service, name, kCFAllocatorDefault, 0);
+	}
+	CFRelease(name);
+	if (value == NULL) {
+		return 0;
+	}
+	int ret = CFGetTypeID(value) == CFStringGetTypeID() &&
+		CFStringGetCString((CFStringRef)value, buf, size, kCFStringEncodingUTF8);
+	CFRelease(value);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/cgo"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// The attached and removed devices are followed with the IOKit service notifications of IOSerialBSDClient.
+// The device is matched by the USB serial number when it's known and otherwise by the name of its device node.
+
+// watchPollInterval is the interval in which the device watcher checks if it's stopped.
+const watchPollInterval = 250 * time.Millisecond
+
+// Registry keys of the serial port services.
+const (
+	calloutDeviceKey   = "IOCalloutDevice"
+	dialinDeviceKey    = "IODialinDevice"
+	usbSerialNumberKey = "USB Serial Number"
+)
+
+// registryString returns the string property of the service. Empty if it's not found.
+// If parents is true, the parents of the service are searched as well.
+func registryString(service C.io_object_t, key string, parents bool) string {
+	name := C.CString(key)
+	defer C.free(unsafe.Pointer(name))
+	var search C.int
+	if parents {
+		search = 1
+	}
+	var buf [256]C.char
+	if C.gxString(service, name, search, &buf[0], C.int(len(buf))) == 0 {
+		return ""
+	}
+	return C.GoString(&buf[0])
+}
+
+// deviceSerial returns the USB serial number of the serial port device. Empty if it's unknown.
+func deviceSerial(port string) string {
+	var services C.io_iterator_t
+	if C.gxSerialServices(&services) != C.KERN_SUCCESS {
+		return ""
+	}
+	defer C.IOObjectRelease(C.io_object_t(services))
+	ret := ""
+	for service := C.IOIteratorNext(services); service != 0; service = C.IOIteratorNext(services) {
+		if ret == "" && (registryString(service, calloutDeviceKey, false) == port ||
+			registryString(service, dialinDeviceKey, false) == port) {
+			ret = registryString(service, usbSerialNumberKey, true)
+		}
+		C.IOObjectRelease(service)
+	}
+	return ret
+}
+
+// ioKitWatcher follows the serial port services of the device.
+type ioKitWatcher struct {
+	g      *GXSerial
+	port   string
+	serial string
+	// Is the port the dial-in device node, e.g. /dev/tty.usbserial.
+	dialin  bool
+	stopped atomic.Bool
+}
+
+// watchDevice follows the IOKit notifications of the serial port device.
+// It returns the function that stops the watcher.
+func watchDevice(g *GXSerial) (func(), error) {
+	w := &ioKitWatcher{g: g, port: g.Port, serial: g.deviceSerial,
+		dialin: strings.HasPrefix(filepath.Base(g.Port), "tty.")}
+	result := make(chan error, 1)
+	go w.run(result)
+	if err := <-result; err != nil {
+		return nil, err
+	}
+	return func() {
+		w.stopped.Store(true)
+	}, nil
+}
+
+// run adds the notifications and runs the run loop until the watcher is stopped.
+// The run loop belongs to the thread, so the goroutine is locked to it.
+func (w *ioKitWatcher) run(result chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	h := cgo.NewHandle(w)
+	defer h.Delete()
+	notify := C.IONotificationPortCreate(C.MACH_PORT_NULL)
+	if notify == nil {
+		result <- errors.New("IONotificationPortCreate failed")
+		return
+	}
+	defer C.IONotificationPortDestroy(notify)
+	var attached, removed C.io_iterator_t
+	ret := C.gxWatch(notify, C.uintptr_t(h), &attached, &removed)
+	defer C.IOObjectRelease(C.io_object_t(attached))
+	defer C.IOObjectRelease(C.io_object_t(removed))
+	if ret != C.KERN_SUCCESS {
+		result <- fmt.Errorf("IOServiceAddMatchingNotification failed: %#x", uint32(ret))
+		return
+	}
+	// The notifications are armed when the services that already exist are iterated.
+	releaseServices(attached)
+	releaseServices(removed)
+	result <- nil
+	// The run loop times out so that the stop is noticed.
+	for !w.stopped.Load() {
+		C.gxRun(C.double(watchPollInterval.Seconds()))
+	}
+}
+
+// releaseServices releases the services of the iterator without handling them.
+func releaseServices(services C.io_iterator_t) {
+	for service := C.IOIteratorNext(services); service != 0; service = C.IOIteratorNext(services) {
+		C.IOObjectRelease(service)
+	}
+}
+
+// matched handles the attached or removed serial port services of the iterator.
+func (w *ioKitWatcher) matched(iterator uint32, attached bool) {
+	services := C.io_iterator_t(iterator)
+	for service := C.IOIteratorNext(services); service != 0; service = C.IOIteratorNext(services) {
+		if !w.stopped.Load() {
+			w.changed(service, attached)
+		}
+		C.IOObjectRelease(service)
+	}
+}
+
+// changed handles the attached or removed serial port service.
+func (w *ioKitWatcher) changed(service C.io_object_t, attached bool) {
+	key := calloutDeviceKey
+	if w.dialin {
+		key = dialinDeviceKey
+	}
+	port := registryString(service, key, false)
+	if port == "" {
+		return
+	}
+	if !attached {
+		if port == w.port {
+			w.g.deviceRemoved()
+		}
+		return
+	}
+	if w.serial != "" && port != w.port && registryString(service, usbSerialNumberKey, true) == w.serial {
+		// The device got a new name.
+		w.port = port
+		w.g.deviceAttached(port)
+	} else if port == w.port && (w.serial == "" || registryString(service, usbSerialNumberKey, true) == w.serial) {
+		w.g.deviceAttached(port)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build darwin && cgo
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+
+// #include <stdint.h>
+import "C"
+
+import "runtime/cgo"
+
+// gxServicesMatched is called by the IOKit notifications of darwinIOKit.go.
+// The callback is in its own file because the preamble of the file that exports a function
+// can't contain the C definitions.
+//
+//export gxServicesMatched
+func gxServicesMatched(handle C.uintptr_t, services C.uint, attached C.int) {
+	cgo.Handle(handle).Value().(*ioKitWatcher).matched(uint32(services), attached != 0)
+}
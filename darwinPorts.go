@@ -0,0 +1,60 @@
+//go:build darwin
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import "strings"
+
+// getPortsInfo returns the serial ports.
+// The device metadata is in the IOKit registry that can't be read without cgo, so only the names are returned.
+func getPortsInfo() ([]PortInfo, error) {
+	names, err := getPortNames()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]PortInfo, 0, len(names))
+	for _, name := range names {
+		ret = append(ret, PortInfo{Name: name})
+	}
+	return ret, nil
+}
+
+// devicePath returns the device path of the serial port name, e.g. /dev/cu.usbserial for cu.usbserial.
+func devicePath(name string) string {
+	if strings.Contains(name, "/") {
+		return name
+	}
+	return "/dev/" + name
+}
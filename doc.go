@@ -8,8 +8,11 @@
 //   - Configurable serial settings (port, baud rate, data bits, parity, stop bits)
 //   - Synchronous request/response and asynchronous receive callbacks
 //   - Framing: optional EOP (End Of Packet) marker (byte, string or []byte).
-//   - Timeouts: connection and I/O timeouts via time.Duration.
+//   - Timeouts: connection, write and receive timeouts via time.Duration.
 //   - Tracing: configurable trace level/mask for sent/received/error/info.
+//   - Profiles: named configurations saved to a file with ProfileStore.
+//   - Configuration: YAML and TOML documents (config.LoadFile in the config module), environment variables (FromEnv)
+//     or the device:baud,format shorthand, e.g. COM3:9600,8N1 (NewGXSerialFromString).
 //   - Events: Received, Error, Trace and MediaState callbacks.
 //   - Concurrency: safe for concurrent reads/writes; Close unblocks pending I/O.
 //
@@ -41,7 +44,10 @@
 //
 // When an EOP is configured, incoming bytes are buffered until the marker is
 // observed. The marker can be a single byte (e.g. 0x7E), a string (e.g. "OK"),
-// or an arbitrary byte slice. Disable EOP to read raw stream data.
+// or an arbitrary byte slice. For other terminators the EOP can be a
+// func([]byte) (end int, ok bool) predicate that returns the length of the
+// first complete frame. Use NewPatterns when the reply can end with one of several
+// patterns; Matched tells which one ended the reply. Disable EOP to read raw stream data.
 //
 // # Errors and timeouts
 //
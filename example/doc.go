@@ -6,5 +6,7 @@ The example shows how to:
   - register media callbacks (trace, state, error, receive)
   - send a message
   - read a synchronous reply using EOP-based receive parameters
+  - list the available serial ports with the "list" subcommand,
+    e.g. "gxserial-example-go list -json"
 */
 package main
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Gurux/gxserial-go"
+)
+
+// listPorts prints the available serial ports as a table or as JSON.
+func listPorts(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the ports as JSON.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ports, err := gxserial.GetPortsInfo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to get available serial ports:", err)
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if ports == nil {
+			ports = []gxserial.PortInfo{}
+		}
+		if err := enc.Encode(ports); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT\tVID:PID\tDESCRIPTION\tMANUFACTURER\tSERIAL\tVIRTUAL\tBUSY")
+	for _, it := range ports {
+		ids := "-"
+		if it.IsUSB() {
+			ids = fmt.Sprintf("%04X:%04X", it.VID, it.PID)
+		}
+		virtual := "-"
+		if it.Bus != "" {
+			virtual = it.Bus
+		} else if it.Virtual {
+			virtual = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%t\n", it.Name, ids, dash(it.Description),
+			dash(it.Manufacturer), dash(it.SerialNumber), virtual, it.Busy)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+// dash returns "-" for an empty value.
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -23,6 +23,9 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "list" {
+		os.Exit(listPorts(os.Args[2:]))
+	}
 	flag.Parse()
 	if *port == "" || *message == "" {
 		flag.PrintDefaults()
@@ -63,6 +66,8 @@ func main() {
 		fmt.Printf("Trace: %s\n", e.String())
 	})
 
+	media.SetEop("\n")
+	media.SetLineTermination(gxserial.LineTerminationLF)
 	err = media.Validate()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
@@ -112,9 +117,8 @@ func main() {
 	//call the returned function when sync is not needed anymore.
 	func() {
 		defer media.GetSynchronous()()
+		//The line termination is appended to the message.
 		err = media.Send(*message, "")
-		//Send EOP
-		err = media.Send("\n", "")
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "error:", err)
 			return
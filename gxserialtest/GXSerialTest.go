@@ -0,0 +1,153 @@
+package gxserialtest
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurux/gxcommon-go"
+	gxserial "github.com/Gurux/gxserial-go"
+)
+
+// PairEnv is the environment variable that names an existing virtual port pair, e.g. "COM10,COM11".
+// It's used instead of starting socat or looking up com0com.
+const PairEnv = "GXSERIALTEST_PAIR"
+
+// DefaultTimeout is the time the assert helpers wait for the data.
+const DefaultTimeout = 2 * time.Second
+
+// Pair is a connected pair of virtual serial ports. Data written to one port is read from the other.
+type Pair struct {
+	// A is the name of the first port.
+	A string
+	// B is the name of the second port.
+	B string
+}
+
+// NewPair creates a virtual port pair for the test.
+// The pair is given in the GXSERIALTEST_PAIR environment variable, or it's created with socat
+// on Linux and macOS and looked up from com0com on Windows.
+// The test is skipped if no pair is available. The pair is removed when the test ends.
+func NewPair(tb testing.TB) *Pair {
+	tb.Helper()
+	if v := os.Getenv(PairEnv); v != "" {
+		a, b, ok := strings.Cut(v, ",")
+		if !ok {
+			tb.Fatalf("%s must be two comma separated ports: %q", PairEnv, v)
+		}
+		return &Pair{A: strings.TrimSpace(a), B: strings.TrimSpace(b)}
+	}
+	a, b, err := newPair(tb)
+	if err != nil {
+		tb.Skipf("virtual serial port pair is not available: %v", err)
+	}
+	return &Pair{A: a, B: b}
+}
+
+// Open opens both ports of the pair with 9600 8N1 and returns the media.
+// The media are closed when the test ends.
+func (p *Pair) Open(tb testing.TB) (*gxserial.GXSerial, *gxserial.GXSerial) {
+	tb.Helper()
+	return Open(tb, p.A), Open(tb, p.B)
+}
+
+// Open opens the serial port with 9600 8N1 and returns the media.
+// The media is closed when the test ends.
+func Open(tb testing.TB, port string) *gxserial.GXSerial {
+	tb.Helper()
+	m := gxserial.NewGXSerial(port, gxcommon.BaudRate9600, 8, gxcommon.ParityNone, gxcommon.StopBitsOne)
+	if err := m.Open(); err != nil {
+		tb.Fatalf("failed to open %s: %v", port, err)
+	}
+	tb.Cleanup(func() {
+		_ = m.Close()
+	})
+	return m
+}
+
+// Receive waits for the frame that ends with the EOP, or for count bytes if the EOP is nil.
+// The test fails if the frame is not received before the timeout elapses. Zero timeout uses DefaultTimeout.
+// Data that is received before the call is kept only if the media is already in synchronous mode.
+func Receive(tb testing.TB, m *gxserial.GXSerial, eop any, count int, timeout time.Duration) []byte {
+	tb.Helper()
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if !m.IsSynchronous() {
+		defer m.GetSynchronous()()
+	}
+	args := gxcommon.ReceiveParameters{EOP: eop, Count: count, ReplyType: gxcommon.DataTypeBytes}
+	ok, err := m.ReceiveWait(&args, timeout)
+	if err != nil {
+		tb.Fatalf("receive from %s failed: %v", m.Port, err)
+	}
+	if !ok {
+		tb.Fatalf("no frame received from %s in %v", m.Port, timeout)
+	}
+	ret, _ := args.Reply.([]byte)
+	return ret
+}
+
+// ExpectFrame fails the test if the next frame that m receives is not want.
+// The frame ends with the EOP, or it's len(want) bytes if the EOP is nil.
+func ExpectFrame(tb testing.TB, m *gxserial.GXSerial, eop any, want []byte, timeout time.Duration) {
+	tb.Helper()
+	if got := Receive(tb, m, eop, len(want), timeout); !bytes.Equal(got, want) {
+		tb.Fatalf("%s received %x, want %x", m.Port, got, want)
+	}
+}
+
+// Exchange sends the request from a to b and the reply from b back to a.
+// The test fails if either frame is not received as it was sent.
+// The frames end with the EOP, or the whole frame is waited if the EOP is nil.
+func Exchange(tb testing.TB, a, b *gxserial.GXSerial, eop any, request, reply []byte) {
+	tb.Helper()
+	release := b.GetSynchronous()
+	if err := a.Send(request, ""); err != nil {
+		release()
+		tb.Fatalf("send to %s failed: %v", a.Port, err)
+	}
+	ExpectFrame(tb, b, eop, request, 0)
+	release()
+	release = a.GetSynchronous()
+	defer release()
+	if err := b.Send(reply, ""); err != nil {
+		tb.Fatalf("send to %s failed: %v", b.Port, err)
+	}
+	ExpectFrame(tb, a, eop, reply, 0)
+}
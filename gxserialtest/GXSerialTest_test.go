@@ -0,0 +1,52 @@
+package gxserialtest
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"testing"
+
+	gxserial "github.com/Gurux/gxserial-go"
+)
+
+// TestPairExchange sends frames both ways over a virtual port pair with the platform handlers.
+// It's skipped if socat or com0com is not available and GXSERIALTEST_PAIR is not set.
+func TestPairExchange(t *testing.T) {
+	pair := NewPair(t)
+	a, b := pair.Open(t)
+	Exchange(t, a, b, byte(0x7E), []byte{1, 2, 0x7E}, []byte{3, 4, 0x7E})
+	Exchange(t, a, b, gxserial.HdlcFrame{}, []byte{0x7E, 0xA0, 0x05, 0x03, 0x21, 0x93, 0x7E},
+		[]byte{0x7E, 0xA0, 0x05, 0x21, 0x03, 0x73, 0x7E})
+	// Without the EOP the whole frame is waited.
+	Exchange(t, a, b, nil, []byte{5, 6, 7}, []byte{8, 9})
+}
@@ -0,0 +1,16 @@
+// Package gxserialtest provides helpers for integration tests that use real serial port handlers
+// without hardware.
+//
+// NewPair creates a pair of connected virtual serial ports. socat is used on Linux and macOS
+// and an installed com0com pair on Windows. An existing pair can be given in the
+// GXSERIALTEST_PAIR environment variable, e.g. "COM10,COM11". The test is skipped
+// if no pair is available.
+//
+// Example
+//
+//	func TestExchange(t *testing.T) {
+//	    pair := gxserialtest.NewPair(t)
+//	    a, b := pair.Open(t)
+//	    gxserialtest.Exchange(t, a, b, byte(0x7E), []byte{1, 2, 0x7E}, []byte{3, 4, 0x7E})
+//	}
+package gxserialtest
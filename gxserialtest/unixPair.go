@@ -0,0 +1,96 @@
+//go:build !windows
+
+package gxserialtest
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pairStartTimeout is the maximum time to wait for socat to create the pseudo terminals.
+const pairStartTimeout = 5 * time.Second
+
+// newPair starts socat that connects two pseudo terminals.
+// socat is stopped when the test ends.
+func newPair(tb testing.TB) (string, string, error) {
+	path, err := exec.LookPath("socat")
+	if err != nil {
+		return "", "", err
+	}
+	cmd := exec.Command(path, "-d", "-d", "pty,raw,echo=0", "pty,raw,echo=0")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return "", "", err
+	}
+	if err = cmd.Start(); err != nil {
+		return "", "", err
+	}
+	tb.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	//socat logs the names of the created terminals: "... N PTY is /dev/pts/3".
+	ports := make(chan string, 2)
+	go func() {
+		s := bufio.NewScanner(stderr)
+		for s.Scan() {
+			if _, name, ok := strings.Cut(s.Text(), "PTY is "); ok {
+				ports <- strings.TrimSpace(name)
+			}
+		}
+		close(ports)
+	}()
+	var names []string
+	timer := time.NewTimer(pairStartTimeout)
+	defer timer.Stop()
+	for len(names) != 2 {
+		select {
+		case name, ok := <-ports:
+			if !ok {
+				return "", "", errors.New("socat exited before the terminals were created")
+			}
+			names = append(names, name)
+		case <-timer.C:
+			return "", "", fmt.Errorf("socat didn't create the terminals in %v", pairStartTimeout)
+		}
+	}
+	return names[0], names[1], nil
+}
@@ -0,0 +1,107 @@
+//go:build windows
+
+package gxserialtest
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Gurux/gxserial-go"
+)
+
+// setupc returns the path of the com0com setup utility.
+func setupc() (string, error) {
+	if path, err := exec.LookPath("setupc.exe"); err == nil {
+		return path, nil
+	}
+	for _, dir := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, "com0com", "setupc.exe")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("com0com is not installed")
+}
+
+// newPair returns the first port pair that is installed with com0com.
+// Creating pairs needs administrator rights, so an existing pair is used.
+// The pair is found from the device metadata, and setupc is used if the ports are not present.
+func newPair(testing.TB) (string, string, error) {
+	if a, b, err := gxserial.FindVirtualPair(""); err == nil {
+		return a, b, nil
+	}
+	path, err := setupc()
+	if err != nil {
+		return "", "", err
+	}
+	cmd := exec.Command(path, "list")
+	cmd.Dir = filepath.Dir(path)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", err
+	}
+	//setupc lists the ports of the pair: "CNCA0 PortName=COM10" and "CNCB0 PortName=COM11".
+	names := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, it := range strings.Split(fields[1], ",") {
+			if name, ok := strings.CutPrefix(it, "PortName="); ok && name != "-" {
+				names[fields[0]] = name
+			}
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(names)) {
+		if num, ok := strings.CutPrefix(id, "CNCA"); ok {
+			a := names[id]
+			if b, ok := names["CNCB"+num]; ok {
+				return a, b, nil
+			}
+		}
+	}
+	return "", "", errors.New("com0com has no port pairs")
+}
@@ -40,6 +40,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 	"unsafe"
 
 	"github.com/Gurux/gxcommon-go"
@@ -51,31 +53,45 @@ type port struct {
 	fd int
 	r  *os.File
 	w  *os.File
+	// Time when the data of the last read arrived.
+	readTime time.Time
+	// Logger of the media.
+	log portLogger
 }
 
 // toUnitBaudrate maps a baud rate to the corresponding constant in the unix package.
 var toUnitBaudrate = map[int]uint32{
-	0:      unix.B0,
-	50:     unix.B50,
-	75:     unix.B75,
-	110:    unix.B110,
-	134:    unix.B134,
-	150:    unix.B150,
-	200:    unix.B200,
-	300:    unix.B300,
-	600:    unix.B600,
-	1200:   unix.B1200,
-	1800:   unix.B1800,
-	2400:   unix.B2400,
-	4800:   unix.B4800,
-	9600:   unix.B9600,
-	19200:  unix.B19200,
-	38400:  unix.B38400,
-	57600:  unix.B57600,
-	115200: unix.B115200,
-	230400: unix.B230400,
-	460800: unix.B460800,
-	921600: unix.B921600,
+	0:       unix.B0,
+	50:      unix.B50,
+	75:      unix.B75,
+	110:     unix.B110,
+	134:     unix.B134,
+	150:     unix.B150,
+	200:     unix.B200,
+	300:     unix.B300,
+	600:     unix.B600,
+	1200:    unix.B1200,
+	1800:    unix.B1800,
+	2400:    unix.B2400,
+	4800:    unix.B4800,
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	500000:  unix.B500000,
+	576000:  unix.B576000,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+	1152000: unix.B1152000,
+	1500000: unix.B1500000,
+	2000000: unix.B2000000,
+	2500000: unix.B2500000,
+	3000000: unix.B3000000,
+	3500000: unix.B3500000,
+	4000000: unix.B4000000,
 }
 
 func applyTermiosSpeed(t *unix.Termios, speed uint32) {
@@ -93,6 +109,41 @@ func (p *port) isOpen() bool {
 	return p.f != nil
 }
 
+// supportedBaudRates returns the baud rates of toUnitBaudrate in ascending order.
+func supportedBaudRates() []gxcommon.BaudRate {
+	var ret []gxcommon.BaudRate
+	for k := range toUnitBaudrate {
+		if k != 0 {
+			ret = append(ret, gxcommon.BaudRate(k))
+		}
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// capabilities returns the capabilities of the platform.
+// If the port is open, the native RS-485 support of the driver is probed.
+func (p *port) capabilities() Capabilities {
+	ret := Capabilities{
+		MarkSpaceParity: true,
+		NineBitReceive:  true,
+		ControlLines:    true,
+		ModemStatus:     true,
+		Break:           true,
+		Loopback:        true,
+		BaudRates:       supportedBaudRates(),
+	}
+	if p.isOpen() {
+		var buf [8]uint32
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(p.fd), uintptr(unix.TIOCGRS485), uintptr(unsafe.Pointer(&buf)))
+		ret.RS485 = errno == 0
+		var ss serialStruct
+		_, _, errno = unix.Syscall(unix.SYS_IOCTL, uintptr(p.fd), uintptr(unix.TIOCGSERIAL), uintptr(unsafe.Pointer(&ss)))
+		ret.LowLatency = errno == 0
+	}
+	return ret
+}
+
 // getPortNames returns a list of available serial port device paths on Linux.
 func getPortNames() ([]string, error) {
 	patterns := []string{
@@ -124,21 +175,31 @@ func getPortNames() ([]string, error) {
 }
 
 func openPort(cfg *GXSerial) error {
-	fd, err := unix.Open(cfg.Port, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0666)
+	mode := unix.O_RDWR
+	if cfg.readOnly {
+		mode = unix.O_RDONLY
+	}
+	fd, err := unix.Open(cfg.Port, mode|unix.O_NOCTTY|unix.O_NONBLOCK, 0666)
 	if err != nil {
-		return err
+		return cfg.openFailed("open", err, err)
 	}
+	cfg.openStep("open", "%s fd %d", cfg.Port, fd)
 
 	f := os.NewFile(uintptr(fd), cfg.Port)
-	cfg.s = port{f: f, fd: fd}
+	cfg.s = port{f: f, fd: fd, log: &cfg.logger}
 
 	// (iflag, oflag, cflag, lflag, ispeed, ospeed, cc) = tcgetattr
 	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
 	if err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("tcgetattr", err, err)
 	}
+	cfg.openStep("tcgetattr", "iflag %#x oflag %#x cflag %#x lflag %#x", t.Iflag, t.Oflag, t.Cflag, t.Lflag)
 	t.Cflag |= unix.CLOCAL | unix.CREAD
+	if cfg.readOnly {
+		// Control lines are not dropped when the port is closed.
+		t.Cflag &^= unix.HUPCL
+	}
 	t.Lflag &^= unix.ICANON | unix.ECHO | unix.ECHOE | unix.ECHOK | unix.ECHONL | unix.ISIG | unix.IEXTEN
 	t.Oflag &^= unix.OPOST | unix.ONLCR | unix.OCRNL
 	t.Iflag &^= unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IGNBRK
@@ -146,7 +207,8 @@ func openPort(cfg *GXSerial) error {
 	speed, ok := toUnitBaudrate[int(cfg.baudRate)]
 	if !ok || speed == unix.B0 {
 		cfg.s.close()
-		return fmt.Errorf("open failed. unsupported baud: %d", cfg.baudRate)
+		err = fmt.Errorf("open failed. unsupported baud: %d", cfg.baudRate)
+		return cfg.openFailed("baud rate", err, err)
 	}
 	applyTermiosSpeed(t, speed)
 	// Databits:
@@ -162,7 +224,8 @@ func openPort(cfg *GXSerial) error {
 		t.Cflag |= unix.CS8
 	default:
 		cfg.s.close()
-		return errors.New("invalid databits (must be 5..8)")
+		err = errors.New("invalid databits (must be 5..8)")
+		return cfg.openFailed("data bits", err, err)
 	}
 
 	// Stop bits
@@ -171,9 +234,15 @@ func openPort(cfg *GXSerial) error {
 		t.Cflag &^= unix.CSTOPB
 	case 2:
 		t.Cflag |= unix.CSTOPB
+	case gxcommon.StopBitsOnePointFive:
+		// termios has no 1.5 stop bits.
+		cfg.s.close()
+		err = cfg.unsupported("StopBits", cfg.stopBits)
+		return cfg.openFailed("stop bits", err, err)
 	default:
 		cfg.s.close()
-		return errors.New("invalid stopbits (must be 1 or 2)")
+		err = errors.New("invalid stopbits (must be 1 or 2)")
+		return cfg.openFailed("stop bits", err, err)
 	}
 
 	// setup parity
@@ -203,48 +272,133 @@ func openPort(cfg *GXSerial) error {
 	case gxcommon.ParityMark:
 		if !hasCMSPAR {
 			cfg.s.close()
-			return errors.New("mark parity requested but CMSPAR not supported")
+			err = errors.New("mark parity requested but CMSPAR not supported")
+			return cfg.openFailed("parity", err, err)
 		}
 		t.Cflag |= unix.PARENB | CMSPAR | unix.PARODD
 	case gxcommon.ParitySpace:
 		if !hasCMSPAR {
 			cfg.s.close()
-			return errors.New("space parity requested but CMSPAR not supported")
+			err = errors.New("space parity requested but CMSPAR not supported")
+			return cfg.openFailed("parity", err, err)
 		}
 		t.Cflag |= unix.PARENB | CMSPAR
 		t.Cflag &^= unix.PARODD
 	default:
 		cfg.s.close()
-		return errors.New("invalid parity")
+		err = errors.New("invalid parity")
+		return cfg.openFailed("parity", err, err)
 	}
 
-	t.Iflag &^= unix.IXON | unix.IXOFF
-	t.Cflag &^= unix.CRTSCTS
+	setHandshake(t, cfg.handshake)
 	if err := unix.IoctlSetTermios(fd, unix.TCSETS, t); err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("tcsetattr", err, err)
+	}
+	if cfg.openDiagnostics {
+		if applied, err := unix.IoctlGetTermios(fd, unix.TCGETS); err == nil {
+			cfg.openStep("tcsetattr", "requested cflag %#x speed %d, applied cflag %#x speed %d",
+				t.Cflag, t.Ospeed, applied.Cflag, applied.Ospeed)
+		}
 	}
 	if err := unix.IoctlSetInt(fd, unix.TCFLSH, unix.TCIFLUSH); err != nil {
-		return err
+		cfg.s.close()
+		return cfg.openFailed("tcflush", err, err)
 	}
+	cfg.openStep("tcflush", "input queue flushed")
 	cfg.s.r, cfg.s.w, err = os.Pipe()
 	if err != nil {
 		cfg.s.close()
-		return err
+		return cfg.openFailed("pipe", err, err)
+	}
+	cfg.s.logError("set non-blocking failed", unix.SetNonblock(int(cfg.s.r.Fd()), true))
+	if cfg.openDiagnostics {
+		if state, err := cfg.s.dumpState(); err == nil {
+			cfg.openStep("control lines", "RTS %t DTR %t CTS %t DSR %t DCD %t RI %t",
+				state.Rts, state.Dtr, state.Modem.Cts, state.Modem.Dsr, state.Modem.Dcd, state.Modem.Ri)
+		}
 	}
-	_ = unix.SetNonblock(int(cfg.s.r.Fd()), true)
 	return nil
 }
 
+// writev writes the buffers with a single writev call. Partial writes are continued.
+func (p *port) writev(bufs [][]byte) (int, error) {
+	if err := p.ensureOpen(); err != nil {
+		return 0, err
+	}
+	rc, err := p.f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	bufs = append([][]byte(nil), bufs...)
+	total := 0
+	var werr error
+	err = rc.Write(func(fd uintptr) bool {
+		for len(bufs) != 0 {
+			n, e := unix.Writev(int(fd), bufs)
+			if e == unix.EAGAIN {
+				// Wait until the port is writable.
+				return false
+			}
+			if isInterruptedSyscall(e) {
+				continue
+			}
+			if e != nil {
+				werr = e
+				return true
+			}
+			total += n
+			bufs = consumeBuffers(bufs, n)
+		}
+		return true
+	})
+	if werr != nil {
+		return total, werr
+	}
+	return total, err
+}
+
+// isTransientError returns true if the read can be retried after the error.
+func isTransientError(err error) bool {
+	return errors.Is(err, unix.EINTR) || errors.Is(err, unix.EAGAIN)
+}
+
+// isLineErrorAbort returns true if the driver aborted the read because of a line error.
+// Termios doesn't abort the reads, see setAbortOnError.
+func isLineErrorAbort(error) bool {
+	return false
+}
+
+// clearError clears the error state of the port. Nothing is needed on this system.
+func (p *port) clearError() error {
+	return nil
+}
+
+// setWriteDeadline sets the deadline for the following writes. Zero time disables the deadline.
+func (p *port) setWriteDeadline(t time.Time) error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	return p.f.SetWriteDeadline(t)
+}
+
+// interrupt wakes up the pending read so that the reader can stop before the port is closed.
+func (p *port) interrupt() {
+	if p != nil && p.w != nil {
+		_, err := p.w.Write([]byte{0})
+		p.logError("interrupt read failed", err)
+	}
+}
+
 func (p *port) close() error {
 	if p == nil {
 		return nil
 	}
 	if p.r != nil {
-		_ = p.r.Close()
+		p.logError("close pipe failed", p.r.Close())
 	}
 	if p.w != nil {
-		_ = p.w.Close()
+		p.logError("close pipe failed", p.w.Close())
 	}
 	if p.f != nil {
 		err := p.f.Close()
@@ -322,7 +476,7 @@ func (p *port) setParity(value gxcommon.Parity) error {
 	if err != nil {
 		return fmt.Errorf("setParity failed. %w", err)
 	}
-	t.Cflag &^= unix.PARENB | unix.PARODD
+	t.Cflag &^= unix.PARENB | unix.PARODD | unix.CMSPAR
 	switch value {
 	case gxcommon.ParityNone:
 		// nothing
@@ -330,12 +484,109 @@ func (p *port) setParity(value gxcommon.Parity) error {
 		t.Cflag |= unix.PARENB
 	case gxcommon.ParityOdd:
 		t.Cflag |= unix.PARENB | unix.PARODD
-	case gxcommon.ParityMark, gxcommon.ParitySpace:
-		return fmt.Errorf("mark/space parity not supported on this system")
+	case gxcommon.ParityMark:
+		t.Cflag |= unix.PARENB | unix.CMSPAR | unix.PARODD
+	case gxcommon.ParitySpace:
+		t.Cflag |= unix.PARENB | unix.CMSPAR
+	default:
+		return fmt.Errorf("setParity failed. invalid value: %d", value)
+	}
+	return p.setTermios(t)
+}
+
+// setNineBitReceive enables or disables marking of the received bytes whose 9th bit is set.
+// Space parity is used and the bytes with a parity error are prefixed with 0xFF 0x00.
+func (p *port) setNineBitReceive(on bool) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return fmt.Errorf("setNineBitReceive failed. %w", err)
+	}
+	if on {
+		t.Cflag &^= unix.PARODD
+		t.Cflag |= unix.PARENB | unix.CMSPAR
+		t.Iflag &^= unix.IGNPAR | unix.ISTRIP
+		t.Iflag |= unix.INPCK | unix.PARMRK
+	} else {
+		t.Iflag &^= unix.INPCK | unix.PARMRK
+	}
+	return p.setTermios(t)
+}
+
+// setHandshake sets the flow control flags of the termios.
+func setHandshake(t *unix.Termios, value Handshake) {
+	t.Iflag &^= unix.IXON | unix.IXOFF
+	t.Cflag &^= unix.CRTSCTS
+	if value.rtsCts() {
+		t.Cflag |= unix.CRTSCTS
+	}
+	if value.xonXoff() {
+		t.Iflag |= unix.IXON | unix.IXOFF
+		t.Cc[unix.VSTART] = 0x11
+		t.Cc[unix.VSTOP] = 0x13
+	}
+}
+
+// setHandshake sets the flow control of the opened port.
+func (p *port) setHandshake(value Handshake) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return err
+	}
+	setHandshake(t, value)
+	return p.setTermios(t)
+}
+
+// setDiscardNull returns an error because termios can't discard the null bytes.
+func (p *port) setDiscardNull(bool) error {
+	return errors.New("null byte discarding not supported on this system")
+}
+
+// setAbortOnError returns an error because termios can't abort the pending I/O on a line error.
+func (p *port) setAbortOnError(bool) error {
+	return errors.New("abort on error not supported on this system")
+}
+
+// parityMarking is true if the driver marks the received bytes with a parity error
+// and the marks are replaced with the parity error character.
+const parityMarking = true
+
+// setParityErrorChar enables or disables marking of the received bytes with a parity error.
+// The bytes with a parity error are prefixed with 0xFF 0x00 and 0xFF bytes are doubled.
+func (p *port) setParityErrorChar(on bool, _ byte) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return fmt.Errorf("setParityErrorChar failed. %w", err)
+	}
+	if on {
+		t.Iflag &^= unix.IGNPAR | unix.ISTRIP
+		t.Iflag |= unix.INPCK | unix.PARMRK
+	} else {
+		t.Iflag &^= unix.INPCK | unix.PARMRK
+	}
+	return p.setTermios(t)
+}
+
+// setBreakDetection enables or disables marking of the received breaks.
+// A break is received as 0xFF 0x00 0x00 and 0xFF bytes are doubled.
+func (p *port) setBreakDetection(on bool) error {
+	t, err := p.getTermios()
+	if err != nil {
+		return fmt.Errorf("setBreakDetection failed. %w", err)
+	}
+	if on {
+		t.Iflag &^= unix.IGNBRK | unix.BRKINT
+		t.Iflag |= unix.PARMRK
+	} else {
+		t.Iflag &^= unix.PARMRK
 	}
 	return p.setTermios(t)
 }
 
+// takeBreak returns false because the breaks are marked in the received data.
+func (p *port) takeBreak() (bool, error) {
+	return false, nil
+}
+
 func (p *port) getStopBits() (int, error) {
 	t, err := p.getTermios()
 	if err != nil {
@@ -400,6 +651,17 @@ func (p *port) setRtsEnable(on bool) error {
 	return p.setModemBit(unix.TIOCM_RTS, on)
 }
 
+// rtsToggleDriver is false because the driver can't toggle RTS and the application does it.
+const rtsToggleDriver = false
+
+// setRtsToggle lowers RTS when the application starts to toggle it.
+func (p *port) setRtsToggle(on bool) error {
+	if !on {
+		return nil
+	}
+	return p.setRtsEnable(false)
+}
+
 func (p *port) getDtrEnable() (bool, error) {
 	if err := p.ensureOpen(); err != nil {
 		return false, err
@@ -415,6 +677,263 @@ func (p *port) setDtrEnable(on bool) error {
 	return p.setModemBit(unix.TIOCM_DTR, on)
 }
 
+// waitModemChange waits until CTS, DSR, DCD or RI changes state.
+func (p *port) waitModemChange() error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	err := unix.IoctlSetInt(p.fd, unix.TIOCMIWAIT, unix.TIOCM_CTS|unix.TIOCM_DSR|unix.TIOCM_CD|unix.TIOCM_RNG)
+	switch {
+	case err == nil, errors.Is(err, unix.EINTR):
+		return nil
+	case errors.Is(err, unix.ENOTTY), errors.Is(err, unix.EINVAL):
+		return errModemWaitUnsupported
+	}
+	return fmt.Errorf("waitModemChange failed: %w", err)
+}
+
+// setBreak starts or ends the break condition.
+func (p *port) setBreak(on bool) error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	req := uint(unix.TIOCCBRK)
+	if on {
+		req = unix.TIOCSBRK
+	}
+	if err := unix.IoctlSetInt(p.fd, req, 0); err != nil {
+		return fmt.Errorf("setBreak failed: %w", err)
+	}
+	return nil
+}
+
+func (p *port) getModemStatus() (ModemStatus, error) {
+	if err := p.ensureOpen(); err != nil {
+		return ModemStatus{}, err
+	}
+	status, err := unix.IoctlGetInt(p.fd, unix.TIOCMGET)
+	if err != nil {
+		return ModemStatus{}, fmt.Errorf("getModemStatus failed: %w", err)
+	}
+	return ModemStatus{
+		Cts: (status & unix.TIOCM_CTS) != 0,
+		Dsr: (status & unix.TIOCM_DSR) != 0,
+		Dcd: (status & unix.TIOCM_CD) != 0,
+		Ri:  (status & unix.TIOCM_RI) != 0,
+	}, nil
+}
+
+// dumpState returns the effective termios flags, control line states and queue levels.
+func (p *port) dumpState() (*PortState, error) {
+	t, err := p.getTermios()
+	if err != nil {
+		return nil, err
+	}
+	ret := &PortState{}
+	ret.add("c_iflag", "%#x", t.Iflag)
+	ret.add("c_oflag", "%#x", t.Oflag)
+	ret.add("c_cflag", "%#x", t.Cflag)
+	ret.add("c_lflag", "%#x", t.Lflag)
+	ret.add("ispeed", "%d", t.Ispeed)
+	ret.add("ospeed", "%d", t.Ospeed)
+	ret.add("CSIZE", "%#x", t.Cflag&unix.CSIZE)
+	ret.add("CSTOPB", "%t", t.Cflag&unix.CSTOPB != 0)
+	ret.add("PARENB", "%t", t.Cflag&unix.PARENB != 0)
+	ret.add("PARODD", "%t", t.Cflag&unix.PARODD != 0)
+	ret.add("CRTSCTS", "%t", t.Cflag&unix.CRTSCTS != 0)
+	ret.add("IXON", "%t", t.Iflag&unix.IXON != 0)
+	ret.add("IXOFF", "%t", t.Iflag&unix.IXOFF != 0)
+	if ret.Rts, err = p.getRtsEnable(); err != nil {
+		return nil, err
+	}
+	if ret.Dtr, err = p.getDtrEnable(); err != nil {
+		return nil, err
+	}
+	if ret.Modem, err = p.getModemStatus(); err != nil {
+		return nil, err
+	}
+	if ret.BytesToRead, err = p.getBytesToRead(); err != nil {
+		return nil, err
+	}
+	if ret.BytesToWrite, err = p.getBytesToWrite(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// getFlowStatus returns why the transmission is held off.
+// POSIX has no query for the XOFF state. If IXON is set and the output queue doesn't drain during
+// the sample time while CTS doesn't hold the transmission, XOFF is assumed to be received.
+func (p *port) getFlowStatus(sample time.Duration) (FlowStatus, error) {
+	t, err := p.getTermios()
+	if err != nil {
+		return FlowStatus{}, err
+	}
+	pending, err := p.getBytesToWrite()
+	if err != nil {
+		return FlowStatus{}, err
+	}
+	ret := FlowStatus{Pending: pending}
+	if pending == 0 {
+		return ret, nil
+	}
+	if t.Cflag&unix.CRTSCTS != 0 {
+		status, err := p.getModemStatus()
+		if err != nil {
+			return FlowStatus{}, err
+		}
+		ret.CtsHold = !status.Cts
+	}
+	if !ret.CtsHold && t.Iflag&unix.IXON != 0 {
+		time.Sleep(sample)
+		if ret.Pending, err = p.getBytesToWrite(); err != nil {
+			return FlowStatus{}, err
+		}
+		ret.XoffHold = ret.Pending != 0 && ret.Pending >= pending
+	}
+	return ret, nil
+}
+
+// waitTxEmpty waits until the last byte is shifted out of the transmitter.
+// The line status register is polled. If the driver doesn't support it, the output queue is polled
+// and one character time is waited after it's empty.
+func (p *port) waitTxEmpty(charTime time.Duration) error {
+	for {
+		if err := p.ensureOpen(); err != nil {
+			return err
+		}
+		lsr, err := unix.IoctlGetInt(p.fd, unix.TIOCSERGETLSR)
+		if err == nil {
+			if lsr&unix.TIOCSER_TEMT != 0 {
+				return nil
+			}
+		} else {
+			n, err := p.getBytesToWrite()
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				time.Sleep(charTime)
+				return nil
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// flush discards the data in the input and output queues.
+func (p *port) flush() error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	if err := unix.IoctlSetInt(p.fd, unix.TCFLSH, unix.TCIOFLUSH); err != nil {
+		return fmt.Errorf("tcflush failed: %w", err)
+	}
+	return nil
+}
+
+// serialRS485 is struct serial_rs485 of linux/serial.h.
+type serialRS485 struct {
+	flags              uint32
+	delayRtsBeforeSend uint32
+	delayRtsAfterSend  uint32
+	padding            [5]uint32
+}
+
+// serial_rs485 flags.
+const (
+	serRS485Enabled      = 1 << 0
+	serRS485RtsOnSend    = 1 << 1
+	serRS485RtsAfterSend = 1 << 2
+	serRS485RxDuringTx   = 1 << 4
+)
+
+// setRS485 sets the RS-485 mode of the driver with TIOCSRS485.
+func (p *port) setRS485(value *RS485) error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	var cfg serialRS485
+	if value.Enabled {
+		cfg.flags |= serRS485Enabled
+	}
+	if value.RtsOnSend {
+		cfg.flags |= serRS485RtsOnSend
+	}
+	if value.RtsAfterSend {
+		cfg.flags |= serRS485RtsAfterSend
+	}
+	if value.RxDuringTx {
+		cfg.flags |= serRS485RxDuringTx
+	}
+	cfg.delayRtsBeforeSend = uint32(value.DelayBeforeSend.Milliseconds())
+	cfg.delayRtsAfterSend = uint32(value.DelayAfterSend.Milliseconds())
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(p.fd), uintptr(unix.TIOCSRS485), uintptr(unsafe.Pointer(&cfg)))
+	if errno != 0 {
+		return fmt.Errorf("TIOCSRS485 failed: %w", errno)
+	}
+	return nil
+}
+
+// serialStruct is struct serial_struct of linux/serial.h.
+type serialStruct struct {
+	typ           int32
+	line          int32
+	port          uint32
+	irq           int32
+	flags         int32
+	xmitFifoSize  int32
+	customDivisor int32
+	baudBase      int32
+	closeDelay    uint16
+	ioType        int8
+	reservedChar  int8
+	hub6          int32
+	closingWait   uint16
+	closingWait2  uint16
+	iomemBase     uintptr
+	iomemRegShift uint16
+	portHigh      uint32
+	iomapBase     uintptr
+}
+
+// asyncLowLatency is ASYNC_LOW_LATENCY of linux/tty_flags.h.
+const asyncLowLatency = 1 << 13
+
+// setLowLatency sets the low latency mode of the driver with TIOCSSERIAL.
+// The driver passes the received data to the reader without buffering it.
+func (p *port) setLowLatency(value bool) error {
+	if err := p.ensureOpen(); err != nil {
+		return err
+	}
+	var ss serialStruct
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(p.fd), uintptr(unix.TIOCGSERIAL), uintptr(unsafe.Pointer(&ss)))
+	if errno != 0 {
+		return fmt.Errorf("TIOCGSERIAL failed: %w", errno)
+	}
+	if value {
+		ss.flags |= asyncLowLatency
+	} else {
+		ss.flags &^= asyncLowLatency
+	}
+	_, _, errno = unix.Syscall(unix.SYS_IOCTL, uintptr(p.fd), uintptr(unix.TIOCSSERIAL), uintptr(unsafe.Pointer(&ss)))
+	if errno != 0 {
+		return fmt.Errorf("TIOCSSERIAL failed: %w", errno)
+	}
+	return nil
+}
+
+// tiocmLoop is the loopback bit of the modem control register.
+const tiocmLoop = 0x8000
+
+// setLoopback enables or disables the loopback mode of the UART.
+func (p *port) setLoopback(on bool) error {
+	if err := p.setModemBit(tiocmLoop, on); err != nil {
+		return fmt.Errorf("setLoopback failed: %w", err)
+	}
+	return nil
+}
+
 func (p *port) setModemBit(bit int, on bool) error {
 	if err := p.ensureOpen(); err != nil {
 		return err
@@ -457,6 +976,8 @@ func (p *port) read() ([]byte, error) {
 	if (pfds[1].Revents & unix.POLLIN) != 0 {
 		return nil, nil
 	}
+	// The data is timestamped as soon as the poll wakes up.
+	arrived := time.Now()
 
 	cnt, _ := p.getBytesToRead()
 	if cnt <= 0 {
@@ -480,8 +1001,10 @@ func (p *port) read() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		p.readTime = arrived
 		return append(buf[:n], ret...), nil
 	}
+	p.readTime = arrived
 	return buf[:n], nil
 }
 
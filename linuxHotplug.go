@@ -0,0 +1,165 @@
+//go:build linux
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchPollInterval is the interval in which the device watcher checks if it's stopped.
+const watchPollInterval = 250 * time.Millisecond
+
+// deviceName returns the kernel name of the serial port device, e.g. ttyUSB0.
+// Symbolic links, e.g. /dev/serial/by-id, are resolved.
+func deviceName(port string) string {
+	if path, err := filepath.EvalSymlinks(port); err == nil {
+		port = path
+	}
+	return filepath.Base(port)
+}
+
+// deviceSerial returns the USB serial number of the serial port device. Empty if it's unknown.
+func deviceSerial(port string) string {
+	dir, err := filepath.EvalSymlinks(filepath.Join("/sys/class/tty", deviceName(port), "device"))
+	if err != nil {
+		return ""
+	}
+	for ; strings.HasPrefix(dir, "/sys/devices/"); dir = filepath.Dir(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, "serial")); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// uevent is a kernel device event.
+type uevent struct {
+	action    string
+	subsystem string
+	devName   string
+}
+
+// parseUevent parses a kernel uevent message,
+// e.g. "remove@/devices/...\x00ACTION=remove\x00SUBSYSTEM=tty\x00DEVNAME=ttyUSB0".
+func parseUevent(msg []byte) (uevent, bool) {
+	var ret uevent
+	fields := bytes.Split(msg, []byte{0})
+	if !bytes.Contains(fields[0], []byte("@")) {
+		return ret, false
+	}
+	for _, it := range fields[1:] {
+		key, value, ok := strings.Cut(string(it), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ACTION":
+			ret.action = value
+		case "SUBSYSTEM":
+			ret.subsystem = value
+		case "DEVNAME":
+			ret.devName = filepath.Base(value)
+		}
+	}
+	return ret, ret.action != ""
+}
+
+// watchDevice follows the kernel uevents of the serial port device.
+// It returns the function that stops the watcher.
+func watchDevice(g *GXSerial) (func(), error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, err
+	}
+	if err = unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: 1}); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	// Receive times out so that the stop is noticed.
+	tv := unix.NsecToTimeval(int64(watchPollInterval))
+	if err = unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	port := g.Port
+	name := deviceName(port)
+	serial := g.deviceSerial
+	var stopped atomic.Bool
+	go func() {
+		defer unix.Close(fd)
+		buf := make([]byte, 16*1024)
+		for !stopped.Load() {
+			n, _, err := unix.Recvfrom(fd, buf, 0)
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			if err != nil {
+				g.errorf(true, "hotplug", err)
+				return
+			}
+			e, ok := parseUevent(buf[:n])
+			if !ok || e.subsystem != "tty" || stopped.Load() {
+				continue
+			}
+			switch e.action {
+			case "remove":
+				if e.devName == name {
+					g.deviceRemoved()
+				}
+			case "add":
+				if serial != "" && e.devName != name && deviceSerial("/dev/"+e.devName) == serial {
+					// The device got a new name.
+					name = e.devName
+					port = "/dev/" + name
+					g.deviceAttached(port)
+				} else if e.devName == name && (serial == "" || deviceSerial(port) == serial) {
+					g.deviceAttached(port)
+				}
+			}
+		}
+	}()
+	return func() {
+		stopped.Store(true)
+	}, nil
+}
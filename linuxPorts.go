@@ -0,0 +1,137 @@
+//go:build linux
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lockDirs are the directories where UUCP-style lock files of the serial ports are kept.
+var lockDirs = []string{"/run/lock", "/var/lock"}
+
+// getPortsInfo returns the serial ports with the metadata that is read from sysfs.
+func getPortsInfo() ([]PortInfo, error) {
+	names, err := getPortNames()
+	if err != nil {
+		return nil, err
+	}
+	open := openDevices()
+	ret := make([]PortInfo, 0, len(names))
+	for _, name := range names {
+		info := PortInfo{Name: name}
+		readSysfsInfo(&info)
+		info.Busy = open[name] || portLocked(name)
+		ret = append(ret, info)
+	}
+	return ret, nil
+}
+
+// readSysfsInfo reads the USB attributes of the device, or the driver name if it's not a USB device.
+func readSysfsInfo(info *PortInfo) {
+	dir, err := filepath.EvalSymlinks(filepath.Join("/sys/class/tty", deviceName(info.Name), "device"))
+	if err != nil {
+		return
+	}
+	if driver, err := filepath.EvalSymlinks(filepath.Join(dir, "driver")); err == nil {
+		info.Description = filepath.Base(driver)
+	}
+	for ; strings.HasPrefix(dir, "/sys/devices/"); dir = filepath.Dir(dir) {
+		vid, err := strconv.ParseUint(sysfsAttr(dir, "idVendor"), 16, 16)
+		if err != nil {
+			continue
+		}
+		pid, _ := strconv.ParseUint(sysfsAttr(dir, "idProduct"), 16, 16)
+		info.VID = uint16(vid)
+		info.PID = uint16(pid)
+		if product := sysfsAttr(dir, "product"); product != "" {
+			info.Description = product
+		}
+		info.Manufacturer = sysfsAttr(dir, "manufacturer")
+		info.SerialNumber = sysfsAttr(dir, "serial")
+		return
+	}
+}
+
+// sysfsAttr returns the value of the sysfs attribute. Empty if it doesn't exist.
+func sysfsAttr(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// openDevices returns the devices that are open in the processes.
+// Only the processes whose file descriptors can be read are seen.
+func openDevices() map[string]bool {
+	ret := map[string]bool{}
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		if target, err := os.Readlink(fd); err == nil && strings.HasPrefix(target, "/dev/") {
+			ret[target] = true
+		}
+	}
+	return ret
+}
+
+// portLocked returns true if the port has a UUCP lock file of a running process.
+func portLocked(name string) bool {
+	for _, dir := range lockDirs {
+		data, err := os.ReadFile(filepath.Join(dir, "LCK.."+filepath.Base(name)))
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return true
+		}
+		if _, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// devicePath returns the device path of the serial port name, e.g. /dev/ttyUSB0 for ttyUSB0.
+func devicePath(name string) string {
+	if strings.Contains(name, "/") {
+		return name
+	}
+	return "/dev/" + name
+}
@@ -36,9 +36,13 @@ package gxserial
 // ---------------------------------------------------------------------------
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -52,12 +56,42 @@ type port struct {
 	ovRead  windows.Overlapped
 	ovWrite windows.Overlapped
 	closing windows.Handle
+	// Windows can't read back the output lines. Last set states are kept here.
+	rts bool
+	dtr bool
+	// Write deadline. Zero if writes don't time out.
+	writeDeadline time.Time
+	// Time when the data of the last read arrived.
+	readTime time.Time
+	// commMask is held while the comm event mask is set and its events are waited.
+	// The modem status lines and EV_TXEMPTY can't be waited at the same time.
+	commMask sync.Mutex
+	// commErrors collects the CE_ flags of ClearCommError until they are taken, e.g. by takeBreak.
+	commErrors atomic.Uint32
+	// Logger of the media.
+	log portLogger
 }
 
 func (p *port) isOpen() bool {
 	return p != nil && p.h != 0 && p.h != windows.InvalidHandle
 }
 
+// capabilities returns the capabilities of the platform.
+func (p *port) capabilities() Capabilities {
+	return Capabilities{
+		MarkSpaceParity:      true,
+		CustomBaudRate:       true,
+		OnePointFiveStopBits: true,
+		DiscardNull:          true,
+		AbortOnError:         true,
+		ControlLines:         true,
+		ModemStatus:          true,
+		Break:                true,
+		BaudRates: []gxcommon.BaudRate{110, 300, 600, 1200, 2400, 4800, 9600, 14400, 19200,
+			38400, 57600, 115200, 128000, 230400, 256000, 460800, 921600, 1000000, 2000000, 3000000, 4000000},
+	}
+}
+
 // getPortNames retrieves the list of available serial port names on a Windows system by querying the registry.
 func getPortNames() ([]string, error) {
 	const path = `HARDWARE\DEVICEMAP\SERIALCOMM`
@@ -91,6 +125,9 @@ func getPortNames() ([]string, error) {
 const (
 	dcbFBinary         = 1 << 0
 	dcbFParity         = 1 << 1
+	dcbFOutxCtsFlow    = 1 << 2
+	dcbFOutX           = 1 << 8
+	dcbFInX            = 1 << 9
 	dcbFErrorChar      = 1 << 10
 	dcbFNull           = 1 << 11
 	dcbFAbortOnError   = 1 << 14
@@ -104,10 +141,20 @@ const (
 	xoff byte = 0x13
 )
 
+// GetCommModemStatus values
+const (
+	msCtsOn  uint32 = 0x0010
+	msDsrOn  uint32 = 0x0020
+	msRingOn uint32 = 0x0040
+	msRlsdOn uint32 = 0x0080
+)
+
 // RTS/DTR control values (DCB 2-bit fields)
 const (
-	rtsControlDisable uint32 = 0
-	dtrControlDisable uint32 = 0
+	rtsControlDisable   uint32 = 0
+	rtsControlHandshake uint32 = 2
+	rtsControlToggle    uint32 = 3
+	dtrControlDisable   uint32 = 0
 )
 
 func setBinary(d *windows.DCB, on bool) {
@@ -154,6 +201,18 @@ func setDtrControl(d *windows.DCB, val uint32) {
 	d.Flags |= (val & 0x3) << 4
 }
 
+// setHandshake sets the flow control flags of the DCB.
+func setHandshake(d *windows.DCB, value Handshake) {
+	d.Flags &^= dcbFOutxCtsFlow | dcbFOutX | dcbFInX
+	if value.rtsCts() {
+		d.Flags |= dcbFOutxCtsFlow
+		setRtsControl(d, rtsControlHandshake)
+	}
+	if value.xonXoff() {
+		d.Flags |= dcbFOutX | dcbFInX
+	}
+}
+
 func (p *port) getCommState() (*windows.DCB, error) {
 	if !p.isOpen() {
 		return nil, errors.New("serial port is not open")
@@ -189,6 +248,8 @@ func (p *port) updateSettings(cfg *GXSerial) error {
 	switch cfg.stopBits {
 	case gxcommon.StopBitsOne:
 		d.StopBits = 0 // ONESTOPBIT
+	case gxcommon.StopBitsOnePointFive:
+		d.StopBits = 1 // ONE5STOPBITS
 	case gxcommon.StopBitsTwo:
 		d.StopBits = 2 // TWOSTOPBITS
 	default:
@@ -201,8 +262,11 @@ func (p *port) updateSettings(cfg *GXSerial) error {
 	setAbortOnError(d, false)
 	d.XonChar = xon
 	d.XoffChar = xoff
-	setRtsControl(d, rtsControlDisable)
-	setDtrControl(d, dtrControlDisable)
+	if !cfg.readOnly {
+		setRtsControl(d, rtsControlDisable)
+		setDtrControl(d, dtrControlDisable)
+	}
+	setHandshake(d, cfg.handshake)
 	return p.setCommState(d)
 }
 
@@ -232,6 +296,8 @@ func (p *port) setStopBits(value gxcommon.StopBits) error {
 	switch value {
 	case gxcommon.StopBitsOne:
 		d.StopBits = 0
+	case gxcommon.StopBitsOnePointFive:
+		d.StopBits = 1
 	case gxcommon.StopBitsTwo:
 		d.StopBits = 2
 	default:
@@ -246,26 +312,270 @@ func (p *port) setParity(value gxcommon.Parity) error {
 		return err
 	}
 	d.Parity = byte(value)
+	setParityCheck(d, value != gxcommon.ParityNone)
+	return p.setCommState(d)
+}
+
+// setHandshake sets the flow control of the opened port.
+func (p *port) setHandshake(value Handshake) error {
+	d, err := p.getCommState()
+	if err != nil {
+		return fmt.Errorf("setHandshake failed: %w", err)
+	}
+	if d.Flags&dcbFRtsControlMask == rtsControlHandshake<<12 {
+		setRtsControl(d, rtsControlDisable)
+	}
+	setHandshake(d, value)
+	return p.setCommState(d)
+}
+
+// setNineBitReceive returns an error because the driver doesn't mark the bytes with parity errors.
+func (p *port) setNineBitReceive(bool) error {
+	return errors.New("9-bit receive not supported on this system")
+}
+
+// parityMarking is true if the driver marks the received bytes with a parity error
+// and the marks are replaced with the parity error character.
+// The driver replaces the bytes itself.
+const parityMarking = false
+
+// setParityErrorChar sets the character that the driver uses for the bytes with a parity error.
+func (p *port) setParityErrorChar(on bool, value byte) error {
+	d, err := p.getCommState()
+	if err != nil {
+		return fmt.Errorf("setParityErrorChar failed: %w", err)
+	}
+	setErrorChar(d, on)
+	d.ErrorChar = value
 	return p.setCommState(d)
 }
 
+// setDiscardNull sets if the driver discards the received null bytes.
+func (p *port) setDiscardNull(on bool) error {
+	d, err := p.getCommState()
+	if err != nil {
+		return fmt.Errorf("setDiscardNull failed: %w", err)
+	}
+	setNull(d, on)
+	return p.setCommState(d)
+}
+
+// setAbortOnError sets if the driver aborts the pending reads and writes on a line error.
+func (p *port) setAbortOnError(on bool) error {
+	d, err := p.getCommState()
+	if err != nil {
+		return fmt.Errorf("setAbortOnError failed: %w", err)
+	}
+	setAbortOnError(d, on)
+	return p.setCommState(d)
+}
+
+func (p *port) setLoopback(bool) error {
+	return errors.New("loopback mode not supported on this system")
+}
+
+func (p *port) setLowLatency(bool) error {
+	return errors.New("low latency mode not supported on this system")
+}
+
+func (p *port) setRS485(*RS485) error {
+	return errors.New("RS-485 mode not supported on this system")
+}
+
+func (p *port) getRtsEnable() (bool, error) {
+	if !p.isOpen() {
+		return false, errors.New("serial port is not open")
+	}
+	return p.rts, nil
+}
+
+func (p *port) setRtsEnable(on bool) error {
+	f := uint32(windows.CLRRTS)
+	if on {
+		f = windows.SETRTS
+	}
+	if err := p.escapeCommFunction(f); err != nil {
+		return fmt.Errorf("setRtsEnable failed: %w", err)
+	}
+	p.rts = on
+	return nil
+}
+
+// rtsToggleDriver is true because the driver toggles RTS (RTS_CONTROL_TOGGLE).
+const rtsToggleDriver = true
+
+// setRtsToggle enables or disables RTS_CONTROL_TOGGLE. RTS is low when the data is not sent.
+func (p *port) setRtsToggle(on bool) error {
+	d, err := p.getCommState()
+	if err != nil {
+		return err
+	}
+	if on {
+		setRtsControl(d, rtsControlToggle)
+	} else {
+		setRtsControl(d, rtsControlDisable)
+	}
+	if err = p.setCommState(d); err != nil {
+		return fmt.Errorf("setRtsToggle failed: %w", err)
+	}
+	p.rts = false
+	return nil
+}
+
+func (p *port) getDtrEnable() (bool, error) {
+	if !p.isOpen() {
+		return false, errors.New("serial port is not open")
+	}
+	return p.dtr, nil
+}
+
+func (p *port) setDtrEnable(on bool) error {
+	f := uint32(windows.CLRDTR)
+	if on {
+		f = windows.SETDTR
+	}
+	if err := p.escapeCommFunction(f); err != nil {
+		return fmt.Errorf("setDtrEnable failed: %w", err)
+	}
+	p.dtr = on
+	return nil
+}
+
+// waitModemChange waits for the EV_CTS, EV_DSR, EV_RLSD or EV_RING event.
+func (p *port) waitModemChange() error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	p.commMask.Lock()
+	defer p.commMask.Unlock()
+	ev, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return fmt.Errorf("waitModemChange failed: %w", err)
+	}
+	defer windows.CloseHandle(ev)
+	if err = windows.SetCommMask(p.h, windows.EV_CTS|windows.EV_DSR|windows.EV_RLSD|windows.EV_RING); err != nil {
+		return fmt.Errorf("waitModemChange failed: %w", err)
+	}
+	var mask, n uint32
+	ov := windows.Overlapped{HEvent: ev}
+	err = windows.WaitCommEvent(p.h, &mask, &ov)
+	if errors.Is(err, windows.ERROR_IO_PENDING) {
+		handles := []windows.Handle{p.closing, ev}
+		idx, werr := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+		if werr != nil {
+			return fmt.Errorf("waitModemChange failed: %w", werr)
+		}
+		if idx == windows.WAIT_OBJECT_0 {
+			_ = windows.CancelIoEx(p.h, &ov)
+			_ = windows.GetOverlappedResult(p.h, &ov, &n, true)
+			return errors.New("serial port is not open")
+		}
+		err = windows.GetOverlappedResult(p.h, &ov, &n, true)
+	}
+	if err != nil {
+		return fmt.Errorf("waitModemChange failed: %w", err)
+	}
+	return nil
+}
+
+// setBreak starts or ends the break condition.
+func (p *port) setBreak(on bool) error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	var err error
+	if on {
+		err = windows.SetCommBreak(p.h)
+	} else {
+		err = windows.ClearCommBreak(p.h)
+	}
+	if err != nil {
+		return fmt.Errorf("setBreak failed: %w", err)
+	}
+	return nil
+}
+
+func (p *port) getModemStatus() (ModemStatus, error) {
+	if !p.isOpen() {
+		return ModemStatus{}, errors.New("serial port is not open")
+	}
+	var status uint32
+	if err := windows.GetCommModemStatus(p.h, &status); err != nil {
+		return ModemStatus{}, fmt.Errorf("getModemStatus failed: %w", err)
+	}
+	return ModemStatus{
+		Cts: (status & msCtsOn) != 0,
+		Dsr: (status & msDsrOn) != 0,
+		Dcd: (status & msRlsdOn) != 0,
+		Ri:  (status & msRingOn) != 0,
+	}, nil
+}
+
+// dumpState returns the effective DCB flags, control line states and queue levels.
+func (p *port) dumpState() (*PortState, error) {
+	d, err := p.getCommState()
+	if err != nil {
+		return nil, err
+	}
+	ret := &PortState{}
+	ret.add("BaudRate", "%d", d.BaudRate)
+	ret.add("ByteSize", "%d", d.ByteSize)
+	ret.add("Parity", "%d", d.Parity)
+	ret.add("StopBits", "%d", d.StopBits)
+	ret.add("Flags", "%#x", d.Flags)
+	ret.add("fBinary", "%t", d.Flags&dcbFBinary != 0)
+	ret.add("fParity", "%t", d.Flags&dcbFParity != 0)
+	ret.add("fDtrControl", "%d", (d.Flags&dcbFDtrControlMask)>>4)
+	ret.add("fRtsControl", "%d", (d.Flags&dcbFRtsControlMask)>>12)
+	ret.add("fErrorChar", "%t", d.Flags&dcbFErrorChar != 0)
+	ret.add("fNull", "%t", d.Flags&dcbFNull != 0)
+	ret.add("fAbortOnError", "%t", d.Flags&dcbFAbortOnError != 0)
+	ret.add("XonChar", "%#x", d.XonChar)
+	ret.add("XoffChar", "%#x", d.XoffChar)
+	ret.add("ErrorChar", "%#x", d.ErrorChar)
+	ret.Rts = p.rts
+	ret.Dtr = p.dtr
+	if ret.Modem, err = p.getModemStatus(); err != nil {
+		return nil, err
+	}
+	if ret.BytesToRead, err = p.getBytesToRead(); err != nil {
+		return nil, err
+	}
+	if ret.BytesToWrite, err = p.getBytesToWrite(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (p *port) escapeCommFunction(f uint32) error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	return windows.EscapeCommFunction(p.h, f)
+}
+
 func openPort(cfg *GXSerial) error {
 	if strings.TrimSpace(cfg.Port) == "" {
-		return errors.New("invalid serial port name")
+		err := errors.New("invalid serial port name")
+		return cfg.openFailed("port name", err, err)
 	}
 
-	cfg.s = port{}
+	cfg.s = port{log: &cfg.logger}
 
 	closing, err := windows.CreateEvent(nil, 1, 1, nil) // manual-reset=TRUE, initial=TRUE
 	if err != nil {
-		return fmt.Errorf("CreateEvent(closing) failed: %w", err)
+		return cfg.openFailed("CreateEvent(closing)", err, fmt.Errorf("CreateEvent(closing) failed: %w", err))
 	}
 	cfg.s.closing = closing
 
 	path := `\\.\` + cfg.Port
+	access := uint32(windows.GENERIC_READ | windows.GENERIC_WRITE)
+	if cfg.readOnly {
+		access = windows.GENERIC_READ
+	}
 	h, err := windows.CreateFile(
 		windows.StringToUTF16Ptr(path),
-		windows.GENERIC_READ|windows.GENERIC_WRITE,
+		access,
 		0,
 		nil,
 		windows.OPEN_EXISTING,
@@ -274,41 +584,54 @@ func openPort(cfg *GXSerial) error {
 	)
 	if err != nil {
 		_ = cfg.s.close()
-		return fmt.Errorf("failed to open port %q: %w", cfg.Port, err)
+		return cfg.openFailed("CreateFile", err, fmt.Errorf("failed to open port %q: %w", cfg.Port, err))
 	}
 	cfg.s.h = h
+	cfg.openStep("CreateFile", "%s", path)
 
 	er, err := windows.CreateEvent(nil, 0, 0, nil) // auto-reset
 	if err != nil {
 		_ = cfg.s.close()
-		return fmt.Errorf("CreateEvent(read) failed: %w", err)
+		return cfg.openFailed("CreateEvent(read)", err, fmt.Errorf("CreateEvent(read) failed: %w", err))
 	}
 	cfg.s.ovRead.HEvent = er
 
 	ew, err := windows.CreateEvent(nil, 0, 0, nil)
 	if err != nil {
 		_ = cfg.s.close()
-		return fmt.Errorf("CreateEvent(write) failed: %w", err)
+		return cfg.openFailed("CreateEvent(write)", err, fmt.Errorf("CreateEvent(write) failed: %w", err))
 	}
 	cfg.s.ovWrite.HEvent = ew
 
 	if err := windows.ResetEvent(cfg.s.closing); err != nil {
 		_ = cfg.s.close()
-		return fmt.Errorf("ResetEvent(closing) failed: %w", err)
+		return cfg.openFailed("ResetEvent(closing)", err, fmt.Errorf("ResetEvent(closing) failed: %w", err))
 	}
 
 	if err := cfg.s.updateSettings(cfg); err != nil {
 		_ = cfg.s.close()
-		return fmt.Errorf("failed to update serial port settings: %w", err)
+		return cfg.openFailed("SetCommState", err, fmt.Errorf("failed to update serial port settings: %w", err))
+	}
+	if cfg.openDiagnostics {
+		if d, err := cfg.s.getCommState(); err == nil {
+			cfg.openStep("SetCommState", "requested baud %d bits %d parity %d stop bits %d, applied baud %d bits %d parity %d stop bits %d flags %#x",
+				cfg.baudRate, cfg.dataBits, cfg.parity, cfg.stopBits, d.BaudRate, d.ByteSize, d.Parity, d.StopBits, d.Flags)
+		}
 	}
 
 	if err := windows.PurgeComm(cfg.s.h,
 		windows.PURGE_TXCLEAR|windows.PURGE_TXABORT|windows.PURGE_RXCLEAR|windows.PURGE_RXABORT,
 	); err != nil {
 		_ = cfg.s.close()
-		return fmt.Errorf("PurgeComm failed: %w", err)
+		return cfg.openFailed("PurgeComm", err, fmt.Errorf("PurgeComm failed: %w", err))
+	}
+	cfg.openStep("PurgeComm", "input and output queues purged")
+	if cfg.openDiagnostics {
+		if state, err := cfg.s.dumpState(); err == nil {
+			cfg.openStep("control lines", "RTS %t DTR %t CTS %t DSR %t DCD %t RI %t",
+				state.Rts, state.Dtr, state.Modem.Cts, state.Modem.Dsr, state.Modem.Dcd, state.Modem.Ri)
+		}
 	}
-
 	return nil
 }
 
@@ -317,10 +640,9 @@ func (p *port) getBytesToWrite() (int, error) {
 	if !p.isOpen() {
 		return 0, errors.New("serial port is not open")
 	}
-	var flags uint32
 	var st windows.ComStat
-	if err := windows.ClearCommError(p.h, &flags, &st); err != nil {
-		_ = p.close()
+	if _, err := p.clearCommError(&st); err != nil {
+		p.logError("close failed", p.close())
 		return 0, fmt.Errorf("getBytesToWrite failed: %w", err)
 	}
 	return int(st.CBOutQue), nil
@@ -330,11 +652,10 @@ func (p *port) getBytesToRead() (int, error) {
 	if !p.isOpen() {
 		return 0, errors.New("serial port is not open")
 	}
-	var flags uint32
 	var st windows.ComStat
-	if err := windows.ClearCommError(p.h, &flags, &st); err != nil {
+	if _, err := p.clearCommError(&st); err != nil {
 		if err != windows.ERROR_INVALID_HANDLE {
-			_ = p.close()
+			p.logError("close failed", p.close())
 			return 0, fmt.Errorf("getBytesToRead failed: %w", err)
 		}
 		return 0, nil
@@ -360,9 +681,10 @@ func (p *port) read() ([]byte, error) {
 
 	buf := make([]byte, count)
 	var n uint32
-	_ = windows.ResetEvent(p.ovRead.HEvent)
+	p.logError("reset read event failed", windows.ResetEvent(p.ovRead.HEvent))
 	err = windows.ReadFile(p.h, buf, &n, &p.ovRead)
 	if err == nil {
+		p.readTime = time.Now()
 		return buf[:n], nil
 	}
 	if !errors.Is(err, windows.ERROR_IO_PENDING) {
@@ -386,6 +708,8 @@ func (p *port) read() ([]byte, error) {
 	if idx == windows.WAIT_OBJECT_0 {
 		return nil, nil // closing
 	}
+	// The data is timestamped as soon as the wait ends.
+	arrived := time.Now()
 	if gerr := windows.GetOverlappedResult(p.h, &p.ovRead, &n, true); gerr != nil {
 		if errors.Is(gerr, windows.ERROR_OPERATION_ABORTED) {
 			return nil, nil
@@ -406,8 +730,10 @@ func (p *port) read() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		p.readTime = arrived
 		return append(buf[:n], ret...), nil
 	}
+	p.readTime = arrived
 	return buf[:n], nil
 }
 
@@ -421,7 +747,7 @@ func (p *port) write(data []byte) (int, error) {
 
 	var n uint32
 
-	_ = windows.ResetEvent(p.ovWrite.HEvent)
+	p.logError("reset write event failed", windows.ResetEvent(p.ovWrite.HEvent))
 
 	err := windows.WriteFile(p.h, data, &n, &p.ovWrite)
 	if err == nil {
@@ -436,6 +762,9 @@ func (p *port) write(data []byte) (int, error) {
 
 	if errors.Is(err, windows.ERROR_IO_PENDING) {
 		timeout := uint32((1 * time.Second) / time.Millisecond)
+		if !p.writeDeadline.IsZero() {
+			timeout = uint32(max(0, time.Until(p.writeDeadline)) / time.Millisecond)
+		}
 		handles := []windows.Handle{p.closing, p.ovWrite.HEvent}
 		idx, werr := windows.WaitForMultipleObjects(handles, false, timeout)
 		if werr != nil {
@@ -444,6 +773,11 @@ func (p *port) write(data []byte) (int, error) {
 		if idx == windows.WAIT_OBJECT_0 {
 			return 0, nil // closing
 		}
+		if idx == uint32(windows.WAIT_TIMEOUT) && !p.writeDeadline.IsZero() {
+			_ = windows.CancelIoEx(p.h, &p.ovWrite)
+			_ = windows.GetOverlappedResult(p.h, &p.ovWrite, &n, true)
+			return int(n), os.ErrDeadlineExceeded
+		}
 		if gerr := windows.GetOverlappedResult(p.h, &p.ovWrite, &n, true); gerr != nil {
 			if errors.Is(gerr, windows.ERROR_OPERATION_ABORTED) {
 				return 0, nil
@@ -456,6 +790,187 @@ func (p *port) write(data []byte) (int, error) {
 	return 0, fmt.Errorf("write failed: %w", err)
 }
 
+// isTransientError returns true if the read can be retried after the error.
+func isTransientError(err error) bool {
+	return errors.Is(err, windows.ERROR_IO_INCOMPLETE) ||
+		errors.Is(err, windows.ERROR_OPERATION_ABORTED) ||
+		errors.Is(err, windows.ERROR_SEM_TIMEOUT) ||
+		errors.Is(err, windows.ERROR_COUNTER_TIMEOUT) ||
+		errors.Is(err, windows.ERROR_MORE_DATA)
+}
+
+// isLineErrorAbort returns true if the driver aborted the read because of a line error.
+// The reads fail until the error is cleared with clearError.
+func isLineErrorAbort(err error) bool {
+	return errors.Is(err, windows.ERROR_OPERATION_ABORTED)
+}
+
+// clearError clears the communication error so that the following reads don't fail.
+func (p *port) clearError() error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	var stat windows.ComStat
+	_, err := p.clearCommError(&stat)
+	return err
+}
+
+// clearCommError clears the communication errors and returns them.
+// The errors are also collected to commErrors, because every ClearCommError call clears them.
+func (p *port) clearCommError(stat *windows.ComStat) (uint32, error) {
+	var errs uint32
+	if err := windows.ClearCommError(p.h, &errs, stat); err != nil {
+		return 0, err
+	}
+	p.commErrors.Or(errs)
+	return errs, nil
+}
+
+// ceBreak is the communication error of a received break.
+const ceBreak = 0x0010
+
+// setBreakDetection does nothing because the breaks are read from the communication errors.
+func (p *port) setBreakDetection(bool) error {
+	return nil
+}
+
+// takeBreak returns true if a break is received after the previous call. The communication errors are cleared.
+// The breaks that are cleared by the other ClearCommError calls are read from commErrors.
+func (p *port) takeBreak() (bool, error) {
+	if !p.isOpen() {
+		return false, errors.New("serial port is not open")
+	}
+	var stat windows.ComStat
+	if _, err := p.clearCommError(&stat); err != nil {
+		return false, fmt.Errorf("takeBreak failed: %w", err)
+	}
+	return p.commErrors.And(^uint32(ceBreak))&ceBreak != 0, nil
+}
+
+// COMSTAT flags of the held transmission.
+const (
+	comStatCtsHold  = 0x01
+	comStatDsrHold  = 0x02
+	comStatRlsdHold = 0x04
+	comStatXoffHold = 0x08
+	comStatXoffSent = 0x10
+)
+
+// getFlowStatus returns why the transmission is held off.
+func (p *port) getFlowStatus(time.Duration) (FlowStatus, error) {
+	if !p.isOpen() {
+		return FlowStatus{}, errors.New("serial port is not open")
+	}
+	var stat windows.ComStat
+	if _, err := p.clearCommError(&stat); err != nil {
+		return FlowStatus{}, fmt.Errorf("getFlowStatus failed: %w", err)
+	}
+	return FlowStatus{
+		CtsHold:  stat.Flags&comStatCtsHold != 0,
+		DsrHold:  stat.Flags&comStatDsrHold != 0,
+		DcdHold:  stat.Flags&comStatRlsdHold != 0,
+		XoffHold: stat.Flags&comStatXoffHold != 0,
+		XoffSent: stat.Flags&comStatXoffSent != 0,
+		Pending:  int(stat.CBOutQue),
+	}, nil
+}
+
+// waitTxEmpty waits for the EV_TXEMPTY event that tells that the last byte is sent.
+func (p *port) waitTxEmpty(time.Duration) error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	ev, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return fmt.Errorf("waitTxEmpty failed: %w", err)
+	}
+	defer windows.CloseHandle(ev)
+	for {
+		//The event is not raised if the output queue is already empty.
+		if n, err := p.getBytesToWrite(); err != nil || n == 0 {
+			return err
+		}
+		if !p.commMask.TryLock() {
+			//The comm event mask is used to wait the modem status lines and the queue is polled.
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		mask, err := p.waitTxEmptyEvent(ev)
+		p.commMask.Unlock()
+		if err != nil {
+			return err
+		}
+		if mask&windows.EV_TXEMPTY != 0 {
+			return nil
+		}
+	}
+}
+
+// waitTxEmptyEvent sets the EV_TXEMPTY mask and waits for a comm event. The caller holds commMask.
+func (p *port) waitTxEmptyEvent(ev windows.Handle) (uint32, error) {
+	if err := windows.SetCommMask(p.h, windows.EV_TXEMPTY); err != nil {
+		return 0, fmt.Errorf("waitTxEmpty failed: %w", err)
+	}
+	var mask, n uint32
+	ov := windows.Overlapped{HEvent: ev}
+	err := windows.WaitCommEvent(p.h, &mask, &ov)
+	if errors.Is(err, windows.ERROR_IO_PENDING) {
+		handles := []windows.Handle{p.closing, ev}
+		idx, werr := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+		if werr != nil {
+			return 0, fmt.Errorf("waitTxEmpty failed: %w", werr)
+		}
+		if idx == windows.WAIT_OBJECT_0 {
+			_ = windows.CancelIoEx(p.h, &ov)
+			_ = windows.GetOverlappedResult(p.h, &ov, &n, true)
+			return 0, errors.New("serial port is not open")
+		}
+		err = windows.GetOverlappedResult(p.h, &ov, &n, true)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("waitTxEmpty failed: %w", err)
+	}
+	return mask, nil
+}
+
+// flush discards the data in the input and output queues.
+func (p *port) flush() error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	if err := windows.PurgeComm(p.h, windows.PURGE_TXCLEAR|windows.PURGE_RXCLEAR); err != nil {
+		return fmt.Errorf("PurgeComm failed: %w", err)
+	}
+	return nil
+}
+
+// writev writes the buffers with a single overlapped write.
+func (p *port) writev(bufs [][]byte) (int, error) {
+	return p.write(bytes.Join(bufs, nil))
+}
+
+// setWriteDeadline sets the deadline for the following writes. Zero time disables the deadline.
+func (p *port) setWriteDeadline(t time.Time) error {
+	if !p.isOpen() {
+		return errors.New("serial port is not open")
+	}
+	p.writeDeadline = t
+	return nil
+}
+
+// interrupt wakes up the pending read so that the reader can stop before the port is closed.
+func (p *port) interrupt() {
+	if p == nil {
+		return
+	}
+	if p.closing != 0 {
+		p.logError("interrupt read failed", windows.SetEvent(p.closing))
+	}
+	if p.h != 0 && p.h != windows.InvalidHandle {
+		_ = windows.CancelIoEx(p.h, &p.ovRead)
+	}
+}
+
 func (p *port) close() error {
 	if p == nil {
 		return nil
@@ -0,0 +1,225 @@
+//go:build windows
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modcfgmgr32                  = windows.NewLazySystemDLL("cfgmgr32.dll")
+	procCMRegisterNotification   = modcfgmgr32.NewProc("CM_Register_Notification")
+	procCMUnregisterNotification = modcfgmgr32.NewProc("CM_Unregister_Notification")
+)
+
+var (
+	// guidDevClassPorts is the device setup class of the COM and LPT ports.
+	guidDevClassPorts = windows.GUID{Data1: 0x4D36E978, Data2: 0xE325, Data3: 0x11CE,
+		Data4: [8]byte{0xBF, 0xC1, 0x08, 0x00, 0x2B, 0xE1, 0x03, 0x18}}
+	// guidDevInterfaceComPort is the device interface class of the COM ports.
+	guidDevInterfaceComPort = windows.GUID{Data1: 0x86E0D1E0, Data2: 0x8089, Data3: 0x11D0,
+		Data4: [8]byte{0x9C, 0xE4, 0x08, 0x00, 0x3E, 0x30, 0x1F, 0x73}}
+)
+
+const (
+	cmNotifyFilterTypeDeviceInterface    = 0
+	cmNotifyActionDeviceInterfaceArrival = 0
+	cmNotifyActionDeviceInterfaceRemoval = 1
+)
+
+// cmNotifyFilter is CM_NOTIFY_FILTER for device interface notifications.
+type cmNotifyFilter struct {
+	size       uint32
+	flags      uint32
+	filterType uint32
+	reserved   uint32
+	classGUID  windows.GUID
+	// Rest of the union.
+	_ [384]byte
+}
+
+// cmNotifyEventData is CM_NOTIFY_EVENT_DATA of a device interface notification.
+type cmNotifyEventData struct {
+	filterType   uint32
+	reserved     uint32
+	classGUID    windows.GUID
+	symbolicLink [1]uint16
+}
+
+// deviceWatch is a registered device notification.
+type deviceWatch struct {
+	g *GXSerial
+	// Device instance ID of the opened port.
+	id string
+}
+
+var (
+	watchMu sync.Mutex
+	// Registered device notifications by the context value.
+	watches   = map[uintptr]*deviceWatch{}
+	nextWatch uintptr
+	// Callback is shared because callbacks are never released.
+	notifyCallback uintptr
+)
+
+func init() {
+	notifyCallback = windows.NewCallback(onDeviceNotify)
+}
+
+// portKey returns the port name without the device namespace prefix in upper case.
+func portKey(port string) string {
+	return strings.ToUpper(strings.TrimPrefix(port, `\\.\`))
+}
+
+// portInstanceIDs returns the device instance IDs of the present COM ports by the port name.
+func portInstanceIDs() (map[string]string, error) {
+	devices, err := portDevices()
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string, len(devices))
+	for name, dev := range devices {
+		if dev.instanceID != "" {
+			ret[name] = dev.instanceID
+		}
+	}
+	return ret, nil
+}
+
+// interfaceInstanceID returns the device instance ID of the device interface,
+// e.g. \\?\USB#VID_2341&PID_0043#75735#{86e0d1e0-...} is USB\VID_2341&PID_0043\75735.
+func interfaceInstanceID(symbolicLink string) string {
+	id := strings.TrimPrefix(symbolicLink, `\\?\`)
+	if pos := strings.LastIndex(id, "#{"); pos != -1 {
+		id = id[:pos]
+	}
+	return strings.ReplaceAll(id, "#", `\`)
+}
+
+// instanceSerial returns the serial number of the USB device instance ID, e.g. USB\VID_0403&PID_6001\A50285BI.
+// Empty is returned if the device has no serial number.
+func instanceSerial(id string) string {
+	parts := strings.Split(id, `\`)
+	if len(parts) != 3 || !strings.EqualFold(parts[0], "USB") || strings.Contains(parts[2], "&") {
+		return ""
+	}
+	return parts[2]
+}
+
+// deviceSerial returns the USB serial number of the COM port device. Empty if it's unknown.
+func deviceSerial(port string) string {
+	ids, err := portInstanceIDs()
+	if err != nil {
+		return ""
+	}
+	return instanceSerial(ids[portKey(port)])
+}
+
+// onDeviceNotify is called by the configuration manager when a COM port interface arrives or is removed.
+func onDeviceNotify(notify windows.Handle, context uintptr, action uint32, data *cmNotifyEventData, size uint32) uintptr {
+	watchMu.Lock()
+	w := watches[context]
+	watchMu.Unlock()
+	if w == nil || data == nil {
+		return 0
+	}
+	id := interfaceInstanceID(windows.UTF16PtrToString(&data.symbolicLink[0]))
+	if !strings.EqualFold(id, w.id) {
+		return 0
+	}
+	// Callback must return quickly.
+	switch action {
+	case cmNotifyActionDeviceInterfaceRemoval:
+		go w.g.deviceRemoved()
+	case cmNotifyActionDeviceInterfaceArrival:
+		go func() {
+			port := w.g.Port
+			if ids, err := portInstanceIDs(); err == nil {
+				for name, it := range ids {
+					if strings.EqualFold(it, id) {
+						port = name
+					}
+				}
+			}
+			w.g.deviceAttached(port)
+		}()
+	}
+	return 0
+}
+
+// watchDevice registers device interface notifications for the COM port.
+// The device is matched by the device instance ID. It returns the function that stops the notifications.
+func watchDevice(g *GXSerial) (func(), error) {
+	if err := procCMRegisterNotification.Find(); err != nil {
+		return nil, err
+	}
+	ids, err := portInstanceIDs()
+	if err != nil {
+		return nil, err
+	}
+	id, ok := ids[portKey(g.Port)]
+	if !ok {
+		return nil, fmt.Errorf("device instance of %s not found", g.Port)
+	}
+	filter := cmNotifyFilter{filterType: cmNotifyFilterTypeDeviceInterface, classGUID: guidDevInterfaceComPort}
+	filter.size = uint32(unsafe.Sizeof(filter))
+	watchMu.Lock()
+	nextWatch++
+	key := nextWatch
+	watches[key] = &deviceWatch{g: g, id: id}
+	watchMu.Unlock()
+	var handle uintptr
+	r, _, _ := procCMRegisterNotification.Call(uintptr(unsafe.Pointer(&filter)), key, notifyCallback,
+		uintptr(unsafe.Pointer(&handle)))
+	if r != 0 {
+		watchMu.Lock()
+		delete(watches, key)
+		watchMu.Unlock()
+		return nil, errors.New("CM_Register_Notification failed: " + fmt.Sprint(r))
+	}
+	return func() {
+		_, _, _ = procCMUnregisterNotification.Call(handle)
+		watchMu.Lock()
+		delete(watches, key)
+		watchMu.Unlock()
+	}, nil
+}
@@ -0,0 +1,175 @@
+//go:build windows
+
+package gxserial
+
+// --------------------------------------------------------------------------
+//
+//	Gurux Ltd
+//
+// Filename:        $HeadURL$
+//
+// Version:         $Revision$,
+//
+//	$Date$
+//	$Author$
+//
+// # Copyright (c) Gurux Ltd
+//
+// ---------------------------------------------------------------------------
+//
+//	DESCRIPTION
+//
+// This file is a part of Gurux Device Framework.
+//
+// Gurux Device Framework is Open Source software; you can redistribute it
+// and/or modify it under the terms of the GNU General Public License
+// as published by the Free Software Foundation; version 2 of the License.
+// Gurux Device Framework is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+// See the GNU General Public License for more details.
+//
+// More information of Gurux products: https://www.gurux.org
+//
+// This code is licensed under the GNU General Public License v2.
+// Full text may be retrieved at http://www.gnu.org/licenses/gpl-2.0.txt
+// ---------------------------------------------------------------------------
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// usbIDs finds the vendor and product IDs from the device instance ID,
+// e.g. USB\VID_0403&PID_6001\A50285BI or FTDIBUS\VID_0403+PID_6001+A50285BIA\0000.
+var usbIDs = regexp.MustCompile(`(?i)VID_([0-9A-F]{4})[&+]PID_([0-9A-F]{4})`)
+
+// portDevice is the device metadata of a COM port.
+type portDevice struct {
+	instanceID   string
+	description  string
+	manufacturer string
+}
+
+// guidDevClassCncPorts is the device setup class of the com0com ports.
+var guidDevClassCncPorts = windows.GUID{Data1: 0xDF799E12, Data2: 0x3C56, Data3: 0x421B,
+	Data4: [8]byte{0xB2, 0x98, 0xB6, 0xD3, 0x64, 0x2B, 0xC8, 0x78}}
+
+// portDevices returns the metadata of the present COM port and com0com port devices by the port name.
+func portDevices() (map[string]portDevice, error) {
+	ret := map[string]portDevice{}
+	if err := classPortDevices(&guidDevClassPorts, ret); err != nil {
+		return nil, err
+	}
+	// com0com isn't necessarily installed.
+	_ = classPortDevices(&guidDevClassCncPorts, ret)
+	return ret, nil
+}
+
+// classPortDevices adds the metadata of the present port devices of the setup class to ret.
+func classPortDevices(class *windows.GUID, ret map[string]portDevice) error {
+	devs, err := windows.SetupDiGetClassDevsEx(class, "", 0, windows.DIGCF_PRESENT, 0, "")
+	if err != nil {
+		return err
+	}
+	defer devs.Close()
+	for i := 0; ; i++ {
+		data, err := devs.EnumDeviceInfo(i)
+		if err != nil {
+			break
+		}
+		key, err := devs.OpenDevRegKey(data, windows.DICS_FLAG_GLOBAL, 0, windows.DIREG_DEV, windows.KEY_READ)
+		if err != nil {
+			continue
+		}
+		name, _, err := registry.Key(key).GetStringValue("PortName")
+		_ = registry.Key(key).Close()
+		if err != nil {
+			continue
+		}
+		var dev portDevice
+		dev.instanceID, _ = devs.DeviceInstanceID(data)
+		dev.description = deviceProperty(devs, data, windows.SPDRP_FRIENDLYNAME)
+		if dev.description == "" {
+			dev.description = deviceProperty(devs, data, windows.SPDRP_DEVICEDESC)
+		}
+		dev.manufacturer = deviceProperty(devs, data, windows.SPDRP_MFG)
+		ret[strings.ToUpper(name)] = dev
+	}
+	return nil
+}
+
+// virtualDevice returns true if the device instance ID is a virtual port, and the bus name of a com0com port,
+// e.g. COM0COM\PORT\CNCA0 or ROOT\PORTS\0000.
+func virtualDevice(id string) (bool, string) {
+	parts := strings.Split(id, `\`)
+	switch {
+	case strings.EqualFold(parts[0], "COM0COM"):
+		return true, strings.ToUpper(parts[len(parts)-1])
+	case strings.EqualFold(parts[0], "ROOT"):
+		return true, ""
+	}
+	return false, ""
+}
+
+// deviceProperty returns the string property of the device. Empty if it's not set.
+func deviceProperty(devs windows.DevInfo, data *windows.DevInfoData, property windows.SPDRP) string {
+	v, err := devs.DeviceRegistryProperty(data, property)
+	if err != nil {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// portBusy returns true if another process has the port open.
+// The port is opened without sharing and closed immediately, so DTR and RTS can change.
+func portBusy(name string) bool {
+	h, err := windows.CreateFile(windows.StringToUTF16Ptr(`\\.\`+name),
+		windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED) || errors.Is(err, windows.ERROR_SHARING_VIOLATION)
+	}
+	_ = windows.CloseHandle(h)
+	return false
+}
+
+// getPortsInfo returns the COM ports with the metadata of the device manager.
+func getPortsInfo() ([]PortInfo, error) {
+	names, err := getPortNames()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := portDevices()
+	if err != nil {
+		devices = map[string]portDevice{}
+	}
+	ret := make([]PortInfo, 0, len(names))
+	for _, name := range names {
+		info := PortInfo{Name: name, Busy: portBusy(name)}
+		if dev, ok := devices[portKey(name)]; ok {
+			info.Description = dev.description
+			info.Manufacturer = dev.manufacturer
+			info.Virtual, info.Bus = virtualDevice(dev.instanceID)
+			if m := usbIDs.FindStringSubmatch(dev.instanceID); m != nil {
+				vid, _ := strconv.ParseUint(m[1], 16, 16)
+				pid, _ := strconv.ParseUint(m[2], 16, 16)
+				info.VID = uint16(vid)
+				info.PID = uint16(pid)
+				info.SerialNumber = instanceSerial(dev.instanceID)
+			}
+		}
+		ret = append(ret, info)
+	}
+	return ret, nil
+}
+
+// devicePath returns the device path of the serial port name. COM port names are used as they are.
+func devicePath(name string) string {
+	return name
+}